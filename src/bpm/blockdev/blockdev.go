@@ -0,0 +1,100 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+// Package blockdev resolves filesystem paths to the block devices which back
+// them so that per-device I/O limits can be applied.
+package blockdev
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const sysDevBlock = "/sys/dev/block"
+
+// Device identifies a block device by its major and minor numbers.
+type Device struct {
+	Major int64
+	Minor int64
+}
+
+func (d Device) String() string {
+	return fmt.Sprintf("%d:%d", d.Major, d.Minor)
+}
+
+// Resolve returns the whole-disk block device which backs path. If path is a
+// block device node then that device is used, otherwise the device of the
+// filesystem containing path is used. Partitions are resolved to their parent
+// disk as the kernel only accepts I/O limits on whole disks.
+func Resolve(path string) (Device, error) {
+	return resolve(path, sysDevBlock)
+}
+
+func resolve(path, sysRoot string) (Device, error) {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return Device{}, fmt.Errorf("unable to stat %s: %w", path, err)
+	}
+
+	dev := st.Dev
+	if st.Mode&unix.S_IFMT == unix.S_IFBLK {
+		dev = st.Rdev
+	}
+
+	device := Device{
+		Major: int64(unix.Major(dev)),
+		Minor: int64(unix.Minor(dev)),
+	}
+
+	// Major number 0 is reserved for anonymous devices (tmpfs, overlay,
+	// etc.) which have no block device behind them.
+	if device.Major == 0 {
+		return Device{}, fmt.Errorf("%s is not backed by a block device", path)
+	}
+
+	return parentDisk(device, sysRoot)
+}
+
+func parentDisk(device Device, sysRoot string) (Device, error) {
+	devPath := filepath.Join(sysRoot, device.String())
+	if _, err := os.Stat(filepath.Join(devPath, "partition")); os.IsNotExist(err) {
+		return device, nil
+	} else if err != nil {
+		return Device{}, err
+	}
+
+	// The sysfs entry for a partition is a symlink to a directory nested
+	// inside the directory of its parent disk.
+	partPath, err := filepath.EvalSymlinks(devPath)
+	if err != nil {
+		return Device{}, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(partPath), "dev"))
+	if err != nil {
+		return Device{}, fmt.Errorf("unable to find parent disk of %s: %w", device, err)
+	}
+
+	var parent Device
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(data)), "%d:%d", &parent.Major, &parent.Minor); err != nil {
+		return Device{}, fmt.Errorf("unable to parse parent disk of %s: %w", device, err)
+	}
+
+	return parent, nil
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2019-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package blockdev
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Blockdev", func() {
+	Describe("parentDisk", func() {
+		var sysRoot string
+
+		BeforeEach(func() {
+			var err error
+			sysRoot, err = os.MkdirTemp("", "blockdev")
+			Expect(err).NotTo(HaveOccurred())
+
+			devices := filepath.Join(sysRoot, "devices", "block", "sdb")
+			Expect(os.MkdirAll(filepath.Join(devices, "sdb1"), 0700)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(devices, "dev"), []byte("8:16\n"), 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(devices, "sdb1", "dev"), []byte("8:17\n"), 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(devices, "sdb1", "partition"), []byte("1\n"), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(sysRoot, "dev", "block"), 0700)).To(Succeed())
+			Expect(os.Symlink(devices, filepath.Join(sysRoot, "dev", "block", "8:16"))).To(Succeed())
+			Expect(os.Symlink(filepath.Join(devices, "sdb1"), filepath.Join(sysRoot, "dev", "block", "8:17"))).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(sysRoot)).To(Succeed())
+		})
+
+		It("returns whole disks unchanged", func() {
+			dev, err := parentDisk(Device{Major: 8, Minor: 16}, filepath.Join(sysRoot, "dev", "block"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dev).To(Equal(Device{Major: 8, Minor: 16}))
+		})
+
+		It("resolves partitions to their parent disk", func() {
+			dev, err := parentDisk(Device{Major: 8, Minor: 17}, filepath.Join(sysRoot, "dev", "block"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dev).To(Equal(Device{Major: 8, Minor: 16}))
+		})
+	})
+
+	Describe("Resolve", func() {
+		It("returns an error when the path does not exist", func() {
+			_, err := Resolve("/does/not/exist")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error when the path is not backed by a block device", func() {
+			_, err := Resolve("/proc/self")
+			Expect(err).To(MatchError(ContainSubstring("not backed by a block device")))
+		})
+	})
+})
@@ -25,7 +25,9 @@ import (
 
 func main() {
 	if err := commands.RootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		if exitstatus.ShouldPrint(err) {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		}
 		os.Exit(exitstatus.FromError(err))
 	}
 
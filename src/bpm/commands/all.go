@@ -0,0 +1,230 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/spf13/cobra"
+
+	"bpm/config"
+	"bpm/exitstatus"
+	"bpm/hostlock"
+)
+
+// maxConcurrentProcesses is how many processes of a job a command run with
+// --all operates on at once.
+const maxConcurrentProcesses = 4
+
+var allProcesses bool
+
+func addAllFlag(cmd *cobra.Command, verb string) {
+	cmd.Flags().BoolVar(&allProcesses, "all", false, fmt.Sprintf("%s every process of the job", verb))
+}
+
+func validateAllInput(cmd *cobra.Command) error {
+	if allProcesses && cmd.Flags().Changed("process") {
+		return errors.New("--process cannot be used with --all")
+	}
+
+	return nil
+}
+
+type processAction func(bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error
+
+type lifecycleAction func(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error
+
+// processOrder is the order in which forEachProcess operates on the processes
+// of a job.
+type processOrder int
+
+const (
+	// anyOrder operates on every process at once.
+	anyOrder processOrder = iota
+
+	// dependenciesFirst operates on a process only once every process it
+	// depends on has succeeded. It is used when starting processes.
+	dependenciesFirst
+
+	// dependentsFirst operates on a process only once every process which
+	// depends on it has been operated on. It is used when stopping processes.
+	dependentsFirst
+)
+
+// forEachProcess runs the action against every process of the job in the
+// order given with at most maxConcurrentProcesses running at once. Every
+// process is attempted even if some fail (except for those whose dependencies
+// failed in dependenciesFirst order) and the failures are collected into a
+// single error.
+func forEachProcess(jobCfg *config.JobConfig, order processOrder, action processAction) error {
+	errs := make([]error, len(jobCfg.Processes))
+	sem := make(chan struct{}, maxConcurrentProcesses)
+
+	indices := map[string]int{}
+	done := map[string]chan struct{}{}
+	for i, procCfg := range jobCfg.Processes {
+		indices[procCfg.Name] = i
+		done[procCfg.Name] = make(chan struct{})
+	}
+
+	prerequisites := map[string][]string{}
+	for _, procCfg := range jobCfg.Processes {
+		for _, dep := range procCfg.DependsOn {
+			switch order {
+			case dependenciesFirst:
+				prerequisites[procCfg.Name] = append(prerequisites[procCfg.Name], dep)
+			case dependentsFirst:
+				prerequisites[dep] = append(prerequisites[dep], procCfg.Name)
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, procCfg := range jobCfg.Processes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[procCfg.Name])
+
+			for _, prerequisite := range prerequisites[procCfg.Name] {
+				<-done[prerequisite]
+
+				if order == dependenciesFirst && errs[indices[prerequisite]] != nil {
+					errs[i] = fmt.Errorf("dependency %s failed", prerequisite)
+					return
+				}
+			}
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			errs[i] = action(config.NewBPMConfig(boshEnv, bpmCfg.JobName(), procCfg.Name), procCfg)
+		}()
+	}
+	wg.Wait()
+
+	var failures processErrors
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, processError{process: jobCfg.Processes[i].Name, err: err})
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return failures.withExitStatus()
+}
+
+// withLifecycleLock wraps the action so that it logs to bpm.log under the
+// session and holds the lifecycle lock of the process while it runs.
+func withLifecycleLock(sessionName string, action lifecycleAction) processAction {
+	return withBpmLogger(sessionName, func(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+		lock, err := locks.LockJob(bpmCfg.JobName(), bpmCfg.ProcName())
+		if err != nil {
+			logger.Error("failed-to-acquire-lock", err)
+			return err
+		}
+		defer func() {
+			if err := lock.Unlock(); err != nil {
+				logger.Error("failed-to-release-lock", err)
+			}
+		}()
+
+		return action(logger, bpmCfg, procCfg)
+	})
+}
+
+// withBpmLogger wraps the action so that it logs to bpm.log under the session.
+// The caller must already hold the lifecycle lock of the process.
+func withBpmLogger(sessionName string, action lifecycleAction) processAction {
+	return func(bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+		logger, err := newBpmLogger(bpmCfg, sessionName)
+		if err != nil {
+			return err
+		}
+
+		logger.Info("starting")
+		defer logger.Info("complete")
+
+		return action(logger, bpmCfg, procCfg)
+	}
+}
+
+// lockAllProcesses takes the lifecycle lock of every process of the job, in
+// the order they are configured, for commands which operate on the job in more
+// than one pass. The returned function releases them.
+func lockAllProcesses(jobCfg *config.JobConfig) (func(), error) {
+	var held []hostlock.LockedLock
+	release := func() {
+		for _, lock := range held {
+			if err := lock.Unlock(); err != nil {
+				logger.Error("failed-to-release-lock", err)
+			}
+		}
+	}
+
+	for _, procCfg := range jobCfg.Processes {
+		lock, err := locks.LockJob(bpmCfg.JobName(), procCfg.Name)
+		if err != nil {
+			logger.Error("failed-to-acquire-lock", err, lager.Data{"process": procCfg.Name})
+			release()
+			return nil, err
+		}
+		held = append(held, lock)
+	}
+
+	return release, nil
+}
+
+type processError struct {
+	process string
+	err     error
+}
+
+type processErrors []processError
+
+func (e processErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, failure := range e {
+		lines[i] = fmt.Sprintf("  %s: %s", failure.process, failure.err)
+	}
+
+	return fmt.Sprintf("%d process(es) failed:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// withExitStatus keeps the exit status of the failures if they all agree on
+// one so that, for example, `start --all --wait` still exits with the not
+// ready status.
+func (e processErrors) withExitStatus() error {
+	status := exitstatus.FromError(e[0].err)
+	for _, failure := range e[1:] {
+		if exitstatus.FromError(failure.err) != status {
+			return e
+		}
+	}
+
+	if status == 1 {
+		return e
+	}
+
+	return &exitstatus.Error{Status: status, Err: e}
+}
@@ -0,0 +1,112 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"bpm/exitstatus"
+	"bpm/runc/lifecycle"
+)
+
+var (
+	execTTY  bool
+	execUser string
+	execCwd  string
+
+	// The command to run which comes after the job name and a "--".
+	execArgs []string
+)
+
+func init() {
+	execCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	execCommand.Flags().BoolVarP(&execTTY, "tty", "t", false, "allocate a pseudo-terminal for the command")
+	execCommand.Flags().StringArrayVarP(&env, "env", "e", []string{}, "Additional environment variables (format: KEY=VALUE)")
+	execCommand.Flags().StringVarP(&execUser, "user", "u", "", "user to run the command as (defaults to the user of the process)")
+	execCommand.Flags().StringVarP(&execCwd, "cwd", "w", "", "working directory of the command")
+	RootCmd.AddCommand(execCommand)
+}
+
+var execCommand = &cobra.Command{
+	Long:    "Runs a command inside the container of a running BOSH Process, passing standard input through, and exits with the exit status of the command",
+	RunE:    execute,
+	Short:   "runs a command inside the process container",
+	Use:     "exec <job-name> -- <command> [args...]",
+	PreRunE: execPre,
+}
+
+func execPre(cmd *cobra.Command, args []string) error {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 || dash == len(args) {
+		return errors.New("must specify a command to run after --")
+	}
+
+	if dash > 1 {
+		return errors.New("must specify a single job before --")
+	}
+
+	for _, e := range env {
+		if !strings.Contains(e, "=") {
+			return fmt.Errorf("invalid environment variable definition (format should be KEY=value): %q", e)
+		}
+	}
+
+	execArgs = args[dash:]
+
+	return validateInput(args[:dash])
+}
+
+func execute(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+
+	runcLifecycle, err := newRuncLifecycle()
+	if err != nil {
+		return err
+	}
+
+	if _, err := runningProcess(bpmCfg, runcLifecycle); err != nil {
+		return err
+	}
+
+	opts := lifecycle.ExecOptions{
+		TTY:  execTTY,
+		Env:  env,
+		User: execUser,
+		Cwd:  execCwd,
+	}
+
+	status, err := runcLifecycle.ExecProcess(bpmCfg, execArgs, opts, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+	if err != nil {
+		// A command which exited with a non-zero status has already had its
+		// say so the status is passed through without an error message.
+		var exitErr *exec.ExitError
+		signal := exitstatus.SignalName(status)
+
+		return &exitstatus.Error{
+			Status: status,
+			Signal: signal,
+			Quiet:  errors.As(err, &exitErr) && signal == "",
+			Err:    fmt.Errorf("failed to run command: %s", err),
+		}
+	}
+
+	return nil
+}
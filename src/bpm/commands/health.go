@@ -0,0 +1,70 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	healthCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	RootCmd.AddCommand(healthCommand)
+}
+
+var healthCommand = &cobra.Command{
+	Long:    "Runs the liveness probe of a BOSH Process and exits non-zero with the reason if it is unhealthy",
+	RunE:    health,
+	Short:   "checks the health of a BOSH Process",
+	Use:     "health <job-name>",
+	PreRunE: healthPre,
+}
+
+func healthPre(cmd *cobra.Command, args []string) error {
+	if err := validateInput(args); err != nil {
+		return err
+	}
+
+	return setupBpmLogs("health")
+}
+
+func health(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+
+	jobCfg, err := bpmCfg.ParseJobConfig()
+	if err != nil {
+		return fmt.Errorf("failed to parse job configuration: %s", err)
+	}
+
+	procCfg, err := processByNameFromJobConfig(jobCfg, procName)
+	if err != nil {
+		return fmt.Errorf("process %q not present in job configuration (%s)", procName, bpmCfg.JobConfig())
+	}
+
+	runcLifecycle, err := newRuncLifecycle()
+	if err != nil {
+		return err
+	}
+
+	if err := runcLifecycle.CheckHealth(logger, bpmCfg, procCfg); err != nil {
+		return fmt.Errorf("unhealthy: %s", err)
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "healthy") //nolint:errcheck
+
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"bpm/history"
+	"bpm/presenters"
+)
+
+var historyJSON bool
+
+func init() {
+	historyCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	historyCommand.Flags().BoolVar(&historyJSON, "json", false, "output the history as JSON")
+	RootCmd.AddCommand(historyCommand)
+}
+
+var historyCommand = &cobra.Command{
+	Long:    "Shows when each recent run of a BOSH Process was started and how it stopped",
+	RunE:    showHistory,
+	Short:   "shows the start and exit history of a BOSH Process",
+	Use:     "history <job-name>",
+	PreRunE: historyPre,
+}
+
+func historyPre(cmd *cobra.Command, args []string) error {
+	return validateInput(args)
+}
+
+func showHistory(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+
+	entries, err := history.Read(bpmCfg.HistoryFile().External())
+	if err != nil {
+		return fmt.Errorf("failed to read history: %s", err)
+	}
+
+	if historyJSON {
+		return presenters.PrintHistoryJSON(entries, cmd.OutOrStdout())
+	}
+
+	return presenters.PrintHistory(entries, cmd.OutOrStdout())
+}
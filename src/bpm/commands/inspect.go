@@ -0,0 +1,90 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"bpm/presenters"
+	"bpm/runc/lifecycle"
+)
+
+var (
+	inspectJSON,
+	inspectRawSpec bool
+)
+
+func init() {
+	inspectCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	inspectCommand.Flags().BoolVar(&inspectJSON, "json", false, "output the configuration as JSON")
+	inspectCommand.Flags().BoolVar(&inspectRawSpec, "raw-spec", false, "output the OCI spec the container was created with")
+	RootCmd.AddCommand(inspectCommand)
+}
+
+var inspectCommand = &cobra.Command{
+	Long:    "Shows the effective configuration of the container of a BOSH Process, as it was created by bpm",
+	RunE:    inspect,
+	Short:   "shows the container configuration of a BOSH Process",
+	Use:     "inspect <job-name>",
+	PreRunE: inspectPre,
+}
+
+func inspectPre(cmd *cobra.Command, args []string) error {
+	if inspectJSON && inspectRawSpec {
+		return errors.New("--json cannot be used with --raw-spec")
+	}
+
+	return validateInput(args)
+}
+
+func inspect(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+
+	runcLifecycle, err := newRuncLifecycle()
+	if err != nil {
+		return err
+	}
+
+	if inspectRawSpec {
+		spec, err := runcLifecycle.ProcessSpec(bpmCfg)
+		if lifecycle.IsNotExist(err) {
+			return errors.New("process could not be found")
+		} else if err != nil {
+			return fmt.Errorf("failed to read spec: %s", err)
+		}
+
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(spec)
+	}
+
+	inspection, err := runcLifecycle.InspectProcess(bpmCfg)
+	if lifecycle.IsNotExist(err) {
+		return errors.New("process could not be found")
+	} else if err != nil {
+		return fmt.Errorf("failed to inspect process: %s", err)
+	}
+
+	if inspectJSON {
+		return presenters.PrintInspectionJSON(inspection, cmd.OutOrStdout())
+	}
+
+	return presenters.PrintInspection(inspection, cmd.OutOrStdout())
+}
@@ -17,24 +17,52 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
 
 	"bpm/config"
+	"bpm/history"
 	"bpm/jobid"
 	"bpm/models"
 	"bpm/presenters"
+	"bpm/supervisor"
+)
+
+var (
+	listOutput string
+	listWide   bool
 )
 
 func init() {
+	listCommandCommand.Flags().StringVarP(&listOutput, "output", "o", "", "output format (json, yaml or wide)")
+	listCommandCommand.Flags().BoolVar(&listWide, "wide", false, "shorthand for --output wide")
 	RootCmd.AddCommand(listCommandCommand)
 }
 
 var listCommandCommand = &cobra.Command{
-	RunE:  listContainers,
-	Short: "list the state of bpm containers",
-	Use:   "list",
+	RunE:    listContainers,
+	Short:   "list the state of bpm containers",
+	Use:     "list",
+	PreRunE: listPre,
+}
+
+func listPre(cmd *cobra.Command, _ []string) error {
+	if listWide {
+		if listOutput != "" && listOutput != "wide" {
+			return fmt.Errorf("--wide cannot be used with --output %s", listOutput)
+		}
+
+		listOutput = "wide"
+	}
+
+	switch listOutput {
+	case "", "json", "yaml", "wide":
+		return nil
+	default:
+		return fmt.Errorf("invalid output format %q (must be one of json, yaml or wide)", listOutput)
+	}
 }
 
 func listContainers(cmd *cobra.Command, _ []string) error {
@@ -55,9 +83,16 @@ func listContainers(cmd *cobra.Command, _ []string) error {
 
 		for _, process := range jobCfg.Processes {
 			procCfg := config.NewBPMConfig(boshEnv, job, process.Name)
+			last := lastExit(procCfg)
 			processes = append(processes, &models.Process{
-				Name:   procCfg.ContainerID(),
-				Status: models.ProcessStateStopped,
+				Name:       procCfg.ContainerID(),
+				JobName:    job,
+				ProcName:   process.Name,
+				Status:     stoppedStatus(procCfg),
+				OOMKilled:  last != nil && last.OOMKilled,
+				BundlePath: procCfg.BundlePath(),
+				ConfigPath: procCfg.JobConfig(),
+				LastExit:   last,
 			})
 		}
 	}
@@ -79,7 +114,7 @@ func listContainers(cmd *cobra.Command, _ []string) error {
 		}
 	}
 
-	err = presenters.PrintJobs(processes, cmd.OutOrStdout())
+	err = printJobs(processes, cmd.OutOrStdout())
 	if err != nil {
 		fmt.Fprintf(cmd.OutOrStderr(), "failed to display jobs: %s\n", err.Error()) //nolint:errcheck
 		return err
@@ -88,10 +123,54 @@ func listContainers(cmd *cobra.Command, _ []string) error {
 	return nil
 }
 
+// stoppedStatus is the status of a process which is not running. A process
+// which `bpm supervise` has given up on restarting is shown as failed.
+func stoppedStatus(bpmCfg *config.BPMConfig) string {
+	state, err := supervisor.ReadState(bpmCfg.SupervisorStateFile().External())
+	if err == nil && state.Status == supervisor.StatusFailed {
+		return models.ProcessStateFailed
+	}
+
+	return models.ProcessStateStopped
+}
+
+// lastExit returns the most recent run of the process which has ended, if
+// there is one. A missing or unreadable history is not an error here.
+func lastExit(bpmCfg *config.BPMConfig) *models.HistoryEntry {
+	entries, err := history.Read(bpmCfg.HistoryFile().External())
+	if err != nil {
+		return nil
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].StopTime != nil {
+			return &entries[i]
+		}
+	}
+
+	return nil
+}
+
+func printJobs(processes []*models.Process, w io.Writer) error {
+	switch listOutput {
+	case "json":
+		return presenters.PrintJobsJSON(processes, w)
+	case "yaml":
+		return presenters.PrintJobsYAML(processes, w)
+	case "wide":
+		return presenters.PrintJobsWide(processes, w)
+	default:
+		return presenters.PrintJobs(processes, w)
+	}
+}
+
 func updateProcess(processes []*models.Process, process *models.Process) ([]*models.Process, error) {
 	for i := range processes {
 		if processes[i].Name == process.Name {
-			processes[i] = process
+			processes[i].Pid = process.Pid
+			processes[i].Status = process.Status
+			processes[i].OOMKilled = process.OOMKilled
+			processes[i].StartTime = process.StartTime
 			return processes, nil
 		}
 	}
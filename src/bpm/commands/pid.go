@@ -18,15 +18,22 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/spf13/cobra"
 
+	"bpm/config"
 	"bpm/models"
+	"bpm/presenters"
 	"bpm/runc/lifecycle"
 )
 
+var pidJSON bool
+
 func init() {
 	pidCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	pidCommand.Flags().BoolVar(&pidJSON, "json", false, "output the process as JSON")
+	addAllFlag(pidCommand, "display the pid of")
 	RootCmd.AddCommand(pidCommand)
 }
 
@@ -38,7 +45,11 @@ var pidCommand = &cobra.Command{
 }
 
 func pidPre(cmd *cobra.Command, args []string) error {
-	return validateInput(args)
+	if err := validateInput(args); err != nil {
+		return err
+	}
+
+	return validateAllInput(cmd)
 }
 
 func pidForJob(cmd *cobra.Command, _ []string) error {
@@ -48,14 +59,121 @@ func pidForJob(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return err
 	}
+
+	if allProcesses {
+		return pidForAllProcesses(cmd, runcLifecycle)
+	}
+
+	process, err := runningProcess(bpmCfg, runcLifecycle)
+	if err != nil {
+		return err
+	}
+
+	if pidJSON {
+		processes, err := listProcesses(runcLifecycle, bpmCfg)
+		if err != nil {
+			return err
+		}
+
+		if len(processes) == 0 {
+			return errors.New("process is not running or could not be found")
+		}
+
+		return presenters.PrintProcessJSON(processes[0], cmd.OutOrStdout())
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "%d\n", process.Pid) //nolint:errcheck
+
+	return nil
+}
+
+// pidForAllProcesses displays the pid of every running process of the job,
+// one per line as "PROCESS: PID" (or as a JSON array with --json). An error is
+// returned for each process which is not running.
+func pidForAllProcesses(cmd *cobra.Command, runcLifecycle *lifecycle.RuncLifecycle) error {
+	jobCfg, err := bpmCfg.ParseJobConfig()
+	if err != nil {
+		return fmt.Errorf("failed to parse job configuration: %s", err)
+	}
+
+	var mu sync.Mutex
+	running := map[string]*models.Process{}
+	statErr := forEachProcess(jobCfg, anyOrder, func(bpmCfg *config.BPMConfig, _ *config.ProcessConfig) error {
+		process, err := runningProcess(bpmCfg, runcLifecycle)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		running[bpmCfg.ProcName()] = process
+		mu.Unlock()
+
+		return nil
+	})
+
+	var bpmCfgs []*config.BPMConfig
+	for _, procCfg := range jobCfg.Processes {
+		if _, ok := running[procCfg.Name]; ok {
+			bpmCfgs = append(bpmCfgs, config.NewBPMConfig(boshEnv, bpmCfg.JobName(), procCfg.Name))
+		}
+	}
+
+	if pidJSON {
+		processes, err := listProcesses(runcLifecycle, bpmCfgs...)
+		if err != nil {
+			return err
+		}
+
+		if err := presenters.PrintJobsJSON(processes, cmd.OutOrStdout()); err != nil {
+			return err
+		}
+
+		return statErr
+	}
+
+	for _, cfg := range bpmCfgs {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: %d\n", cfg.ProcName(), running[cfg.ProcName()].Pid) //nolint:errcheck
+	}
+
+	return statErr
+}
+
+func runningProcess(bpmCfg *config.BPMConfig, runcLifecycle *lifecycle.RuncLifecycle) (*models.Process, error) {
 	process, err := runcLifecycle.StatProcess(bpmCfg)
 	if err != nil && !lifecycle.IsNotExist(err) {
-		return fmt.Errorf("failed to get job: %s", err)
+		return nil, fmt.Errorf("failed to get job: %s", err)
 	} else if lifecycle.IsNotExist(err) || process.Status == models.ProcessStateFailed {
-		return errors.New("process is not running or could not be found")
+		return nil, errors.New("process is not running or could not be found")
 	}
 
-	fmt.Fprintf(cmd.OutOrStdout(), "%d\n", process.Pid) //nolint:errcheck
+	return process, nil
+}
 
-	return nil
+// listProcesses returns the processes using the same schema as `bpm list
+// --output json`. The runc state does not include the creation time of the
+// container so the container list is used to fill it in. Processes which are
+// not in the container list are left out.
+func listProcesses(runcLifecycle *lifecycle.RuncLifecycle, bpmCfgs ...*config.BPMConfig) ([]*models.Process, error) {
+	containers, err := runcLifecycle.ListProcesses()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %s", err)
+	}
+
+	processes := []*models.Process{}
+	for _, cfg := range bpmCfgs {
+		for _, process := range containers {
+			if process.Name != cfg.ContainerID() {
+				continue
+			}
+
+			process.JobName = cfg.JobName()
+			process.ProcName = cfg.ProcName()
+			process.BundlePath = cfg.BundlePath()
+			process.ConfigPath = cfg.JobConfig()
+
+			processes = append(processes, process)
+		}
+	}
+
+	return processes, nil
 }
@@ -0,0 +1,115 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/spf13/cobra"
+
+	"bpm/config"
+	"bpm/runc/lifecycle"
+)
+
+var restartIfNeeded bool
+
+func init() {
+	reloadCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	reloadCommand.Flags().BoolVar(&restartIfNeeded, "restart-if-needed", false, "restart the process if its configuration changed in a way which reloading cannot apply")
+	addAllFlag(reloadCommand, "reload")
+	RootCmd.AddCommand(reloadCommand)
+}
+
+var reloadCommand = &cobra.Command{
+	Long:     "Sends a BOSH Process its reload_signal so that it reloads its configuration. The process is not signalled if its bpm configuration changed in a way which needs a restart, such as its volumes, limits, or capabilities.",
+	RunE:     reload,
+	Short:    "reloads the configuration of a BOSH Process",
+	Use:      "reload <job-name>",
+	PreRunE:  reloadPre,
+	PostRunE: reloadPost,
+}
+
+func reloadPre(cmd *cobra.Command, args []string) error {
+	if err := validateInput(args); err != nil {
+		return err
+	}
+
+	if err := validateAllInput(cmd); err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	if err := setupBpmLogs("reload"); err != nil {
+		return err
+	}
+
+	return acquireLifecycleLock()
+}
+
+func reloadPost(cmd *cobra.Command, args []string) error {
+	return releaseLifecycleLock()
+}
+
+func reload(cmd *cobra.Command, _ []string) error {
+	logger.Info("starting")
+	defer logger.Info("complete")
+
+	jobCfg, err := bpmCfg.ParseJobConfig()
+	if err != nil {
+		logger.Error("failed-to-parse-config", err)
+		return fmt.Errorf("failed to parse job configuration: %s", err)
+	}
+
+	runcLifecycle, err := newRuncLifecycle()
+	if err != nil {
+		return err
+	}
+
+	if allProcesses {
+		return forEachProcess(jobCfg, dependenciesFirst, withLifecycleLock("reload", func(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+			return reloadProcess(logger, bpmCfg, procCfg, runcLifecycle)
+		}))
+	}
+
+	procCfg, err := processByNameFromJobConfig(jobCfg, procName)
+	if err != nil {
+		logger.Error("process-not-defined", err)
+		return fmt.Errorf("process %q not present in job configuration (%s)", procName, bpmCfg.JobConfig())
+	}
+
+	return reloadProcess(logger, bpmCfg, procCfg, runcLifecycle)
+}
+
+func reloadProcess(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig, runcLifecycle *lifecycle.RuncLifecycle) error {
+	if _, err := runningProcess(bpmCfg, runcLifecycle); err != nil {
+		return err
+	}
+
+	err := runcLifecycle.ReloadProcess(logger, bpmCfg, procCfg)
+	if lifecycle.IsRestartRequired(err) && restartIfNeeded {
+		logger.Info("restarting", lager.Data{"reason": err.Error()})
+		return restartProcess(logger, bpmCfg, procCfg, runcLifecycle)
+	}
+
+	if err != nil {
+		logger.Error("failed-to-reload", err)
+		return fmt.Errorf("failed to reload job-process: %s", err)
+	}
+
+	return nil
+}
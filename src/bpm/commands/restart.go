@@ -0,0 +1,183 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"fmt"
+	"sync"
+
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/spf13/cobra"
+
+	"bpm/config"
+	"bpm/runc/lifecycle"
+)
+
+func init() {
+	restartCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	addAllFlag(restartCommand, "restart")
+	RootCmd.AddCommand(restartCommand)
+}
+
+var restartCommand = &cobra.Command{
+	Long:     "Stops and then starts a BOSH Process while holding the lifecycle lock so that no other start or stop can happen in between.",
+	RunE:     restart,
+	Short:    "restarts a BOSH Process",
+	Use:      "restart <job-name>",
+	PreRunE:  restartPre,
+	PostRunE: restartPost,
+}
+
+func restartPre(cmd *cobra.Command, args []string) error {
+	if err := validateInput(args); err != nil {
+		return err
+	}
+
+	if err := validateAllInput(cmd); err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	if err := setupBpmLogs("restart"); err != nil {
+		return err
+	}
+
+	return acquireLifecycleLock()
+}
+
+func restartPost(cmd *cobra.Command, args []string) error {
+	return releaseLifecycleLock()
+}
+
+func restart(cmd *cobra.Command, _ []string) error {
+	logger.Info("starting")
+	defer logger.Info("complete")
+
+	// The configuration is parsed before anything is stopped so that a broken
+	// configuration does not leave the job-process stopped.
+	jobCfg, err := bpmCfg.ParseJobConfig()
+	if err != nil {
+		logger.Error("failed-to-parse-config", err)
+		return fmt.Errorf("failed to parse job configuration: %s", err)
+	}
+
+	runcLifecycle, err := newRuncLifecycle()
+	if err != nil {
+		return err
+	}
+
+	if allProcesses {
+		return restartAllProcesses(jobCfg, runcLifecycle)
+	}
+
+	procCfg, err := processByNameFromJobConfig(jobCfg, procName)
+	if err != nil {
+		logger.Error("process-not-defined", err)
+		return fmt.Errorf("process %q not present in job configuration (%s)", procName, bpmCfg.JobConfig())
+	}
+
+	return restartProcess(logger, bpmCfg, procCfg, runcLifecycle)
+}
+
+// restartAllProcesses stops every process of the job, dependents first, before
+// starting them again, dependencies first, so that no process is running while
+// one it depends on is stopped. A process which could not be stopped is not
+// started again and is reported along with any start failures. The lifecycle
+// lock of every process is held across both passes.
+func restartAllProcesses(jobCfg *config.JobConfig, runcLifecycle *lifecycle.RuncLifecycle) error {
+	unlock, err := lockAllProcesses(jobCfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var stopFailures sync.Map
+
+	// Failures to stop are reported by the start pass so that every process
+	// which did stop is started again.
+	_ = forEachProcess(jobCfg, dependentsFirst, withBpmLogger("restart", func(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+		err := stopForRestart(logger, bpmCfg, procCfg, runcLifecycle)
+		if err != nil {
+			stopFailures.Store(procCfg.Name, err)
+		}
+		return err
+	}))
+
+	return forEachProcess(jobCfg, dependenciesFirst, withBpmLogger("restart", func(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+		if err, failed := stopFailures.Load(procCfg.Name); failed {
+			return err.(error)
+		}
+
+		return startForRestart(logger, bpmCfg, procCfg, runcLifecycle)
+	}))
+}
+
+func restartProcess(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig, runcLifecycle *lifecycle.RuncLifecycle) error {
+	if err := stopForRestart(logger, bpmCfg, procCfg, runcLifecycle); err != nil {
+		return err
+	}
+
+	return startForRestart(logger, bpmCfg, procCfg, runcLifecycle)
+}
+
+// stopForRestart is the stop phase of a restart. A process which is not
+// running is left as it is. Any supervisor is told to stop so that it does not
+// race the restart to start the process again.
+func stopForRestart(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig, runcLifecycle *lifecycle.RuncLifecycle) error {
+	requestSupervisorStop(logger, bpmCfg)
+
+	_, err := runcLifecycle.StatProcess(bpmCfg)
+	switch {
+	case lifecycle.IsNotExist(err):
+		logger.Info("job-already-stopped")
+	case err != nil:
+		logger.Error("failed-to-get-job", err)
+
+		if cerr := forceCleanupBrokenRuncState(logger, bpmCfg, runcLifecycle); cerr != nil {
+			logger.Error("failed-cleaning-up-broken-job", cerr)
+			return fmt.Errorf("failed to restart job-process: stop phase: %s", cerr)
+		}
+	default:
+		stopLogger := logger.Session("stop")
+		if err := runcLifecycle.StopProcess(stopLogger, bpmCfg, procCfg, procCfg.ParseShutdownTimeout()); err != nil {
+			stopLogger.Error("failed-to-stop", err)
+		}
+
+		removeLogger := logger.Session("remove")
+		if err := runcLifecycle.RemoveProcess(removeLogger, bpmCfg); err != nil {
+			removeLogger.Error("failed-to-cleanup", err)
+			return fmt.Errorf("failed to restart job-process: remove phase: %s", err)
+		}
+
+		if err := runcLifecycle.RunPostStopHook(removeLogger, bpmCfg, procCfg); err != nil {
+			removeLogger.Error("post-stop-hook-failed", err)
+		}
+	}
+
+	return nil
+}
+
+// startForRestart is the start phase of a restart.
+func startForRestart(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig, runcLifecycle *lifecycle.RuncLifecycle) error {
+	startLogger := logger.Session("start")
+	if err := runcLifecycle.StartProcess(startLogger, bpmCfg, procCfg); err != nil {
+		startLogger.Error("failed-to-start", err)
+		return fmt.Errorf("failed to restart job-process: start phase: %s", err)
+	}
+
+	return nil
+}
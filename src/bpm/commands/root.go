@@ -26,6 +26,7 @@ import (
 	"code.cloudfoundry.org/lager/v3"
 	"github.com/spf13/cobra"
 
+	"bpm/blockdev"
 	"bpm/bosh"
 	"bpm/cgroups"
 	"bpm/config"
@@ -120,31 +121,42 @@ func validateInput(args []string) error {
 }
 
 func setupBpmLogs(sessionName string) error {
+	var err error
+	logger, err = newBpmLogger(bpmCfg, sessionName)
+	return err
+}
+
+func newBpmLogger(bpmCfg *config.BPMConfig, sessionName string) (lager.Logger, error) {
 	if err := os.MkdirAll(bpmCfg.LogDir().External(), 0750); err != nil {
-		return err
+		return nil, err
 	}
 
 	usr, err := userFinder.Lookup(usertools.VcapUser)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	logFile, err := safeio.OpenAppendChown(bpmCfg.BPMLog(), int(usr.UID), int(usr.GID), 0600)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	logger = lager.NewLogger("bpm")
+	logger := lager.NewLogger("bpm")
 	logger.RegisterSink(lager.NewPrettySink(logFile, lager.INFO))
-	logger = logger.Session(sessionName, lager.Data{
+	return logger.Session(sessionName, lager.Data{
 		"job":     bpmCfg.JobName(),
 		"process": bpmCfg.ProcName(),
-	})
-
-	return nil
+	}), nil
 }
 
+// acquireLifecycleLock takes the lifecycle lock of the process. When a command
+// is run with --all the lock of each process is instead taken as it is
+// operated on.
 func acquireLifecycleLock() error {
+	if allProcesses {
+		return nil
+	}
+
 	l := logger.Session("acquiring-lifecycle-lock")
 	l.Info("starting")
 	defer l.Info("complete")
@@ -160,6 +172,10 @@ func acquireLifecycleLock() error {
 }
 
 func releaseLifecycleLock() error {
+	if allProcesses {
+		return nil
+	}
+
 	l := logger.Session("releasing-lifecycle-lock")
 	l.Info("starting")
 	defer l.Info("complete")
@@ -183,7 +199,7 @@ func newRuncLifecycle() (*lifecycle.RuncLifecycle, error) {
 		return nil, fmt.Errorf("failed to fetch system features: %w", err)
 	}
 
-	runcAdapter := adapter.NewRuncAdapter(*features, filepath.Glob, sharedvolume.MakeShared, locks, cgroupsPathForContainer)
+	runcAdapter := adapter.NewRuncAdapter(*features, filepath.Glob, sharedvolume.MakeShared, locks, cgroupsPathForContainer, blockdev.Resolve)
 	return lifecycle.NewRuncLifecycle(
 		runcClient,
 		runcAdapter,
@@ -226,7 +242,7 @@ func isRunningSystemd() bool {
 // Occasionally RunC can get in an inconsistent state after a restart where
 // it's internal state.json file is truncated. RunC is unable to get out of
 // this state without some intervention. This is that intervention.
-func forceCleanupBrokenRuncState(logger lager.Logger, runcLifecycle *lifecycle.RuncLifecycle) error {
+func forceCleanupBrokenRuncState(logger lager.Logger, bpmCfg *config.BPMConfig, runcLifecycle *lifecycle.RuncLifecycle) error {
 	// We compute this here rather than adding a new function to the
 	// configuration object to try and contain this hack to one place.
 	statePath := filepath.Join(config.RuncRoot(boshEnv), bpmCfg.ContainerID(), "state.json")
@@ -73,23 +73,29 @@ func run(cmd *cobra.Command, _ []string) error {
 	jobCfg, err := bpmCfg.ParseJobConfig()
 	if err != nil {
 		logger.Error("failed-to-parse-config", err)
-		return fmt.Errorf("failed to parse job configuration: %s", err)
+		return &exitstatus.Error{
+			Status: exitstatus.InvalidConfig,
+			Err:    fmt.Errorf("failed to parse job configuration: %s", err),
+		}
 	}
 
 	procCfg, err := processByNameFromJobConfig(jobCfg, procName)
 	if err != nil {
 		logger.Error("process-not-defined", err)
-		return fmt.Errorf("process %q not present in job configuration (%s)", procName, bpmCfg.JobConfig())
+		return &exitstatus.Error{
+			Status: exitstatus.InvalidConfig,
+			Err:    fmt.Errorf("process %q not present in job configuration (%s)", procName, bpmCfg.JobConfig()),
+		}
 	}
 
 	if err = procCfg.AddVolumes(volumes, boshEnv, bpmCfg.DefaultVolumes()); err != nil {
 		logger.Error("invalid-volume-definition", err)
-		return err
+		return &exitstatus.Error{Status: exitstatus.InvalidConfig, Err: err}
 	}
 
 	if err = procCfg.AddEnvVars(env, boshEnv, bpmCfg.DefaultVolumes()); err != nil {
 		logger.Error("invalid-environment-definition", err)
-		return err
+		return &exitstatus.Error{Status: exitstatus.InvalidConfig, Err: err}
 	}
 
 	runcLifecycle, err := newRuncLifecycle()
@@ -100,9 +106,9 @@ func run(cmd *cobra.Command, _ []string) error {
 	if err != nil && !lifecycle.IsNotExist(err) {
 		logger.Error("failed-getting-job", err)
 
-		if cerr := forceCleanupBrokenRuncState(logger, runcLifecycle); cerr != nil {
+		if cerr := forceCleanupBrokenRuncState(logger, bpmCfg, runcLifecycle); cerr != nil {
 			logger.Error("failed-cleaning-up-broken-job", cerr)
-			return cerr
+			return &exitstatus.Error{Status: exitstatus.ContainerFailed, Err: cerr}
 		}
 	}
 
@@ -119,17 +125,43 @@ func run(cmd *cobra.Command, _ []string) error {
 		logger.Info("removing-stopped-process")
 		if err := runcLifecycle.RemoveProcess(logger, bpmCfg); err != nil {
 			logger.Error("failed-to-cleanup", err)
-			return fmt.Errorf("failed to clean up stale job-process: %s", err)
+			return &exitstatus.Error{
+				Status: exitstatus.ContainerFailed,
+				Err:    fmt.Errorf("failed to clean up stale job-process: %s", err),
+			}
 		}
 		fallthrough
 	default:
 		if status, err := runcLifecycle.RunProcess(logger, bpmCfg, procCfg); err != nil {
-			return &exitstatus.Error{
-				Status: status,
-				Err:    fmt.Errorf("failed to run job-process: %s", err),
-			}
+			return runError(status, err)
 		}
 	}
 
 	return nil
 }
+
+// runError maps a failure to run the process to the exit status of `bpm run`.
+// Failures of bpm have reserved exit statuses while the exit status of the
+// process is passed through.
+func runError(status int, err error) error {
+	switch {
+	case lifecycle.IsOOMKilled(err):
+		return &exitstatus.Error{
+			Status: exitstatus.OOMKilled,
+			Err:    fmt.Errorf("job-process was killed by the OOM killer (exit status %d)", status),
+		}
+	case lifecycle.IsHookFailure(err):
+		return &exitstatus.Error{Status: exitstatus.HookFailed, Err: err}
+	case lifecycle.IsSetupFailure(err):
+		return &exitstatus.Error{
+			Status: exitstatus.ContainerFailed,
+			Err:    fmt.Errorf("failed to set up job-process: %s", err),
+		}
+	default:
+		return &exitstatus.Error{
+			Status: status,
+			Signal: exitstatus.SignalName(status),
+			Err:    fmt.Errorf("failed to run job-process: %s", err),
+		}
+	}
+}
@@ -16,18 +16,24 @@
 package commands
 
 import (
-	"errors"
-	"fmt"
 	"os"
+	"os/user"
 
+	"code.cloudfoundry.org/lager/v3"
 	"github.com/spf13/cobra"
 
-	"bpm/models"
 	"bpm/runc/lifecycle"
 )
 
+var (
+	shellPath string
+	shellUser string
+)
+
 func init() {
 	shellCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	shellCommand.Flags().StringVarP(&shellPath, "shell", "s", "", "shell to run (defaults to /bin/bash, or /bin/sh if bash is not present)")
+	shellCommand.Flags().StringVarP(&shellUser, "user", "u", "", "user to run the shell as (defaults to the user of the process)")
 	RootCmd.AddCommand(shellCommand)
 }
 
@@ -39,7 +45,17 @@ var shellCommand = &cobra.Command{
 }
 
 func shellPre(cmd *cobra.Command, args []string) error {
-	return validateInput(args)
+	if err := validateInput(args); err != nil {
+		return err
+	}
+
+	if err := setupBpmLogs("shell"); err != nil {
+		return err
+	}
+
+	logger = logger.WithData(lager.Data{"invoked_by": invokingUser()})
+
+	return nil
 }
 
 func shell(cmd *cobra.Command, _ []string) error {
@@ -50,12 +66,25 @@ func shell(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	process, err := runcLifecycle.StatProcess(bpmCfg)
-	if err != nil && !lifecycle.IsNotExist(err) {
-		return fmt.Errorf("failed to get job: %s", err)
-	} else if lifecycle.IsNotExist(err) || process.Status == models.ProcessStateFailed {
-		return errors.New("process is not running or could not be found")
+	if _, err := runningProcess(bpmCfg, runcLifecycle); err != nil {
+		return err
+	}
+
+	opts := lifecycle.ShellOptions{Shell: shellPath, User: shellUser}
+	return runcLifecycle.OpenShell(logger, bpmCfg, opts, os.Stdin, cmd.OutOrStdout(), cmd.OutOrStderr())
+}
+
+// invokingUser returns the name of the user who ran bpm. As bpm must be run
+// as root this is the user who ran sudo if it was used.
+func invokingUser() string {
+	if name := os.Getenv("SUDO_USER"); name != "" {
+		return name
+	}
+
+	usr, err := user.Current()
+	if err != nil {
+		return "unknown"
 	}
 
-	return runcLifecycle.OpenShell(bpmCfg, os.Stdin, cmd.OutOrStdout(), cmd.OutOrStderr())
+	return usr.Username
 }
@@ -0,0 +1,87 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"bpm/runc/client"
+)
+
+var (
+	signalAll bool
+
+	// The signal to send which comes after the job name.
+	signalToSend client.Signal
+)
+
+func init() {
+	signalCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	signalCommand.Flags().BoolVar(&signalAll, "all", false, "send the signal to every process in the container rather than only to the main process")
+	RootCmd.AddCommand(signalCommand)
+}
+
+var signalCommand = &cobra.Command{
+	Long:    "Sends a signal such as HUP or USR1 to a running BOSH Process, e.g. to make it reload its configuration",
+	RunE:    sendSignal,
+	Short:   "sends a signal to a BOSH Process",
+	Use:     "signal <job-name> <signal>",
+	PreRunE: signalPre,
+}
+
+func signalPre(cmd *cobra.Command, args []string) error {
+	if err := validateInput(args); err != nil {
+		return err
+	}
+
+	if len(args) != 2 {
+		return errors.New("must specify a single signal")
+	}
+
+	sig, err := client.ParseSignal(args[1])
+	if err != nil {
+		return err
+	}
+	signalToSend = sig
+
+	cmd.SilenceUsage = true
+
+	return setupBpmLogs("signal")
+}
+
+func sendSignal(cmd *cobra.Command, _ []string) error {
+	logger.Info("starting")
+	defer logger.Info("complete")
+
+	runcLifecycle, err := newRuncLifecycle()
+	if err != nil {
+		return err
+	}
+
+	if _, err := runningProcess(bpmCfg, runcLifecycle); err != nil {
+		return err
+	}
+
+	if err := runcLifecycle.SignalProcess(logger, bpmCfg, signalToSend, signalAll); err != nil {
+		logger.Error("failed-to-signal", err)
+		return fmt.Errorf("failed to send %s to job-process: %s", signalToSend, err)
+	}
+
+	return nil
+}
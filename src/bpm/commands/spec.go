@@ -0,0 +1,95 @@
+// Copyright (C) 2018-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/spf13/cobra"
+
+	"bpm/exitstatus"
+)
+
+var specConfigPath string
+
+func init() {
+	specCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	specCommand.Flags().StringVar(&specConfigPath, "config", "", "job configuration to use instead of the deployed bpm.yml")
+	specCommand.Flags().StringArrayVarP(&volumes, "volume", "v", []string{}, "Optional list of volumes (format: <path>[:<options>])")
+	specCommand.Flags().StringArrayVarP(&env, "env", "e", []string{}, "Additional environment variables (format: KEY=VALUE)")
+	RootCmd.AddCommand(specCommand)
+}
+
+var specCommand = &cobra.Command{
+	Long:    "Prints the OCI spec which a BOSH Process would be started with without starting it or changing anything on the host",
+	RunE:    renderSpec,
+	Short:   "prints the container spec of a BOSH Process without starting it",
+	Use:     "spec <job-name>",
+	PreRunE: specPre,
+}
+
+func specPre(cmd *cobra.Command, args []string) error {
+	return validateInput(args)
+}
+
+func renderSpec(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+
+	path := bpmCfg.JobConfig()
+	if specConfigPath != "" {
+		path = specConfigPath
+	}
+
+	jobCfg, err := bpmCfg.ParseJobConfigFile(path)
+	if err != nil {
+		return &exitstatus.Error{
+			Status: exitstatus.InvalidConfig,
+			Err:    fmt.Errorf("failed to parse job configuration: %s", err),
+		}
+	}
+
+	procCfg, err := processByNameFromJobConfig(jobCfg, procName)
+	if err != nil {
+		return &exitstatus.Error{
+			Status: exitstatus.InvalidConfig,
+			Err:    fmt.Errorf("process %q not present in job configuration (%s)", procName, path),
+		}
+	}
+
+	if err := procCfg.AddVolumes(volumes, boshEnv, bpmCfg.DefaultVolumes()); err != nil {
+		return &exitstatus.Error{Status: exitstatus.InvalidConfig, Err: err}
+	}
+
+	if err := procCfg.AddEnvVars(env, boshEnv, bpmCfg.DefaultVolumes()); err != nil {
+		return &exitstatus.Error{Status: exitstatus.InvalidConfig, Err: err}
+	}
+
+	runcLifecycle, err := newRuncLifecycle()
+	if err != nil {
+		return err
+	}
+
+	spec, err := runcLifecycle.RenderSpec(lager.NewLogger("bpm"), bpmCfg, procCfg)
+	if err != nil {
+		return fmt.Errorf("failed to build spec: %s", err)
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(spec)
+}
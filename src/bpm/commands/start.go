@@ -18,14 +18,25 @@ package commands
 import (
 	"fmt"
 
+	"code.cloudfoundry.org/lager/v3"
 	"github.com/spf13/cobra"
 
+	"bpm/config"
+	"bpm/exitstatus"
 	"bpm/models"
 	"bpm/runc/lifecycle"
 )
 
+// exitStatusNotReady is the exit status of `bpm start --wait` when the process
+// was started but did not pass its readiness probe.
+const exitStatusNotReady = 3
+
+var startWait bool
+
 func init() {
 	startCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	startCommand.Flags().BoolVar(&startWait, "wait", false, "wait for the process to pass its readiness probe")
+	addAllFlag(startCommand, "start")
 	RootCmd.AddCommand(startCommand)
 }
 
@@ -42,6 +53,10 @@ func startPre(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := validateAllInput(cmd); err != nil {
+		return err
+	}
+
 	cmd.SilenceUsage = true
 
 	if err := setupBpmLogs("start"); err != nil {
@@ -65,21 +80,34 @@ func start(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("failed to parse job configuration: %s", err)
 	}
 
+	runcLifecycle, err := newRuncLifecycle()
+	if err != nil {
+		return err
+	}
+
+	if allProcesses {
+		return forEachProcess(jobCfg, dependenciesFirst, withLifecycleLock("start", func(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+			return startProcess(logger, bpmCfg, procCfg, runcLifecycle)
+		}))
+	}
+
 	procCfg, err := processByNameFromJobConfig(jobCfg, procName)
 	if err != nil {
 		logger.Error("process-not-defined", err)
 		return fmt.Errorf("process %q not present in job configuration (%s)", procName, bpmCfg.JobConfig())
 	}
 
-	runcLifecycle, err := newRuncLifecycle()
-	if err != nil {
-		return err
-	}
+	return startProcess(logger, bpmCfg, procCfg, runcLifecycle)
+}
+
+func startProcess(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig, runcLifecycle *lifecycle.RuncLifecycle) error {
+	resetSupervisorState(logger, bpmCfg)
+
 	process, err := runcLifecycle.StatProcess(bpmCfg)
 	if err != nil && !lifecycle.IsNotExist(err) {
 		logger.Error("failed-getting-job", err)
 
-		if cerr := forceCleanupBrokenRuncState(logger, runcLifecycle); cerr != nil {
+		if cerr := forceCleanupBrokenRuncState(logger, bpmCfg, runcLifecycle); cerr != nil {
 			logger.Error("failed-cleaning-up-broken-job", cerr)
 			return cerr
 		}
@@ -93,7 +121,6 @@ func start(cmd *cobra.Command, _ []string) error {
 	switch state {
 	case models.ProcessStateRunning:
 		logger.Info("process-already-running")
-		return nil
 	case models.ProcessStateFailed:
 		logger.Info("removing-stopped-process")
 		if err := runcLifecycle.RemoveProcess(logger, bpmCfg); err != nil {
@@ -108,5 +135,21 @@ func start(cmd *cobra.Command, _ []string) error {
 		}
 	}
 
+	if startWait {
+		return waitForReady(logger, bpmCfg, procCfg, runcLifecycle)
+	}
+
+	return nil
+}
+
+func waitForReady(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig, runcLifecycle *lifecycle.RuncLifecycle) error {
+	if err := runcLifecycle.WaitForReady(logger, bpmCfg, procCfg); err != nil {
+		logger.Error("failed-to-become-ready", err)
+		return &exitstatus.Error{
+			Status: exitStatusNotReady,
+			Err:    fmt.Errorf("job-process is not ready: %s", err),
+		}
+	}
+
 	return nil
 }
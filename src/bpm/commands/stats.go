@@ -0,0 +1,206 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"bpm/config"
+	"bpm/jobid"
+	"bpm/models"
+	"bpm/presenters"
+	"bpm/procstats"
+	"bpm/runc/lifecycle"
+)
+
+var (
+	statsAll,
+	statsJSON,
+	statsWatch bool
+
+	statsInterval time.Duration
+)
+
+func init() {
+	statsCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	statsCommand.Flags().BoolVarP(&statsAll, "all", "a", false, "show stats for every running process")
+	statsCommand.Flags().BoolVar(&statsJSON, "json", false, "output stats as JSON")
+	statsCommand.Flags().BoolVarP(&statsWatch, "watch", "w", false, "continuously refresh the stats")
+	statsCommand.Flags().DurationVar(&statsInterval, "interval", 2*time.Second, "refresh interval when watching")
+	RootCmd.AddCommand(statsCommand)
+}
+
+var statsCommand = &cobra.Command{
+	RunE:    stats,
+	Short:   "displays live resource usage for a given job",
+	Use:     "stats <job-name>",
+	PreRunE: statsPre,
+}
+
+func statsPre(cmd *cobra.Command, args []string) error {
+	if statsAll {
+		if len(args) > 0 || procName != "" {
+			return errors.New("a job cannot be specified when using --all")
+		}
+
+		return nil
+	}
+
+	return validateInput(args)
+}
+
+type cpuSample struct {
+	usage float64
+	at    time.Time
+}
+
+func stats(cmd *cobra.Command, _ []string) error {
+	cmd.SilenceUsage = true
+
+	runcLifecycle, err := newRuncLifecycle()
+	if err != nil {
+		return err
+	}
+
+	var targets []*config.BPMConfig
+	if statsAll {
+		targets = configuredProcesses(cmd.OutOrStderr())
+	} else {
+		targets = []*config.BPMConfig{bpmCfg}
+	}
+
+	collector := procstats.NewCollector()
+	previous := map[string]cpuSample{}
+
+	for {
+		samples, err := collectStats(runcLifecycle, collector, targets)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, s := range samples {
+			if prev, ok := previous[s.Name]; ok {
+				percent := (s.CPUUsage - prev.usage) / now.Sub(prev.at).Seconds() * 100
+				s.CPUPercent = &percent
+			}
+			previous[s.Name] = cpuSample{usage: s.CPUUsage, at: now}
+		}
+
+		if err := printStats(cmd.OutOrStdout(), samples); err != nil {
+			return err
+		}
+
+		if !statsWatch {
+			return nil
+		}
+
+		time.Sleep(statsInterval)
+	}
+}
+
+func collectStats(runcLifecycle *lifecycle.RuncLifecycle, collector *procstats.Collector, targets []*config.BPMConfig) ([]*models.ProcessStats, error) {
+	samples := []*models.ProcessStats{}
+
+	for _, cfg := range targets {
+		process, err := runcLifecycle.StatProcess(cfg)
+		if err != nil && !lifecycle.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to get job: %s", err)
+		} else if lifecycle.IsNotExist(err) || process.Status != models.ProcessStateRunning {
+			if statsAll {
+				continue
+			}
+
+			return nil, errors.New("process is not running or could not be found")
+		}
+
+		paths, err := runcLifecycle.CgroupPaths(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find cgroups of %s: %s", cfg.ProcName(), err)
+		}
+
+		s, err := collector.Collect(paths)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect stats for %s: %s", cfg.ProcName(), err)
+		}
+
+		name, err := jobid.Decode(cfg.ContainerID())
+		if err != nil {
+			return nil, err
+		}
+
+		samples = append(samples, &models.ProcessStats{
+			Name:           name,
+			Job:            cfg.JobName(),
+			Process:        cfg.ProcName(),
+			Pid:            process.Pid,
+			MemoryCurrent:  s.MemoryCurrent,
+			MemoryPeak:     s.MemoryPeak,
+			MemoryLimit:    s.MemoryLimit,
+			CPUUsage:       s.CPUUsage.Seconds(),
+			PidsCurrent:    s.PidsCurrent,
+			PidsLimit:      s.PidsLimit,
+			OpenFiles:      s.OpenFiles,
+			OpenFilesLimit: s.OpenFilesLimit,
+		})
+	}
+
+	return samples, nil
+}
+
+func printStats(w io.Writer, samples []*models.ProcessStats) error {
+	if statsJSON {
+		// Each refresh is written as a single line so that --watch produces
+		// a stream of JSON documents.
+		return presenters.PrintStatsJSON(samples, w)
+	}
+
+	if statsWatch {
+		fmt.Fprint(w, "\033[H\033[2J") //nolint:errcheck
+	}
+
+	return presenters.PrintStats(samples, w)
+}
+
+// configuredProcesses returns the configuration of every process of every job
+// on the system which has a valid bpm.yml.
+func configuredProcesses(stderr io.Writer) []*config.BPMConfig {
+	var cfgs []*config.BPMConfig
+
+	for _, job := range boshEnv.JobNames() {
+		jobCfg, err := config.NewBPMConfig(boshEnv, job, "").ParseJobConfig()
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid config for %s: %s\n", job, err.Error()) //nolint:errcheck
+			continue
+		}
+
+		for _, process := range jobCfg.Processes {
+			cfgs = append(cfgs, config.NewBPMConfig(boshEnv, job, process.Name))
+		}
+	}
+
+	return cfgs
+}
@@ -16,18 +16,24 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
+	"code.cloudfoundry.org/lager/v3"
 	"github.com/spf13/cobra"
 
+	"bpm/config"
 	"bpm/runc/lifecycle"
+	"bpm/supervisor"
 )
 
-const DefaultStopTimeout = 15 * time.Second
+var stopTimeout time.Duration
 
 func init() {
 	stopCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	stopCommand.Flags().DurationVar(&stopTimeout, "timeout", 0, "time to wait for the process to exit before it is killed (overrides shutdown_timeout)")
+	addAllFlag(stopCommand, "stop")
 	RootCmd.AddCommand(stopCommand)
 }
 
@@ -44,6 +50,14 @@ func stopPre(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := validateAllInput(cmd); err != nil {
+		return err
+	}
+
+	if stopTimeout < 0 || stopTimeout > config.MaxShutdownTimeout {
+		return fmt.Errorf("timeout must be between 0s and %s, but got %s", config.MaxShutdownTimeout, stopTimeout)
+	}
+
 	cmd.SilenceUsage = true
 
 	if err := setupBpmLogs("stop"); err != nil {
@@ -66,12 +80,30 @@ func stop(cmd *cobra.Command, _ []string) error {
 		return err
 	}
 
-	if _, err := runcLifecycle.StatProcess(bpmCfg); lifecycle.IsNotExist(err) {
-		logger.Info("job-already-stopped")
-		return nil
-	} else if err != nil {
-		logger.Error("failed-to-get-job", err)
-		return fmt.Errorf("failed to get job-process status: %s", err)
+	if allProcesses {
+		jobCfg, err := bpmCfg.ParseJobConfig()
+		if err != nil {
+			logger.Error("failed-to-parse-config", err)
+			return fmt.Errorf("failed to parse job configuration: %s", err)
+		}
+
+		return forEachProcess(jobCfg, dependentsFirst, withLifecycleLock("stop", func(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+			requestSupervisorStop(logger, bpmCfg)
+
+			if stopped, err := isProcessStopped(logger, bpmCfg, runcLifecycle); stopped || err != nil {
+				return err
+			}
+
+			return stopProcess(logger, bpmCfg, procCfg, runcLifecycle)
+		}))
+	}
+
+	requestSupervisorStop(logger, bpmCfg)
+
+	// The process is checked before the configuration is parsed so that a
+	// stopped process with a broken configuration can still be "stopped".
+	if stopped, err := isProcessStopped(logger, bpmCfg, runcLifecycle); stopped || err != nil {
+		return err
 	}
 
 	jobCfg, err := bpmCfg.ParseJobConfig()
@@ -86,7 +118,51 @@ func stop(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("process %q not present in job configuration (%s)", procName, bpmCfg.JobConfig())
 	}
 
-	if err := runcLifecycle.StopProcess(logger, bpmCfg, procCfg, DefaultStopTimeout); err != nil {
+	return stopProcess(logger, bpmCfg, procCfg, runcLifecycle)
+}
+
+// requestSupervisorStop stops `bpm supervise` from restarting the process once
+// it has been stopped. This is done even if the process is not running as the
+// supervisor may be waiting to restart it.
+func requestSupervisorStop(logger lager.Logger, bpmCfg *config.BPMConfig) {
+	if err := supervisor.RequestStop(bpmCfg.SupervisorStateFile().External()); err != nil {
+		logger.Error("failed-to-request-supervisor-stop", err)
+	}
+}
+
+// resetSupervisorState forgets the outcome of a `bpm supervise` which has
+// finished so that it is not reported for a process started by hand.
+func resetSupervisorState(logger lager.Logger, bpmCfg *config.BPMConfig) {
+	if err := supervisor.Reset(bpmCfg.SupervisorStateFile().External()); err != nil {
+		logger.Error("failed-to-reset-supervisor-state", err)
+	}
+}
+
+func isProcessStopped(logger lager.Logger, bpmCfg *config.BPMConfig, runcLifecycle *lifecycle.RuncLifecycle) (bool, error) {
+	if _, err := runcLifecycle.StatProcess(bpmCfg); lifecycle.IsNotExist(err) {
+		logger.Info("job-already-stopped")
+		return true, nil
+	} else if err != nil {
+		logger.Error("failed-to-get-job", err)
+		return false, fmt.Errorf("failed to get job-process status: %s", err)
+	}
+
+	return false, nil
+}
+
+func stopProcess(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig, runcLifecycle *lifecycle.RuncLifecycle) error {
+	timeout := procCfg.ParseShutdownTimeout()
+	if stopTimeout > 0 {
+		if len(procCfg.Shutdown) > 0 {
+			err := errors.New("--timeout cannot be used with a process which has a shutdown sequence")
+			logger.Error("invalid-timeout", err)
+			return err
+		}
+
+		timeout = stopTimeout
+	}
+
+	if err := runcLifecycle.StopProcess(logger, bpmCfg, procCfg, timeout); err != nil {
 		logger.Error("failed-to-stop", err)
 	}
 
@@ -95,5 +171,9 @@ func stop(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("failed to cleanup job-process: %s", err)
 	}
 
+	if err := runcLifecycle.RunPostStopHook(logger, bpmCfg, procCfg); err != nil {
+		logger.Error("post-stop-hook-failed", err)
+	}
+
 	return nil
 }
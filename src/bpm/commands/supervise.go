@@ -0,0 +1,271 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/spf13/cobra"
+
+	"bpm/config"
+	"bpm/models"
+	"bpm/runc/lifecycle"
+	"bpm/supervisor"
+)
+
+// superviseStartPollInterval is how often the container state is checked
+// while waiting for a supervised process to start.
+const superviseStartPollInterval = 100 * time.Millisecond
+
+func init() {
+	superviseCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	RootCmd.AddCommand(superviseCommand)
+}
+
+var superviseCommand = &cobra.Command{
+	Long:    "Runs a BOSH Process in the foreground and restarts it according to its restart_policy whenever it exits, backing off between restarts.",
+	RunE:    supervise,
+	Short:   "runs and restarts a BOSH Process",
+	Use:     "supervise <job-name>",
+	PreRunE: supervisePre,
+}
+
+func supervisePre(cmd *cobra.Command, args []string) error {
+	if err := validateInput(args); err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	return setupBpmLogs("supervise")
+}
+
+type supervisedExit struct {
+	status int
+	err    error
+}
+
+func supervise(cmd *cobra.Command, _ []string) error {
+	logger.Info("starting")
+	defer logger.Info("complete")
+
+	jobCfg, err := bpmCfg.ParseJobConfig()
+	if err != nil {
+		logger.Error("failed-to-parse-config", err)
+		return fmt.Errorf("failed to parse job configuration: %s", err)
+	}
+
+	procCfg, err := processByNameFromJobConfig(jobCfg, procName)
+	if err != nil {
+		logger.Error("process-not-defined", err)
+		return fmt.Errorf("process %q not present in job configuration (%s)", procName, bpmCfg.JobConfig())
+	}
+
+	runcLifecycle, err := newRuncLifecycle()
+	if err != nil {
+		return err
+	}
+
+	policy := supervisor.NewPolicy(procCfg.RestartPolicy)
+	statePath := bpmCfg.SupervisorStateFile().External()
+
+	// Signals sent to the supervisor stop the process rather than leaving it
+	// running unsupervised.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			logger.Info("received-signal", lager.Data{"signal": sig.String()})
+			err := withProcessLock(func() error {
+				requestSupervisorStop(logger, bpmCfg)
+
+				if stopped, err := isProcessStopped(logger, bpmCfg, runcLifecycle); stopped || err != nil {
+					return err
+				}
+
+				return stopProcess(logger.Session("stop"), bpmCfg, procCfg, runcLifecycle)
+			})
+			if err != nil {
+				logger.Error("failed-to-stop", err)
+			}
+		}
+	}()
+
+	state := &supervisor.State{}
+	for {
+		exited, err := startSupervised(logger, procCfg, runcLifecycle, state, statePath)
+		if err != nil {
+			return err
+		}
+
+		if exited == nil {
+			logger.Info("stop-requested")
+			return nil
+		}
+
+		startedAt := time.Now()
+		exit := <-exited
+		if exit.err != nil && exit.status == 0 {
+			// The process could not be run at all.
+			exit.status = 1
+		}
+
+		var backoff time.Duration
+		err = withProcessLock(func() error {
+			current, err := supervisor.ReadState(statePath)
+			if err != nil {
+				return err
+			}
+
+			state.Status = current.Status
+			state.LastExitStatus = &exit.status
+			state.LastExitTime = time.Now()
+
+			data := lager.Data{
+				"exit-status": exit.status,
+				"restarts":    state.Restarts,
+			}
+			if exit.err != nil {
+				data["error"] = exit.err.Error()
+			}
+			logger.Info("process-exited", data)
+
+			if state.Status == supervisor.StatusStopping {
+				state.Status = supervisor.StatusStopped
+				return supervisor.WriteState(statePath, state)
+			}
+
+			if time.Since(startedAt) >= policy.ResetAfter() {
+				state.Restarts = 0
+			}
+
+			if !policy.ShouldRestart(exit.status, state.Restarts) {
+				state.Status = supervisor.FinalStatus(exit.status)
+				return supervisor.WriteState(statePath, state)
+			}
+
+			backoff = policy.Backoff(state.Restarts)
+			state.Status = supervisor.StatusBackoff
+			return supervisor.WriteState(statePath, state)
+		})
+		if err != nil {
+			logger.Error("failed-to-record-exit", err)
+			return fmt.Errorf("failed to record exit of job-process: %s", err)
+		}
+
+		switch state.Status {
+		case supervisor.StatusStopped:
+			return nil
+		case supervisor.StatusFailed:
+			err := fmt.Errorf("job-process exited with status %d and will not be restarted (restarts: %d)", exit.status, state.Restarts)
+			logger.Error("giving-up", err)
+			return err
+		}
+
+		logger.Info("backing-off", lager.Data{"backoff": backoff.String()})
+		time.Sleep(backoff)
+		state.Restarts++
+	}
+}
+
+// startSupervised starts the process in the foreground of a goroutine and
+// returns once it is running. The returned channel receives the exit of the
+// process. It returns a nil channel if a stop has been requested. The
+// lifecycle lock is held while the process is being started but not while it
+// runs so that `bpm stop` can stop it.
+func startSupervised(
+	logger lager.Logger,
+	procCfg *config.ProcessConfig,
+	runcLifecycle *lifecycle.RuncLifecycle,
+	state *supervisor.State,
+	statePath string,
+) (<-chan supervisedExit, error) {
+	var exited chan supervisedExit
+
+	err := withProcessLock(func() error {
+		// A stop requested before the first start was meant for a previous
+		// supervisor which did not get to see it.
+		current, err := supervisor.ReadState(statePath)
+		if err == nil && current.Status == supervisor.StatusStopping && state.Status != "" {
+			state.Status = supervisor.StatusStopped
+			return supervisor.WriteState(statePath, state)
+		}
+
+		process, err := runcLifecycle.StatProcess(bpmCfg)
+		if err != nil && !lifecycle.IsNotExist(err) {
+			logger.Error("failed-getting-job", err)
+
+			if cerr := forceCleanupBrokenRuncState(logger, bpmCfg, runcLifecycle); cerr != nil {
+				logger.Error("failed-cleaning-up-broken-job", cerr)
+				return cerr
+			}
+		} else if process != nil && process.Status == models.ProcessStateRunning {
+			return errors.New("job-process is already running")
+		} else if process != nil {
+			if err := runcLifecycle.RemoveProcess(logger, bpmCfg); err != nil {
+				logger.Error("failed-to-cleanup", err)
+				return fmt.Errorf("failed to clean up stale job-process: %s", err)
+			}
+		}
+
+		state.Status = supervisor.StatusRunning
+		if err := supervisor.WriteState(statePath, state); err != nil {
+			return err
+		}
+
+		exited = make(chan supervisedExit, 1)
+		go func() {
+			status, err := runcLifecycle.RunProcess(logger, bpmCfg, procCfg)
+			exited <- supervisedExit{status: status, err: err}
+		}()
+
+		for {
+			select {
+			case exit := <-exited:
+				// Put the exit back for the caller to receive.
+				exited <- exit
+				return nil
+			case <-time.After(superviseStartPollInterval):
+				if process, err := runcLifecycle.StatProcess(bpmCfg); err == nil && process.Status == models.ProcessStateRunning {
+					return nil
+				}
+			}
+		}
+	})
+	if err != nil {
+		logger.Error("failed-to-start", err)
+		return nil, fmt.Errorf("failed to start job-process: %s", err)
+	}
+
+	return exited, nil
+}
+
+func withProcessLock(f func() error) error {
+	lock, err := locks.LockJob(bpmCfg.JobName(), bpmCfg.ProcName())
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock() //nolint:errcheck
+
+	return f()
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/spf13/cobra"
+
+	"bpm/config"
+	"bpm/runc/lifecycle"
+)
+
+func init() {
+	updateCommand.Flags().StringVarP(&procName, "process", "p", "", "optional process name")
+	addAllFlag(updateCommand, "update")
+	RootCmd.AddCommand(updateCommand)
+}
+
+var updateCommand = &cobra.Command{
+	Long:     "Applies changes to the limits of a BOSH Process, such as its memory or processes limit, to it while it is running. Changes to limits which cannot be applied without a restart, such as its open_files limit, or to its volumes are rejected. Other changes, such as to its environment, are reported as needing a restart.",
+	RunE:     update,
+	Short:    "updates the limits of a running BOSH Process",
+	Use:      "update <job-name>",
+	PreRunE:  updatePre,
+	PostRunE: updatePost,
+}
+
+func updatePre(cmd *cobra.Command, args []string) error {
+	if err := validateInput(args); err != nil {
+		return err
+	}
+
+	if err := validateAllInput(cmd); err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	if err := setupBpmLogs("update"); err != nil {
+		return err
+	}
+
+	return acquireLifecycleLock()
+}
+
+func updatePost(cmd *cobra.Command, args []string) error {
+	return releaseLifecycleLock()
+}
+
+func update(cmd *cobra.Command, _ []string) error {
+	logger.Info("starting")
+	defer logger.Info("complete")
+
+	jobCfg, err := bpmCfg.ParseJobConfig()
+	if err != nil {
+		logger.Error("failed-to-parse-config", err)
+		return fmt.Errorf("failed to parse job configuration: %s", err)
+	}
+
+	runcLifecycle, err := newRuncLifecycle()
+	if err != nil {
+		return err
+	}
+
+	if allProcesses {
+		return forEachProcess(jobCfg, anyOrder, withLifecycleLock("update", func(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+			return updateLimits(logger, bpmCfg, procCfg, runcLifecycle)
+		}))
+	}
+
+	procCfg, err := processByNameFromJobConfig(jobCfg, procName)
+	if err != nil {
+		logger.Error("process-not-defined", err)
+		return fmt.Errorf("process %q not present in job configuration (%s)", procName, bpmCfg.JobConfig())
+	}
+
+	return updateLimits(logger, bpmCfg, procCfg, runcLifecycle)
+}
+
+func updateLimits(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig, runcLifecycle *lifecycle.RuncLifecycle) error {
+	if _, err := runningProcess(bpmCfg, runcLifecycle); err != nil {
+		return err
+	}
+
+	_, pending, err := runcLifecycle.UpdateProcess(logger, bpmCfg, procCfg)
+	if err != nil {
+		logger.Error("failed-to-update", err)
+		return fmt.Errorf("failed to update job-process: %s", err)
+	}
+
+	if len(pending) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: limits are up to date but the process must be restarted to apply changes to: %s\n", procCfg.Name, strings.Join(pending, ", ")) //nolint:errcheck
+	}
+
+	return nil
+}
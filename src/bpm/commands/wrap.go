@@ -0,0 +1,112 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"bpm/exitstatus"
+)
+
+// exitStatusFd is the file descriptor which a process started in the
+// background is given to write its exit status to. It is not open for a
+// process run in the foreground as its exit status is returned by runc.
+const exitStatusFd = 3
+
+func init() {
+	RootCmd.AddCommand(wrapCommand)
+}
+
+// wrapCommand runs inside the container of a process, between its init and
+// the process itself, to record the exit status of the process. Nothing else
+// outlives a container started in the background to learn it.
+var wrapCommand = &cobra.Command{
+	Args:               cobra.MinimumNArgs(1),
+	DisableFlagParsing: true,
+	Hidden:             true,
+	RunE:               wrap,
+	Short:              "runs a process inside its container and records its exit status",
+	Use:                "wrap <executable> [args...]",
+
+	// The process is not run as root so none of the setup of the other
+	// commands can be done.
+	PersistentPreRunE: func(*cobra.Command, []string) error { return nil },
+}
+
+func wrap(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	// This must be looked at before anything else is opened as the file
+	// descriptor would otherwise be reused. It is not passed on to the
+	// process.
+	var exitStatusFile *os.File
+	var stat syscall.Stat_t
+	if err := syscall.Fstat(exitStatusFd, &stat); err == nil && stat.Mode&syscall.S_IFMT == syscall.S_IFREG {
+		syscall.CloseOnExec(exitStatusFd)
+		exitStatusFile = os.NewFile(exitStatusFd, "exit-status")
+	}
+
+	child := exec.Command(args[0], args[1:]...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals)
+
+	if err := child.Start(); err != nil {
+		return err
+	}
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- child.Wait()
+	}()
+
+	for {
+		select {
+		case sig := <-signals:
+			// SIGURG is also sent by the Go runtime to preempt goroutines.
+			if sig == syscall.SIGCHLD || sig == syscall.SIGURG {
+				continue
+			}
+			child.Process.Signal(sig) //nolint:errcheck
+		case err := <-errCh:
+			var exitErr *exec.ExitError
+			if err != nil && !errors.As(err, &exitErr) {
+				return err
+			}
+
+			status := exitstatus.FromWaitStatus(child.ProcessState.Sys().(syscall.WaitStatus))
+			if exitStatusFile != nil {
+				fmt.Fprintf(exitStatusFile, "%d\n", status) //nolint:errcheck
+			}
+
+			if status == 0 {
+				return nil
+			}
+
+			return &exitstatus.Error{Status: status, Quiet: true, Err: err}
+		}
+	}
+}
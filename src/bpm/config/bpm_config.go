@@ -18,6 +18,7 @@ package config
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"bpm/bosh"
 	"bpm/jobid"
@@ -42,6 +43,7 @@ func LocksPath(env *bosh.Env) string {
 type BPMConfig struct {
 	jobName  string
 	procName string
+	hook     string
 
 	boshEnv *bosh.Env
 }
@@ -54,6 +56,15 @@ func NewBPMConfig(boshEnv *bosh.Env, jobName, procName string) *BPMConfig {
 	}
 }
 
+// ForHook returns a copy of the configuration for running a hook of the
+// process in its own container. The copy has a different container ID and
+// bundle path but shares everything else (e.g. logs) with the process.
+func (c *BPMConfig) ForHook(hook string) *BPMConfig {
+	hookCfg := *c
+	hookCfg.hook = hook
+	return &hookCfg
+}
+
 func (c *BPMConfig) JobName() string {
 	return c.jobName
 }
@@ -98,6 +109,24 @@ func (c *BPMConfig) PidFile() bosh.Path {
 	return c.PidDir().Join(fmt.Sprintf("%s.pid", c.procName))
 }
 
+// HistoryFile is where the start and stop of each run of the process is
+// recorded.
+func (c *BPMConfig) HistoryFile() bosh.Path {
+	return c.PidDir().Join(fmt.Sprintf("%s.history.json", c.procName))
+}
+
+// SupervisorStateFile is where `bpm supervise` records the state of the
+// process it is supervising.
+func (c *BPMConfig) SupervisorStateFile() bosh.Path {
+	return c.PidDir().Join(fmt.Sprintf("%s.supervisor.json", c.procName))
+}
+
+// ExitStatusFile is where the exit status of a process started in the
+// background is written when it exits.
+func (c *BPMConfig) ExitStatusFile() bosh.Path {
+	return c.PidDir().Join(fmt.Sprintf("%s.exit", c.procName))
+}
+
 func (c *BPMConfig) LockFile() bosh.Path {
 	return c.PidDir().Join(fmt.Sprintf("%s.lock", c.procName))
 }
@@ -122,12 +151,25 @@ func (c *BPMConfig) TiniPath() bosh.Path {
 	return c.PackageDir().Join("bpm", "bin", "tini")
 }
 
+// BPMPath is the bpm executable itself, which wraps the process in its
+// container so that its exit status can be recorded.
+func (c *BPMConfig) BPMPath() bosh.Path {
+	return c.PackageDir().Join("bpm", "bin", "bpm")
+}
+
 func (c *BPMConfig) DefaultVolumes() []string {
 	return []string{c.DataDir().External(), c.StoreDir().External()}
 }
 
 func (c *BPMConfig) ParseJobConfig() (*JobConfig, error) {
-	cfg, err := ParseJobConfig(c.JobConfig())
+	return c.ParseJobConfigFile(c.JobConfig())
+}
+
+// ParseJobConfigFile parses and validates the job configuration at path as if
+// it were the configuration of this job, e.g. to check a configuration before
+// it is deployed.
+func (c *BPMConfig) ParseJobConfigFile(path string) (*JobConfig, error) {
+	cfg, err := ParseJobConfig(path)
 	if err != nil {
 		return nil, err
 	}
@@ -145,6 +187,10 @@ func (c *BPMConfig) BPMLog() string {
 }
 
 func (c *BPMConfig) BundlePath() string {
+	if c.hook != "" {
+		return filepath.Join(BundlesRoot(c.boshEnv), c.jobName, fmt.Sprintf("%s+%s", c.procName, c.hook))
+	}
+
 	return filepath.Join(BundlesRoot(c.boshEnv), c.jobName, c.procName)
 }
 
@@ -161,5 +207,16 @@ func (c *BPMConfig) ContainerID() string {
 		containerID = fmt.Sprintf("%s.%s", c.jobName, c.procName)
 	}
 
+	if c.hook != "" {
+		containerID = fmt.Sprintf("%s+%s", containerID, c.hook)
+	}
+
 	return jobid.Encode(containerID)
 }
+
+// IsHookContainerID returns whether the container ID is one which ForHook
+// gives to a hook rather than one of a process.
+func IsHookContainerID(containerID string) bool {
+	name, err := jobid.Decode(containerID)
+	return err == nil && strings.Contains(name, "+")
+}
@@ -56,6 +56,58 @@ var _ = Describe("Config", func() {
 					Expect(decoded).To(Equal("foo.bar"))
 				})
 			})
+
+			Context("when the configuration is for a hook", func() {
+				BeforeEach(func() {
+					env := bosh.NewEnv("")
+					bpmCfg = config.NewBPMConfig(env, "foo", "bar").ForHook("pre-stop")
+				})
+
+				It("encodes", func() {
+					encoded := bpmCfg.ContainerID()
+					decoded, err := jobid.Decode(encoded)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(decoded).To(Equal("foo.bar+pre-stop"))
+				})
+
+				It("is recognised as the container of a hook", func() {
+					Expect(config.IsHookContainerID(bpmCfg.ContainerID())).To(BeTrue())
+					Expect(config.IsHookContainerID(config.NewBPMConfig(bosh.NewEnv(""), "foo", "bar").ContainerID())).To(BeFalse())
+				})
+			})
+		})
+	})
+
+	Describe("ParseJobConfigFile", func() {
+		var bpmCfg *config.BPMConfig
+
+		BeforeEach(func() {
+			bpmCfg = config.NewBPMConfig(bosh.NewEnv(""), "foo", "bar")
+		})
+
+		It("parses the configuration at the path", func() {
+			cfg, err := bpmCfg.ParseJobConfigFile("testdata/example.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Processes).NotTo(BeEmpty())
+		})
+
+		It("validates the configuration", func() {
+			_, err := bpmCfg.ParseJobConfigFile("testdata/example-invalid.yml")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ForHook", func() {
+		It("only changes the container and bundle of the process", func() {
+			env := bosh.NewEnv("/root")
+			bpmCfg := config.NewBPMConfig(env, "foo", "bar")
+			hookCfg := bpmCfg.ForHook("post-start")
+
+			Expect(hookCfg.BundlePath()).To(Equal("/root/data/bpm/bundles/foo/bar+post-start"))
+			Expect(hookCfg.RootFSPath()).To(Equal("/root/data/bpm/bundles/foo/bar+post-start/rootfs"))
+			Expect(hookCfg.Stdout()).To(Equal(bpmCfg.Stdout()))
+			Expect(hookCfg.DataDir()).To(Equal(bpmCfg.DataDir()))
+			Expect(bpmCfg.BundlePath()).To(Equal("/root/data/bpm/bundles/foo/bar"))
 		})
 	})
 })
@@ -21,6 +21,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v3"
 
@@ -28,6 +29,52 @@ import (
 	"bpm/runc/client"
 )
 
+const (
+	// DefaultShutdownTimeout is how long a process is given to exit after
+	// being sent its shutdown signal when no shutdown_timeout is configured.
+	DefaultShutdownTimeout = 15 * time.Second
+
+	// MaxShutdownTimeout is the longest shutdown_timeout which may be
+	// configured.
+	MaxShutdownTimeout = 10 * time.Minute
+
+	// DefaultHookTimeout is how long the post_start, pre_stop, and post_stop
+	// hooks may run for when no timeout is configured. The pre_start hook
+	// has no default timeout for backwards compatibility.
+	DefaultHookTimeout = 30 * time.Second
+
+	// MaxHookTimeout is the longest timeout which may be configured for a
+	// hook.
+	MaxHookTimeout = 10 * time.Minute
+
+	// DefaultProbeInterval is how long to wait between attempts of a probe
+	// when no interval is configured.
+	DefaultProbeInterval = 1 * time.Second
+
+	// DefaultProbeTimeout is how long a single attempt of a probe may take
+	// when no timeout is configured.
+	DefaultProbeTimeout = 1 * time.Second
+
+	// DefaultRestartBackoff is how long `bpm supervise` waits before the first
+	// restart of a process when no backoff is configured. The wait doubles
+	// with each consecutive restart.
+	DefaultRestartBackoff = 1 * time.Second
+
+	// DefaultRestartMaxBackoff is the longest `bpm supervise` waits between
+	// restarts when no max_backoff is configured.
+	DefaultRestartMaxBackoff = 1 * time.Minute
+
+	// DefaultReadinessFailureThreshold is how many attempts of a readiness
+	// probe must fail in a row before it is considered to have failed when no
+	// threshold is configured.
+	DefaultReadinessFailureThreshold = 30
+
+	// DefaultLivenessFailureThreshold is how many attempts of a liveness probe
+	// must fail in a row before it is considered to have failed when no
+	// threshold is configured.
+	DefaultLivenessFailureThreshold = 1
+)
+
 type JobConfig struct {
 	Processes []*ProcessConfig `yaml:"processes"`
 }
@@ -46,17 +93,126 @@ type ProcessConfig struct {
 	WorkDir           string            `yaml:"workdir"`
 	Unsafe            *Unsafe           `yaml:"unsafe"`
 	ShutdownSignal    string            `yaml:"shutdown_signal"`
+	ShutdownTimeout   string            `yaml:"shutdown_timeout"`
+	Shutdown          []ShutdownStep    `yaml:"shutdown"`
+	ReloadSignal      string            `yaml:"reload_signal,omitempty"`
+	Readiness         *Probe            `yaml:"readiness,omitempty"`
+	Liveness          *Probe            `yaml:"liveness,omitempty"`
+	DependsOn         []string          `yaml:"depends_on,omitempty"`
+	RestartPolicy     *RestartPolicy    `yaml:"restart_policy,omitempty"`
+}
+
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+// RestartPolicy describes when `bpm supervise` restarts a process after it
+// exits. It may be written in the configuration as either just the policy or
+// as a mapping which also includes options.
+type RestartPolicy struct {
+	Policy     string `yaml:"policy"`
+	MaxRetries int    `yaml:"max_retries,omitempty"`
+	Backoff    string `yaml:"backoff,omitempty"`
+	MaxBackoff string `yaml:"max_backoff,omitempty"`
+}
+
+func (r *RestartPolicy) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&r.Policy)
+	}
+
+	type plainRestartPolicy RestartPolicy
+	return value.Decode((*plainRestartPolicy)(r))
+}
+
+// ShutdownStep is a single step of a shutdown sequence. The signal is sent to
+// the process and then bpm waits for up to the wait duration for it to exit
+// before moving on to the next step.
+type ShutdownStep struct {
+	Signal string `yaml:"signal"`
+	Wait   string `yaml:"wait"`
+}
+
+// Probe is a check of the health of a running process. Exactly one of TCP,
+// HTTP, Socket, or Exec should be set.
+type Probe struct {
+	TCP              *TCPProbe  `yaml:"tcp,omitempty"`
+	HTTP             *HTTPProbe `yaml:"http,omitempty"`
+	Socket           string     `yaml:"socket,omitempty"`
+	Exec             []string   `yaml:"exec,omitempty"`
+	Interval         string     `yaml:"interval,omitempty"`
+	Timeout          string     `yaml:"timeout,omitempty"`
+	FailureThreshold int        `yaml:"failure_threshold,omitempty"`
+}
+
+// TCPProbe succeeds if a connection can be made to the port on localhost.
+type TCPProbe struct {
+	Port int `yaml:"port"`
+}
+
+// HTTPProbe succeeds if a GET request to the path on localhost returns a 2xx
+// or 3xx status code.
+type HTTPProbe struct {
+	Port int    `yaml:"port"`
+	Path string `yaml:"path,omitempty"`
 }
 
 type Limits struct {
-	Memory       *string `yaml:"memory"`
-	OpenFiles    *uint64 `yaml:"open_files"`
-	Processes    *int64  `yaml:"processes"`
-	CoreFileSize *uint64 `yaml:"core_file_size"`
+	Memory            *string  `yaml:"memory"`
+	MemoryReservation *string  `yaml:"memory_reservation"`
+	MemoryHigh        *string  `yaml:"memory_high"`
+	Swap              *string  `yaml:"swap"`
+	OOMScoreAdj       *int     `yaml:"oom_score_adj"`
+	OpenFiles         *uint64  `yaml:"open_files"`
+	Processes         *int64   `yaml:"processes"`
+	CoreFileSize      *uint64  `yaml:"core_file_size"`
+	CPUs              *float64 `yaml:"cpus"`
+	CPUQuota          *int64   `yaml:"cpu_quota"`
+	CPUPeriod         *uint64  `yaml:"cpu_period"`
+	CPUShares         *uint64  `yaml:"cpu_shares"`
+	CPUWeight         *uint64  `yaml:"cpu_weight"`
+	CPUSetCPUs        *string  `yaml:"cpuset_cpus"`
+	IO                *IO      `yaml:"io"`
+}
+
+type IO struct {
+	Weight  *uint16    `yaml:"weight"`
+	Devices []IODevice `yaml:"devices"`
+}
+
+type IODevice struct {
+	Path      string  `yaml:"path"`
+	ReadBPS   *string `yaml:"read_bps"`
+	WriteBPS  *string `yaml:"write_bps"`
+	ReadIOPS  *uint64 `yaml:"read_iops"`
+	WriteIOPS *uint64 `yaml:"write_iops"`
 }
 
 type Hooks struct {
-	PreStart string `yaml:"pre_start"`
+	PreStart  Hook `yaml:"pre_start"`
+	PostStart Hook `yaml:"post_start"`
+	PreStop   Hook `yaml:"pre_stop"`
+	PostStop  Hook `yaml:"post_stop"`
+}
+
+// Hook is an executable which is run at a point in the lifecycle of a
+// process. It may be written in the configuration as either just the path to
+// the executable or as a mapping which also includes options.
+type Hook struct {
+	Path           string `yaml:"path"`
+	Timeout        string `yaml:"timeout,omitempty"`
+	RunInContainer bool   `yaml:"run_in_container,omitempty"`
+}
+
+func (h *Hook) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&h.Path)
+	}
+
+	type plainHook Hook
+	return value.Decode((*plainHook)(h))
 }
 
 type Volume struct {
@@ -90,10 +246,68 @@ func ParseJobConfig(configPath string) (*JobConfig, error) {
 }
 
 func (c *JobConfig) Validate(boshEnv *bosh.Env, defaultVolumes []string) error {
+	names := map[string]bool{}
 	for _, v := range c.Processes {
 		if err := v.Validate(boshEnv, defaultVolumes); err != nil {
 			return err
 		}
+
+		if names[v.Name] {
+			return fmt.Errorf("invalid processes: duplicate process name %s", v.Name)
+		}
+		names[v.Name] = true
+	}
+
+	return c.validateDependencies()
+}
+
+// validateDependencies checks that every process named in depends_on exists
+// and that the dependencies do not form a cycle.
+func (c *JobConfig) validateDependencies() error {
+	processes := map[string]*ProcessConfig{}
+	for _, p := range c.Processes {
+		processes[p.Name] = p
+	}
+
+	for _, p := range c.Processes {
+		for _, dep := range p.DependsOn {
+			if _, ok := processes[dep]; !ok {
+				return fmt.Errorf("invalid depends_on for process %s: unknown process %s", p.Name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("invalid depends_on: dependency cycle %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		for _, dep := range processes[name].DependsOn {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+
+		return nil
+	}
+
+	for _, p := range c.Processes {
+		if err := visit(p.Name, nil); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -130,15 +344,276 @@ func (c *ProcessConfig) Validate(boshEnv *bosh.Env, defaultVolumes []string) err
 		}
 	}
 
+	if c.Limits != nil {
+		if err := c.Limits.validate(c.Unsafe != nil && c.Unsafe.Privileged); err != nil {
+			return err
+		}
+	}
+
 	if c.ShutdownSignal != "" && c.ShutdownSignal != "TERM" && c.ShutdownSignal != "INT" {
 		return fmt.Errorf(
 			"shutdown signal should either be 'TERM' or 'INT' (or left unspecified), but got '%s'",
 			c.ShutdownSignal)
 	}
 
+	if c.ShutdownTimeout != "" {
+		timeout, err := time.ParseDuration(c.ShutdownTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid shutdown timeout: %s", err)
+		}
+
+		if timeout <= 0 || timeout > MaxShutdownTimeout {
+			return fmt.Errorf("shutdown timeout must be greater than 0s and at most %s, but got '%s'", MaxShutdownTimeout, c.ShutdownTimeout)
+		}
+	}
+
+	if c.Hooks != nil {
+		if err := c.Hooks.validate(); err != nil {
+			return err
+		}
+	}
+
+	if len(c.Shutdown) > 0 {
+		if c.ShutdownSignal != "" || c.ShutdownTimeout != "" {
+			return errors.New("shutdown cannot be combined with shutdown_signal or shutdown_timeout")
+		}
+
+		if err := validateShutdownSteps(c.Shutdown); err != nil {
+			return err
+		}
+	}
+
+	if c.ReloadSignal != "" {
+		signal, err := parseLifecycleSignal(c.ReloadSignal)
+		if err != nil {
+			return fmt.Errorf("invalid reload signal: %s", err)
+		}
+
+		// A process cannot handle KILL so it can never reload on it.
+		if signal == client.Kill {
+			return errors.New("invalid reload signal: KILL cannot make a process reload")
+		}
+	}
+
+	if c.Readiness != nil {
+		if err := c.Readiness.validate("readiness"); err != nil {
+			return err
+		}
+	}
+
+	if c.Liveness != nil {
+		if err := c.Liveness.validate("liveness"); err != nil {
+			return err
+		}
+	}
+
+	if c.RestartPolicy != nil {
+		if err := c.RestartPolicy.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Probe) validate(name string) error {
+	var checks int
+	if p.TCP != nil {
+		checks++
+		if err := validatePort(p.TCP.Port); err != nil {
+			return fmt.Errorf("invalid %s probe: %s", name, err)
+		}
+	}
+
+	if p.HTTP != nil {
+		checks++
+		if err := validatePort(p.HTTP.Port); err != nil {
+			return fmt.Errorf("invalid %s probe: %s", name, err)
+		}
+
+		if p.HTTP.Path != "" && !strings.HasPrefix(p.HTTP.Path, "/") {
+			return fmt.Errorf("invalid %s probe: http path must start with '/', but got '%s'", name, p.HTTP.Path)
+		}
+	}
+
+	if p.Socket != "" {
+		checks++
+		if !filepath.IsLocal(p.Socket) {
+			return fmt.Errorf("invalid %s probe: socket must be a relative path within the socket directory, but got '%s'", name, p.Socket)
+		}
+	}
+
+	if len(p.Exec) > 0 {
+		checks++
+	}
+
+	if checks != 1 {
+		return fmt.Errorf("invalid %s probe: exactly one of tcp, http, socket, or exec must be specified", name)
+	}
+
+	durations := []struct {
+		field string
+		value string
+	}{
+		{"interval", p.Interval},
+		{"timeout", p.Timeout},
+	}
+
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s probe %s: %s", name, d.field, err)
+		}
+
+		if duration <= 0 {
+			return fmt.Errorf("%s probe %s must be greater than 0s, but got '%s'", name, d.field, d.value)
+		}
+	}
+
+	if p.FailureThreshold < 0 {
+		return fmt.Errorf("%s probe failure_threshold must not be negative, but got %d", name, p.FailureThreshold)
+	}
+
 	return nil
 }
 
+func (r *RestartPolicy) validate() error {
+	switch r.Policy {
+	case RestartNever, RestartOnFailure, RestartAlways:
+	default:
+		return fmt.Errorf("restart policy should be one of '%s', '%s', or '%s', but got '%s'", RestartNever, RestartOnFailure, RestartAlways, r.Policy)
+	}
+
+	if r.MaxRetries < 0 {
+		return fmt.Errorf("restart policy max_retries must not be negative, but got %d", r.MaxRetries)
+	}
+
+	durations := []struct {
+		field string
+		value string
+	}{
+		{"backoff", r.Backoff},
+		{"max_backoff", r.MaxBackoff},
+	}
+
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("invalid restart policy %s: %s", d.field, err)
+		}
+
+		if duration <= 0 {
+			return fmt.Errorf("restart policy %s must be greater than 0s, but got '%s'", d.field, d.value)
+		}
+	}
+
+	if r.ParseBackoff() > r.ParseMaxBackoff() {
+		return fmt.Errorf("restart policy backoff (%s) must not be greater than max_backoff (%s)", r.ParseBackoff(), r.ParseMaxBackoff())
+	}
+
+	return nil
+}
+
+func validatePort(port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, but got %d", port)
+	}
+
+	return nil
+}
+
+func (h *Hooks) validate() error {
+	hooks := []struct {
+		name string
+		hook Hook
+	}{
+		{"pre_start", h.PreStart},
+		{"post_start", h.PostStart},
+		{"pre_stop", h.PreStop},
+		{"post_stop", h.PostStop},
+	}
+
+	for _, hook := range hooks {
+		if hook.hook.Path == "" {
+			if hook.hook.Timeout != "" {
+				return fmt.Errorf("invalid %s hook: timeout specified without a path", hook.name)
+			}
+			continue
+		}
+
+		if hook.hook.Timeout == "" {
+			continue
+		}
+
+		timeout, err := time.ParseDuration(hook.hook.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid %s hook timeout: %s", hook.name, err)
+		}
+
+		if timeout <= 0 || timeout > MaxHookTimeout {
+			return fmt.Errorf("%s hook timeout must be greater than 0s and at most %s, but got '%s'", hook.name, MaxHookTimeout, hook.hook.Timeout)
+		}
+	}
+
+	return nil
+}
+
+func validateShutdownSteps(steps []ShutdownStep) error {
+	var total time.Duration
+	for i, step := range steps {
+		if _, err := parseLifecycleSignal(step.Signal); err != nil {
+			return fmt.Errorf("invalid shutdown step %d: %s", i+1, err)
+		}
+
+		if step.Wait == "" {
+			continue
+		}
+
+		wait, err := time.ParseDuration(step.Wait)
+		if err != nil {
+			return fmt.Errorf("invalid shutdown step %d: %s", i+1, err)
+		}
+
+		if wait < 0 {
+			return fmt.Errorf("invalid shutdown step %d: wait must not be negative", i+1)
+		}
+
+		total += wait
+	}
+
+	if total > MaxShutdownTimeout {
+		return fmt.Errorf("shutdown steps must wait for at most %s in total, but got %s", MaxShutdownTimeout, total)
+	}
+
+	return nil
+}
+
+// parseLifecycleSignal parses a signal which is sent to end or reload a
+// process. Signals which pause or resume a process, or which are ignored
+// unless a process asks for them, cannot do either and are rejected: a STOP in
+// a shutdown sequence would freeze the process until it is killed.
+func parseLifecycleSignal(name string) (client.Signal, error) {
+	signal, err := client.ParseSignal(name)
+	if err != nil {
+		return 0, err
+	}
+
+	switch signal {
+	case client.Stop, client.Tstp, client.Ttin, client.Ttou, client.Cont, client.Chld, client.Urg:
+		return 0, fmt.Errorf("%s cannot end or reload a process", signal)
+	}
+
+	return signal, nil
+}
+
 func (c *ProcessConfig) ParseShutdownSignal() client.Signal {
 	switch c.ShutdownSignal {
 	case "INT":
@@ -148,6 +623,119 @@ func (c *ProcessConfig) ParseShutdownSignal() client.Signal {
 	}
 }
 
+// ParseReloadSignal returns the signal which makes the process reload its
+// configuration and whether it has one. It assumes that the configuration has
+// already been validated.
+func (c *ProcessConfig) ParseReloadSignal() (client.Signal, bool) {
+	signal, err := client.ParseSignal(c.ReloadSignal)
+	if err != nil {
+		return 0, false
+	}
+
+	return signal, true
+}
+
+// ParseShutdownTimeout returns how long the process should be given to exit
+// after being sent its shutdown signal. It assumes that the configuration has
+// already been validated.
+func (c *ProcessConfig) ParseShutdownTimeout() time.Duration {
+	timeout, err := time.ParseDuration(c.ShutdownTimeout)
+	if err != nil {
+		return DefaultShutdownTimeout
+	}
+
+	return timeout
+}
+
+// ParseTimeout returns how long the hook may run for before it is killed. A
+// zero duration means that it may run forever. It assumes that the
+// configuration has already been validated.
+func (h Hook) ParseTimeout(defaultTimeout time.Duration) time.Duration {
+	timeout, err := time.ParseDuration(h.Timeout)
+	if err != nil {
+		return defaultTimeout
+	}
+
+	return timeout
+}
+
+// ParseInterval returns how long to wait between attempts of the probe. It
+// assumes that the configuration has already been validated.
+func (p *Probe) ParseInterval() time.Duration {
+	interval, err := time.ParseDuration(p.Interval)
+	if err != nil {
+		return DefaultProbeInterval
+	}
+
+	return interval
+}
+
+// ParseTimeout returns how long a single attempt of the probe may take. It
+// assumes that the configuration has already been validated.
+func (p *Probe) ParseTimeout() time.Duration {
+	timeout, err := time.ParseDuration(p.Timeout)
+	if err != nil {
+		return DefaultProbeTimeout
+	}
+
+	return timeout
+}
+
+// Threshold returns how many attempts of the probe must fail in a row before
+// it is considered to have failed.
+func (p *Probe) Threshold(defaultThreshold int) int {
+	if p.FailureThreshold == 0 {
+		return defaultThreshold
+	}
+
+	return p.FailureThreshold
+}
+
+// ParseBackoff returns how long to wait before the first restart. It assumes
+// that the configuration has already been validated.
+func (r *RestartPolicy) ParseBackoff() time.Duration {
+	backoff, err := time.ParseDuration(r.Backoff)
+	if err != nil {
+		return DefaultRestartBackoff
+	}
+
+	return backoff
+}
+
+// ParseMaxBackoff returns the longest wait between restarts. It assumes that
+// the configuration has already been validated.
+func (r *RestartPolicy) ParseMaxBackoff() time.Duration {
+	maxBackoff, err := time.ParseDuration(r.MaxBackoff)
+	if err != nil {
+		return DefaultRestartMaxBackoff
+	}
+
+	return maxBackoff
+}
+
+// ParseSignal returns the signal to send for this step. It assumes that the
+// configuration has already been validated.
+func (s ShutdownStep) ParseSignal() client.Signal {
+	signal, err := client.ParseSignal(s.Signal)
+	if err != nil {
+		return client.Term
+	}
+
+	return signal
+}
+
+// ParseWait returns how long to wait for the process to exit after sending
+// the signal for this step. It assumes that the configuration has already been
+// validated.
+func (s ShutdownStep) ParseWait() time.Duration {
+	wait, err := time.ParseDuration(s.Wait)
+	if err != nil {
+		return 0
+	}
+
+	return wait
+}
+
 func (c *ProcessConfig) AddVolumes(
 	volumes []string,
 	boshEnv *bosh.Env,
@@ -16,11 +16,14 @@
 package config_test
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"bpm/bosh"
 	"bpm/config"
+	"bpm/runc/client"
 )
 
 var _ = Describe("Config", func() {
@@ -61,7 +64,14 @@ var _ = Describe("Config", func() {
 				config.Volume{Path: "/var/vcap/data/jna-tmp", Writable: true, AllowExecutions: true},
 				config.Volume{Path: "/var/vcap/data/shared", Shared: true},
 			))
-			Expect(cfg.Processes[0].Hooks.PreStart).To(Equal("/var/vcap/jobs/program/bin/pre"))
+			Expect(cfg.Processes[0].Hooks.PreStart.Path).To(Equal("/var/vcap/jobs/program/bin/pre"))
+			Expect(cfg.Processes[0].Hooks.PreStop).To(Equal(config.Hook{Path: "/var/vcap/jobs/program/bin/deregister", Timeout: "45s", RunInContainer: true}))
+			Expect(cfg.Processes[0].Readiness).To(Equal(&config.Probe{
+				HTTP:             &config.HTTPProbe{Port: 2424, Path: "/healthz"},
+				Interval:         "2s",
+				FailureThreshold: 10,
+			}))
+			Expect(cfg.Processes[0].RestartPolicy).To(Equal(&config.RestartPolicy{Policy: "on-failure", MaxRetries: 5, Backoff: "2s"}))
 			Expect(cfg.Processes[0].Capabilities).To(ConsistOf("NET_BIND_SERVICE", "SYS_TIME"))
 			Expect(cfg.Processes[0].WorkDir).To(Equal("/I/AM/A/WORKDIR"))
 			Expect(cfg.Processes[0].PersistentDisk).To(BeTrue())
@@ -77,11 +87,13 @@ var _ = Describe("Config", func() {
 			Expect(cfg.Processes[1].Name).To(Equal("second-process"))
 			Expect(cfg.Processes[1].Executable).To(Equal("/I/AM/A/SECOND-EXECUTABLE"))
 			Expect(cfg.Processes[1].Hooks).To(BeNil())
+			Expect(cfg.Processes[1].Readiness).To(BeNil())
+			Expect(cfg.Processes[1].RestartPolicy).To(Equal(&config.RestartPolicy{Policy: "always"}))
 			Expect(cfg.Processes[1].Unsafe).To(BeNil())
 
 			Expect(cfg.Processes[2].Name).To(Equal("third-process"))
 			Expect(cfg.Processes[2].Executable).To(Equal("/I/AM/A/THIRD-EXECUTABLE"))
-			Expect(cfg.Processes[2].Hooks.PreStart).To(BeEmpty())
+			Expect(cfg.Processes[2].Hooks.PreStart.Path).To(BeEmpty())
 			Expect(cfg.Processes[2].Unsafe).To(BeNil())
 		})
 
@@ -169,6 +181,460 @@ var _ = Describe("Config", func() {
 			})
 		})
 
+		Context("when the config has CPU limits", func() {
+			BeforeEach(func() {
+				jobCfg.Processes[0].Limits = &config.Limits{}
+			})
+
+			It("accepts valid CPU limits", func() {
+				cpus := 1.5
+				period := uint64(50000)
+				weight := uint64(200)
+				cpuset := "0-3,6"
+				jobCfg.Processes[0].Limits.CPUs = &cpus
+				jobCfg.Processes[0].Limits.CPUPeriod = &period
+				jobCfg.Processes[0].Limits.CPUWeight = &weight
+				jobCfg.Processes[0].Limits.CPUSetCPUs = &cpuset
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+			})
+
+			It("returns an error if both cpus and cpu_quota are specified", func() {
+				cpus := 1.0
+				quota := int64(50000)
+				jobCfg.Processes[0].Limits.CPUs = &cpus
+				jobCfg.Processes[0].Limits.CPUQuota = &quota
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("only one of cpus or cpu_quota")))
+			})
+
+			It("returns an error if cpus is not positive", func() {
+				cpus := 0.0
+				jobCfg.Processes[0].Limits.CPUs = &cpus
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+			})
+
+			It("returns an error if the cpu quota is too small", func() {
+				quota := int64(10)
+				jobCfg.Processes[0].Limits.CPUQuota = &quota
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+			})
+
+			It("returns an error if cpu_period is out of range or used alone", func() {
+				period := uint64(10)
+				quota := int64(50000)
+				jobCfg.Processes[0].Limits.CPUPeriod = &period
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("requires either cpus or cpu_quota")))
+
+				jobCfg.Processes[0].Limits.CPUQuota = &quota
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("cpu_period must be between")))
+			})
+
+			It("returns an error if both cpu_shares and cpu_weight are specified", func() {
+				shares := uint64(1024)
+				weight := uint64(100)
+				jobCfg.Processes[0].Limits.CPUShares = &shares
+				jobCfg.Processes[0].Limits.CPUWeight = &weight
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("only one of cpu_shares or cpu_weight")))
+			})
+
+			It("returns an error if cpu_shares or cpu_weight are out of range", func() {
+				shares := uint64(1)
+				jobCfg.Processes[0].Limits.CPUShares = &shares
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+
+				weight := uint64(10001)
+				jobCfg.Processes[0].Limits.CPUShares = nil
+				jobCfg.Processes[0].Limits.CPUWeight = &weight
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+			})
+
+			It("returns an error if cpuset_cpus is malformed", func() {
+				for _, cpuset := range []string{"", "a", "0-", "3-1", "0,,1"} {
+					jobCfg.Processes[0].Limits.CPUSetCPUs = &cpuset
+					Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred(), cpuset)
+				}
+			})
+		})
+
+		Context("when the config has memory limits", func() {
+			var limits *config.Limits
+
+			BeforeEach(func() {
+				memory := "1G"
+				reservation := "512M"
+				high := "900M"
+				swap := "0"
+				limits = &config.Limits{
+					Memory:            &memory,
+					MemoryReservation: &reservation,
+					MemoryHigh:        &high,
+					Swap:              &swap,
+				}
+				jobCfg.Processes[0].Limits = limits
+			})
+
+			It("accepts valid memory limits", func() {
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+			})
+
+			It("returns an error if a size is invalid", func() {
+				invalid := "lots"
+				limits.MemoryHigh = &invalid
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("memory_high")))
+			})
+
+			It("returns an error if a memory limit other than swap is zero", func() {
+				for _, zero := range []string{"0", "0M"} {
+					zero := zero
+					limits.MemoryHigh = &zero
+					Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError("invalid limits: memory_high must be greater than zero"))
+				}
+
+				zero := "0"
+				limits.MemoryHigh = nil
+				limits.Memory = &zero
+				limits.MemoryReservation = nil
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError("invalid limits: memory must be greater than zero"))
+			})
+
+			It("returns an error if a soft limit is above the hard limit", func() {
+				reservation := "2G"
+				limits.MemoryReservation = &reservation
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("must not be greater than memory")))
+			})
+
+			It("returns an error if swap is specified without memory", func() {
+				limits.Memory = nil
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("swap requires memory")))
+			})
+		})
+
+		Context("when the config has an oom_score_adj", func() {
+			var adj int
+
+			BeforeEach(func() {
+				jobCfg.Processes[0].Limits = &config.Limits{OOMScoreAdj: &adj}
+			})
+
+			It("accepts a positive value", func() {
+				adj = 300
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+			})
+
+			It("returns an error if the value is out of range", func() {
+				adj = 1001
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+			})
+
+			It("only allows negative values for privileged processes", func() {
+				adj = -500
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("only allowed for privileged")))
+
+				jobCfg.Processes[0].Unsafe = &config.Unsafe{Privileged: true}
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+			})
+		})
+
+		Context("when the config has io limits", func() {
+			var io *config.IO
+
+			BeforeEach(func() {
+				weight := uint16(100)
+				readBPS := "20M"
+				io = &config.IO{
+					Weight:  &weight,
+					Devices: []config.IODevice{{Path: "/var/vcap/store", ReadBPS: &readBPS}},
+				}
+				jobCfg.Processes[0].Limits = &config.Limits{IO: io}
+			})
+
+			It("accepts valid io limits", func() {
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+			})
+
+			It("returns an error if the weight is out of range", func() {
+				weight := uint16(5)
+				io.Weight = &weight
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("weight must be between")))
+			})
+
+			It("returns an error if a device path is relative", func() {
+				io.Devices[0].Path = "store"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("must be absolute")))
+			})
+
+			It("returns an error if a device has no limits", func() {
+				io.Devices[0].ReadBPS = nil
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("at least one of")))
+			})
+
+			It("returns an error if a bandwidth is invalid", func() {
+				invalid := "fast"
+				io.Devices[0].WriteBPS = &invalid
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("invalid write_bps")))
+			})
+
+			It("returns an error if an iops limit is zero", func() {
+				zero := uint64(0)
+				io.Devices[0].ReadIOPS = &zero
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+			})
+		})
+
+		Context("when the config has a shutdown_timeout", func() {
+			It("accepts a valid duration", func() {
+				jobCfg.Processes[0].ShutdownTimeout = "90s"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+				Expect(jobCfg.Processes[0].ParseShutdownTimeout()).To(Equal(90 * time.Second))
+			})
+
+			It("returns an error if the duration cannot be parsed", func() {
+				jobCfg.Processes[0].ShutdownTimeout = "90"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("invalid shutdown timeout")))
+			})
+
+			It("returns an error if the duration is out of range", func() {
+				jobCfg.Processes[0].ShutdownTimeout = "0s"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+
+				jobCfg.Processes[0].ShutdownTimeout = "11m"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+			})
+
+			It("defaults to 15 seconds when not specified", func() {
+				Expect(jobCfg.Processes[0].ParseShutdownTimeout()).To(Equal(config.DefaultShutdownTimeout))
+				Expect(config.DefaultShutdownTimeout).To(Equal(15 * time.Second))
+			})
+		})
+
+		Context("when the config has hooks", func() {
+			BeforeEach(func() {
+				jobCfg.Processes[0].Hooks = &config.Hooks{
+					PreStart:  config.Hook{Path: "/var/vcap/jobs/example/bin/pre-start"},
+					PostStart: config.Hook{Path: "/var/vcap/jobs/example/bin/post-start", Timeout: "1m"},
+					PreStop:   config.Hook{Path: "/var/vcap/jobs/example/bin/pre-stop"},
+				}
+			})
+
+			It("accepts valid hooks", func() {
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+
+				hooks := jobCfg.Processes[0].Hooks
+				Expect(hooks.PostStart.ParseTimeout(config.DefaultHookTimeout)).To(Equal(time.Minute))
+				Expect(hooks.PreStop.ParseTimeout(config.DefaultHookTimeout)).To(Equal(config.DefaultHookTimeout))
+				Expect(hooks.PreStart.ParseTimeout(0)).To(BeZero())
+			})
+
+			It("returns an error if a timeout is invalid", func() {
+				jobCfg.Processes[0].Hooks.PreStop.Timeout = "soon"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("invalid pre_stop hook timeout")))
+
+				jobCfg.Processes[0].Hooks.PreStop.Timeout = "11m"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+			})
+
+			It("returns an error if a timeout is given without a path", func() {
+				jobCfg.Processes[0].Hooks.PostStop.Timeout = "10s"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("invalid post_stop hook")))
+			})
+		})
+
+		Context("when the config has a shutdown sequence", func() {
+			BeforeEach(func() {
+				jobCfg.Processes[0].Shutdown = []config.ShutdownStep{
+					{Signal: "USR1", Wait: "30s"},
+					{Signal: "SIGTERM", Wait: "10s"},
+					{Signal: "QUIT", Wait: "2s"},
+					{Signal: "KILL"},
+				}
+			})
+
+			It("accepts a valid sequence", func() {
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+
+				steps := jobCfg.Processes[0].Shutdown
+				Expect(steps[0].ParseSignal()).To(Equal(client.Usr1))
+				Expect(steps[0].ParseWait()).To(Equal(30 * time.Second))
+				Expect(steps[1].ParseSignal()).To(Equal(client.Term))
+				Expect(steps[3].ParseSignal()).To(Equal(client.Kill))
+				Expect(steps[3].ParseWait()).To(BeZero())
+			})
+
+			It("returns an error if a signal is unknown", func() {
+				jobCfg.Processes[0].Shutdown[1].Signal = "NOPE"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("invalid shutdown step 2")))
+			})
+
+			It("returns an error if a signal cannot end the process", func() {
+				for _, signal := range []string{"STOP", "SIGTSTP", "TTIN", "TTOU", "CONT", "CHLD", "URG"} {
+					jobCfg.Processes[0].Shutdown[0].Signal = signal
+					Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("invalid shutdown step 1")), signal)
+				}
+			})
+
+			It("returns an error if a wait is invalid", func() {
+				jobCfg.Processes[0].Shutdown[0].Wait = "-1s"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+
+				jobCfg.Processes[0].Shutdown[0].Wait = "soon"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+			})
+
+			It("returns an error if the total wait is too long", func() {
+				jobCfg.Processes[0].Shutdown[0].Wait = "10m"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("in total")))
+			})
+
+			It("returns an error if it is combined with shutdown_signal or shutdown_timeout", func() {
+				jobCfg.Processes[0].ShutdownSignal = "INT"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+
+				jobCfg.Processes[0].ShutdownSignal = ""
+				jobCfg.Processes[0].ShutdownTimeout = "20s"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+			})
+		})
+
+		Context("when the config has a reload signal", func() {
+			It("accepts a known signal", func() {
+				jobCfg.Processes[0].ReloadSignal = "SIGHUP"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+
+				signal, ok := jobCfg.Processes[0].ParseReloadSignal()
+				Expect(ok).To(BeTrue())
+				Expect(signal).To(Equal(client.Hup))
+			})
+
+			It("returns an error if the signal is unknown", func() {
+				jobCfg.Processes[0].ReloadSignal = "NOPE"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("invalid reload signal")))
+			})
+
+			It("returns an error if the signal cannot make the process reload", func() {
+				for _, signal := range []string{"STOP", "TSTP", "CHLD", "CONT", "KILL"} {
+					jobCfg.Processes[0].ReloadSignal = signal
+					Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("invalid reload signal")), signal)
+				}
+			})
+
+			It("does not have one when it is left unspecified", func() {
+				_, ok := jobCfg.Processes[0].ParseReloadSignal()
+				Expect(ok).To(BeFalse())
+			})
+		})
+
+		Context("when the config has a readiness probe", func() {
+			BeforeEach(func() {
+				jobCfg.Processes[0].Readiness = &config.Probe{
+					TCP:      &config.TCPProbe{Port: 8080},
+					Interval: "5s",
+				}
+			})
+
+			It("accepts a valid probe", func() {
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+
+				probe := jobCfg.Processes[0].Readiness
+				Expect(probe.ParseInterval()).To(Equal(5 * time.Second))
+				Expect(probe.ParseTimeout()).To(Equal(config.DefaultProbeTimeout))
+				Expect(probe.Threshold(config.DefaultReadinessFailureThreshold)).To(Equal(config.DefaultReadinessFailureThreshold))
+			})
+
+			It("returns an error if there is not exactly one check", func() {
+				jobCfg.Processes[0].Readiness.Socket = "server.sock"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("exactly one of")))
+
+				jobCfg.Processes[0].Readiness = &config.Probe{}
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("exactly one of")))
+			})
+
+			It("returns an error if the port is invalid", func() {
+				jobCfg.Processes[0].Readiness.TCP.Port = 0
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("port must be between")))
+
+				jobCfg.Processes[0].Readiness = &config.Probe{HTTP: &config.HTTPProbe{Port: 70000}}
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("port must be between")))
+			})
+
+			It("returns an error if the http path is not absolute", func() {
+				jobCfg.Processes[0].Readiness = &config.Probe{HTTP: &config.HTTPProbe{Port: 8080, Path: "healthz"}}
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("must start with '/'")))
+			})
+
+			It("returns an error if the socket escapes the socket directory", func() {
+				jobCfg.Processes[0].Readiness = &config.Probe{Socket: "../other/server.sock"}
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("within the socket directory")))
+			})
+
+			It("returns an error if the interval or timeout is invalid", func() {
+				jobCfg.Processes[0].Readiness.Interval = "0s"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+
+				jobCfg.Processes[0].Readiness.Interval = ""
+				jobCfg.Processes[0].Readiness.Timeout = "soon"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("invalid readiness probe timeout")))
+			})
+
+			It("returns an error if the failure threshold is negative", func() {
+				jobCfg.Processes[0].Readiness.FailureThreshold = -1
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+			})
+		})
+
+		Context("when the config has a liveness probe", func() {
+			BeforeEach(func() {
+				jobCfg.Processes[0].Liveness = &config.Probe{
+					Exec:             []string{"/var/vcap/jobs/example/bin/healthy"},
+					FailureThreshold: 3,
+				}
+			})
+
+			It("accepts a valid probe", func() {
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+				Expect(jobCfg.Processes[0].Liveness.Threshold(config.DefaultLivenessFailureThreshold)).To(Equal(3))
+			})
+
+			It("returns an error if the probe is invalid", func() {
+				jobCfg.Processes[0].Liveness.TCP = &config.TCPProbe{Port: 8080}
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("invalid liveness probe")))
+			})
+		})
+
+		Context("when the config has a restart policy", func() {
+			BeforeEach(func() {
+				jobCfg.Processes[0].RestartPolicy = &config.RestartPolicy{
+					Policy:     config.RestartOnFailure,
+					MaxRetries: 3,
+					Backoff:    "500ms",
+				}
+			})
+
+			It("accepts a valid policy", func() {
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+
+				policy := jobCfg.Processes[0].RestartPolicy
+				Expect(policy.ParseBackoff()).To(Equal(500 * time.Millisecond))
+				Expect(policy.ParseMaxBackoff()).To(Equal(config.DefaultRestartMaxBackoff))
+			})
+
+			It("returns an error if the policy is unknown", func() {
+				jobCfg.Processes[0].RestartPolicy.Policy = "sometimes"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("restart policy should be one of")))
+			})
+
+			It("returns an error if max_retries is negative", func() {
+				jobCfg.Processes[0].RestartPolicy.MaxRetries = -1
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(HaveOccurred())
+			})
+
+			It("returns an error if the backoff is invalid", func() {
+				jobCfg.Processes[0].RestartPolicy.Backoff = "soon"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("invalid restart policy backoff")))
+
+				jobCfg.Processes[0].RestartPolicy.Backoff = "2m"
+				Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("must not be greater than max_backoff")))
+			})
+		})
+
 		Context("when the process does not have a name", func() {
 			It("returns an error", func() {
 				jobCfg.Processes[0].Name = ""
@@ -187,6 +653,44 @@ var _ = Describe("Config", func() {
 		})
 	})
 
+	Describe("Validate dependencies", func() {
+		var jobCfg *config.JobConfig
+
+		BeforeEach(func() {
+			jobCfg = &config.JobConfig{
+				Processes: []*config.ProcessConfig{
+					{Name: "server", Executable: "server", DependsOn: []string{"proxy", "migrate"}},
+					{Name: "proxy", Executable: "proxy"},
+					{Name: "migrate", Executable: "migrate", DependsOn: []string{"proxy"}},
+				},
+			}
+		})
+
+		It("accepts dependencies which form no cycles", func() {
+			Expect(jobCfg.Validate(boshEnv, []string{})).To(Succeed())
+		})
+
+		It("returns an error if a dependency is unknown", func() {
+			jobCfg.Processes[1].DependsOn = []string{"database"}
+			Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError("invalid depends_on for process proxy: unknown process database"))
+		})
+
+		It("returns an error if the dependencies form a cycle", func() {
+			jobCfg.Processes[1].DependsOn = []string{"server"}
+			Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError("invalid depends_on: dependency cycle server -> proxy -> server"))
+		})
+
+		It("returns an error if a process depends on itself", func() {
+			jobCfg.Processes[1].DependsOn = []string{"proxy"}
+			Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError(ContainSubstring("proxy -> proxy")))
+		})
+
+		It("returns an error if two processes have the same name", func() {
+			jobCfg.Processes = append(jobCfg.Processes, &config.ProcessConfig{Name: "proxy", Executable: "other-proxy"})
+			Expect(jobCfg.Validate(boshEnv, []string{})).To(MatchError("invalid processes: duplicate process name proxy"))
+		})
+	})
+
 	Describe("AddVolumes", func() {
 		var cfg *config.ProcessConfig
 
@@ -0,0 +1,267 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/bytefmt"
+)
+
+const (
+	// DefaultCPUPeriod is the CFS scheduler period (in microseconds) used
+	// when a CPU quota is requested without an explicit period. It matches
+	// the kernel default.
+	DefaultCPUPeriod uint64 = 100000
+
+	minCPUPeriod uint64 = 1000
+	maxCPUPeriod uint64 = 1000000
+	minCPUQuota  int64  = 1000
+
+	minCPUShares uint64 = 2
+	maxCPUShares uint64 = 262144
+	minCPUWeight uint64 = 1
+	maxCPUWeight uint64 = 10000
+
+	minIOWeight uint16 = 10
+	maxIOWeight uint16 = 1000
+
+	minOOMScoreAdj = -1000
+	maxOOMScoreAdj = 1000
+)
+
+// CPUQuotaAndPeriod returns the CFS quota and period (both in microseconds)
+// which should be applied to the process. The final return value is false if
+// no CPU quota has been requested.
+func (l *Limits) CPUQuotaAndPeriod() (int64, uint64, bool) {
+	period := DefaultCPUPeriod
+	if l.CPUPeriod != nil {
+		period = *l.CPUPeriod
+	}
+
+	switch {
+	case l.CPUs != nil:
+		return int64(*l.CPUs * float64(period)), period, true
+	case l.CPUQuota != nil:
+		return *l.CPUQuota, period, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// SwapBytes returns the amount of swap (in bytes) the process may use in
+// addition to its memory limit.
+func (l *Limits) SwapBytes() (uint64, error) {
+	if l.Swap == nil {
+		return 0, errors.New("no swap limit specified")
+	}
+
+	return parseSize(*l.Swap)
+}
+
+// parseSize parses a human readable size such as 256M. A bare zero is also
+// accepted as it is the natural way to write "no swap".
+func parseSize(size string) (uint64, error) {
+	if size == "0" {
+		return 0, nil
+	}
+
+	return bytefmt.ToBytes(size)
+}
+
+func (l *Limits) validate(privileged bool) error {
+	if err := l.validateMemory(); err != nil {
+		return err
+	}
+
+	if l.OOMScoreAdj != nil {
+		if *l.OOMScoreAdj < minOOMScoreAdj || *l.OOMScoreAdj > maxOOMScoreAdj {
+			return fmt.Errorf("invalid limits: oom_score_adj must be between %d and %d, but got %d", minOOMScoreAdj, maxOOMScoreAdj, *l.OOMScoreAdj)
+		}
+
+		// Allowing any process to protect itself from the OOM killer would
+		// let it push the consequences of its memory use onto every other
+		// job on the VM.
+		if *l.OOMScoreAdj < 0 && !privileged {
+			return errors.New("invalid limits: a negative oom_score_adj is only allowed for privileged processes")
+		}
+	}
+
+	if l.CPUs != nil && l.CPUQuota != nil {
+		return errors.New("invalid limits: only one of cpus or cpu_quota may be specified")
+	}
+
+	if l.CPUPeriod != nil {
+		if l.CPUs == nil && l.CPUQuota == nil {
+			return errors.New("invalid limits: cpu_period requires either cpus or cpu_quota")
+		}
+
+		if *l.CPUPeriod < minCPUPeriod || *l.CPUPeriod > maxCPUPeriod {
+			return fmt.Errorf("invalid limits: cpu_period must be between %d and %d, but got %d", minCPUPeriod, maxCPUPeriod, *l.CPUPeriod)
+		}
+	}
+
+	if l.CPUs != nil && *l.CPUs <= 0 {
+		return fmt.Errorf("invalid limits: cpus must be greater than zero, but got %g", *l.CPUs)
+	}
+
+	if quota, _, ok := l.CPUQuotaAndPeriod(); ok && quota < minCPUQuota {
+		return fmt.Errorf("invalid limits: cpu quota must be at least %d microseconds, but got %d", minCPUQuota, quota)
+	}
+
+	if l.CPUShares != nil && l.CPUWeight != nil {
+		return errors.New("invalid limits: only one of cpu_shares or cpu_weight may be specified")
+	}
+
+	if l.CPUShares != nil && (*l.CPUShares < minCPUShares || *l.CPUShares > maxCPUShares) {
+		return fmt.Errorf("invalid limits: cpu_shares must be between %d and %d, but got %d", minCPUShares, maxCPUShares, *l.CPUShares)
+	}
+
+	if l.CPUWeight != nil && (*l.CPUWeight < minCPUWeight || *l.CPUWeight > maxCPUWeight) {
+		return fmt.Errorf("invalid limits: cpu_weight must be between %d and %d, but got %d", minCPUWeight, maxCPUWeight, *l.CPUWeight)
+	}
+
+	if l.CPUSetCPUs != nil {
+		if err := validateCPUList(*l.CPUSetCPUs); err != nil {
+			return fmt.Errorf("invalid limits: cpuset_cpus: %w", err)
+		}
+	}
+
+	if l.IO != nil {
+		if err := l.IO.validate(); err != nil {
+			return fmt.Errorf("invalid limits: io: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Limits) validateMemory() error {
+	sizes := map[string]uint64{}
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"memory", l.Memory},
+		{"memory_reservation", l.MemoryReservation},
+		{"memory_high", l.MemoryHigh},
+		{"swap", l.Swap},
+	}
+
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+
+		size, err := parseSize(*field.value)
+		if err != nil {
+			return fmt.Errorf("invalid limits: %s: %w", field.name, err)
+		}
+
+		// Only swap can sensibly be zero. A zero memory limit would leave
+		// the process unable to run at all.
+		if size == 0 && field.name != "swap" {
+			return fmt.Errorf("invalid limits: %s must be greater than zero", field.name)
+		}
+		sizes[field.name] = size
+	}
+
+	if l.Swap != nil && l.Memory == nil {
+		return errors.New("invalid limits: swap requires memory to also be specified")
+	}
+
+	if l.Memory == nil {
+		return nil
+	}
+
+	for _, name := range []string{"memory_reservation", "memory_high"} {
+		if size, ok := sizes[name]; ok && size > sizes["memory"] {
+			return fmt.Errorf("invalid limits: %s must not be greater than memory", name)
+		}
+	}
+
+	return nil
+}
+
+func (io *IO) validate() error {
+	if io.Weight != nil && (*io.Weight < minIOWeight || *io.Weight > maxIOWeight) {
+		return fmt.Errorf("weight must be between %d and %d, but got %d", minIOWeight, maxIOWeight, *io.Weight)
+	}
+
+	for _, dev := range io.Devices {
+		if !filepath.IsAbs(dev.Path) {
+			return fmt.Errorf("device path must be absolute, but got %q", dev.Path)
+		}
+
+		if dev.ReadBPS == nil && dev.WriteBPS == nil && dev.ReadIOPS == nil && dev.WriteIOPS == nil {
+			return fmt.Errorf("device %s must have at least one of read_bps, write_bps, read_iops or write_iops", dev.Path)
+		}
+
+		if dev.ReadBPS != nil {
+			if _, err := bytefmt.ToBytes(*dev.ReadBPS); err != nil {
+				return fmt.Errorf("device %s has an invalid read_bps: %w", dev.Path, err)
+			}
+		}
+
+		if dev.WriteBPS != nil {
+			if _, err := bytefmt.ToBytes(*dev.WriteBPS); err != nil {
+				return fmt.Errorf("device %s has an invalid write_bps: %w", dev.Path, err)
+			}
+		}
+
+		if (dev.ReadIOPS != nil && *dev.ReadIOPS == 0) || (dev.WriteIOPS != nil && *dev.WriteIOPS == 0) {
+			return fmt.Errorf("device %s must have iops limits greater than zero", dev.Path)
+		}
+	}
+
+	return nil
+}
+
+// validateCPUList checks that a string is in the kernel's cpuset list format
+// e.g. "0-3,6,8-9".
+func validateCPUList(list string) error {
+	if list == "" {
+		return errors.New("must not be empty")
+	}
+
+	for _, part := range strings.Split(list, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+
+		lo, err := strconv.ParseUint(bounds[0], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid cpu %q", bounds[0])
+		}
+
+		if len(bounds) == 1 {
+			continue
+		}
+
+		hi, err := strconv.ParseUint(bounds[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid cpu %q", bounds[1])
+		}
+
+		if hi < lo {
+			return fmt.Errorf("invalid cpu range %q", part)
+		}
+	}
+
+	return nil
+}
@@ -18,21 +18,91 @@
 // deep callstack as a single error wrapping will cause this to break.
 package exitstatus
 
-import "fmt"
+import (
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// These exit statuses are reserved by `bpm run` for failures of bpm rather
+// than of the process so that the two can be told apart. A process which
+// exits with one of these statuses itself cannot be told apart from them.
+const (
+	// OOMKilled means that the process was killed by the OOM killer.
+	OOMKilled = 122
+
+	// HookFailed means that a hook of the process failed or timed out.
+	HookFailed = 123
+
+	// ContainerFailed means that the container for the process could not be
+	// set up, e.g. because its bundle could not be built.
+	ContainerFailed = 124
+
+	// InvalidConfig means that the configuration of the job, or the volumes
+	// or environment variables given to `bpm run`, were invalid.
+	InvalidConfig = 125
+)
+
+// signalOffset is added to the number of the signal which killed a process to
+// give its exit status, as is done by shells.
+const signalOffset = 128
 
 // Error represents an error and an associated exit status to propagate.
 type Error struct {
 	Status int
-	Err    error
+
+	// Signal is the name of the signal which killed the process (e.g.
+	// SIGKILL) if it was killed by one.
+	Signal string
+
+	// Quiet is set when the exit status is passed through from a command
+	// which has already reported its own failure, so there is nothing more
+	// to print.
+	Quiet bool
+
+	Err error
 }
 
 func (e *Error) Error() string {
+	if e.Signal != "" {
+		return fmt.Sprintf("%s (exit status %d, killed by %s)", e.Err, e.Status, e.Signal)
+	}
+
 	return fmt.Sprintf("%s (exit status %d)", e.Err, e.Status)
 }
 
+// FromWaitStatus returns the exit status of a process following shell
+// conventions: a process killed by a signal has an exit status of 128 plus the
+// number of the signal.
+func FromWaitStatus(status syscall.WaitStatus) int {
+	if status.Signaled() {
+		return signalOffset + int(status.Signal())
+	}
+
+	return status.ExitStatus()
+}
+
+// SignalName returns the name of the signal which killed a process with the
+// exit status following shell conventions. It returns the empty string if the
+// exit status does not correspond to a signal.
+func SignalName(status int) string {
+	if status <= signalOffset {
+		return ""
+	}
+
+	return unix.SignalName(syscall.Signal(status - signalOffset))
+}
+
 // FromError collects the exit status from the passed error if it exists. If it
 // finds an error without status code information then it returns 1 for
 // backwards compatibility.
+// ShouldPrint returns whether the error should be printed before bpm exits.
+func ShouldPrint(err error) bool {
+	serr, ok := err.(*Error)
+	return !ok || !serr.Quiet
+}
+
 func FromError(err error) int {
 	if err == nil {
 		return 0
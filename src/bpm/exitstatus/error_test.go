@@ -17,6 +17,7 @@ package exitstatus_test
 
 import (
 	"errors"
+	"syscall"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -41,6 +42,49 @@ var _ = Describe("exit status", func() {
 
 			Expect(err.Error()).To(Equal("disaster (exit status 34)"))
 		})
+
+		It("includes the signal which killed the process", func() {
+			err := &exitstatus.Error{
+				Status: 137,
+				Signal: "SIGKILL",
+				Err:    errors.New("disaster"),
+			}
+
+			Expect(err.Error()).To(Equal("disaster (exit status 137, killed by SIGKILL)"))
+		})
+	})
+
+	Describe("mapping a wait status", func() {
+		It("returns the exit status of a process which exited", func() {
+			// The exit status is stored in the second byte of the wait status.
+			Expect(exitstatus.FromWaitStatus(syscall.WaitStatus(3 << 8))).To(Equal(3))
+		})
+
+		It("returns 128 plus the signal for a process which was killed", func() {
+			Expect(exitstatus.FromWaitStatus(syscall.WaitStatus(syscall.SIGKILL))).To(Equal(137))
+			Expect(exitstatus.FromWaitStatus(syscall.WaitStatus(syscall.SIGTERM))).To(Equal(143))
+		})
+	})
+
+	Describe("getting the signal name", func() {
+		It("returns the signal for an exit status above 128", func() {
+			Expect(exitstatus.SignalName(137)).To(Equal("SIGKILL"))
+			Expect(exitstatus.SignalName(130)).To(Equal("SIGINT"))
+		})
+
+		It("returns nothing for other exit statuses", func() {
+			Expect(exitstatus.SignalName(1)).To(BeEmpty())
+			Expect(exitstatus.SignalName(128)).To(BeEmpty())
+			Expect(exitstatus.SignalName(255)).To(BeEmpty())
+		})
+	})
+
+	Describe("deciding whether to print", func() {
+		It("prints errors unless they are quiet", func() {
+			Expect(exitstatus.ShouldPrint(errors.New("other"))).To(BeTrue())
+			Expect(exitstatus.ShouldPrint(&exitstatus.Error{Status: 3, Err: errors.New("oops")})).To(BeTrue())
+			Expect(exitstatus.ShouldPrint(&exitstatus.Error{Status: 3, Quiet: true, Err: errors.New("oops")})).To(BeFalse())
+		})
 	})
 
 	Describe("getting the exit status", func() {
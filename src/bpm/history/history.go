@@ -0,0 +1,135 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+// Package history records when each process was started and how it stopped
+// so that this is not lost once its container has been removed.
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"bpm/models"
+)
+
+// MaxEntries is how many runs of a process are kept. Older runs are dropped.
+const MaxEntries = 20
+
+// Read returns the runs recorded at the path, oldest first. A process which
+// has never been started has no history.
+func Read(path string) ([]models.HistoryEntry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []models.HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// RecordStart records that the process was started at the time.
+func RecordStart(path string, t time.Time) error {
+	entries, err := Read(path)
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, models.HistoryEntry{StartTime: t.UTC()})
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+
+	return write(path, entries)
+}
+
+// RecordStop records that the process was stopped by bpm with the signal.
+func RecordStop(path string, t time.Time, signal string) error {
+	return recordEnd(path, t, func(entry *models.HistoryEntry) {
+		entry.Signal = signal
+	})
+}
+
+// RecordExit records that the process exited by itself and whether it was
+// killed by the OOM killer. The exit status is nil if it is not known, e.g.
+// because the process was still running when its container was removed.
+func RecordExit(path string, t time.Time, exitStatus *int, oomKilled bool) error {
+	return recordEnd(path, t, func(entry *models.HistoryEntry) {
+		entry.ExitStatus = exitStatus
+		entry.OOMKilled = oomKilled
+	})
+}
+
+// StoppedByBPM returns whether the latest run was ended by bpm stopping the
+// process rather than by the process exiting by itself.
+func StoppedByBPM(path string) (bool, error) {
+	entries, err := Read(path)
+	if err != nil || len(entries) == 0 {
+		return false, err
+	}
+
+	last := entries[len(entries)-1]
+	return last.StopTime != nil && last.Signal != "", nil
+}
+
+// recordEnd completes the latest run if it is still in progress. Nothing is
+// recorded if it has already been completed, e.g. because the process was
+// stopped before its container was removed.
+func recordEnd(path string, t time.Time, f func(*models.HistoryEntry)) error {
+	entries, err := Read(path)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	last := &entries[len(entries)-1]
+	if last.StopTime != nil {
+		return nil
+	}
+
+	stopped := t.UTC()
+	last.StopTime = &stopped
+	f(last)
+
+	return write(path, entries)
+}
+
+func write(path string, entries []models.HistoryEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
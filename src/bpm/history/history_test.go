@@ -0,0 +1,135 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package history_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bpm/history"
+)
+
+var _ = Describe("History", func() {
+	var (
+		path  string
+		start time.Time
+	)
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "run", "server.history.json")
+		start = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	})
+
+	It("is empty for a process which has never been started", func() {
+		entries, err := history.Read(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("records a run which is still in progress", func() {
+		Expect(history.RecordStart(path, start)).To(Succeed())
+
+		entries, err := history.Read(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].StartTime).To(Equal(start))
+		Expect(entries[0].StopTime).To(BeNil())
+	})
+
+	It("records the signal a process was stopped with", func() {
+		Expect(history.RecordStart(path, start)).To(Succeed())
+		Expect(history.RecordStop(path, start.Add(time.Minute), "TERM")).To(Succeed())
+
+		entries, err := history.Read(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(*entries[0].StopTime).To(Equal(start.Add(time.Minute)))
+		Expect(entries[0].Signal).To(Equal("TERM"))
+		Expect(entries[0].ExitStatus).To(BeNil())
+	})
+
+	It("records the exit status of a process which exited", func() {
+		status := 3
+		Expect(history.RecordStart(path, start)).To(Succeed())
+		Expect(history.RecordExit(path, start.Add(time.Second), &status, false)).To(Succeed())
+
+		entries, err := history.Read(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*entries[0].ExitStatus).To(Equal(3))
+		Expect(entries[0].Signal).To(BeEmpty())
+		Expect(entries[0].OOMKilled).To(BeFalse())
+	})
+
+	It("records that a process was killed by the OOM killer", func() {
+		Expect(history.RecordStart(path, start)).To(Succeed())
+		Expect(history.RecordExit(path, start.Add(time.Second), nil, true)).To(Succeed())
+
+		entries, err := history.Read(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries[0].ExitStatus).To(BeNil())
+		Expect(entries[0].OOMKilled).To(BeTrue())
+	})
+
+	It("does not overwrite how a run ended", func() {
+		Expect(history.RecordStart(path, start)).To(Succeed())
+		Expect(history.RecordStop(path, start.Add(time.Minute), "TERM")).To(Succeed())
+		Expect(history.RecordExit(path, start.Add(time.Hour), nil, true)).To(Succeed())
+
+		entries, err := history.Read(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(*entries[0].StopTime).To(Equal(start.Add(time.Minute)))
+		Expect(entries[0].Signal).To(Equal("TERM"))
+	})
+
+	It("knows whether the latest run was stopped by bpm", func() {
+		Expect(history.StoppedByBPM(path)).To(BeFalse())
+
+		Expect(history.RecordStart(path, start)).To(Succeed())
+		Expect(history.StoppedByBPM(path)).To(BeFalse())
+
+		Expect(history.RecordStop(path, start.Add(time.Minute), "TERM")).To(Succeed())
+		Expect(history.StoppedByBPM(path)).To(BeTrue())
+
+		Expect(history.RecordStart(path, start.Add(time.Hour))).To(Succeed())
+		Expect(history.RecordExit(path, start.Add(2*time.Hour), nil, false)).To(Succeed())
+		Expect(history.StoppedByBPM(path)).To(BeFalse())
+	})
+
+	It("does nothing when a process which was never recorded stops", func() {
+		Expect(history.RecordExit(path, start, nil, false)).To(Succeed())
+
+		_, err := os.Stat(path)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("only keeps the most recent runs", func() {
+		for i := 0; i < history.MaxEntries+5; i++ {
+			t := start.Add(time.Duration(i) * time.Minute)
+			Expect(history.RecordStart(path, t)).To(Succeed())
+			Expect(history.RecordExit(path, t.Add(time.Second), nil, false)).To(Succeed())
+		}
+
+		entries, err := history.Read(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(history.MaxEntries))
+		Expect(entries[0].StartTime).To(Equal(start.Add(5 * time.Minute)))
+	})
+})
@@ -0,0 +1,211 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	uuid "github.com/satori/go.uuid"
+
+	"bpm/config"
+	"bpm/jobid"
+)
+
+var _ = Describe("--all", func() {
+	var (
+		cfg config.JobConfig
+
+		boshRoot     string
+		containerIDs []string
+		job          string
+		runcRoot     string
+	)
+
+	bpm := func(args ...string) *gexec.Session {
+		command := exec.Command(bpmPath, args...)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session).Should(gexec.Exit())
+		return session
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "all-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		runcRoot = setupBoshDirectories(boshRoot, job)
+
+		logFile := filepath.Join(boshRoot, "sys", "log", job, "foo.log")
+		cfg = newJobConfig(job, defaultBash(logFile))
+		for _, name := range []string{"worker", "scheduler"} {
+			cfg.Processes = append(cfg.Processes, &config.ProcessConfig{
+				Name:       name,
+				Executable: "/bin/bash",
+				Args:       []string{"-c", alternativeBash},
+			})
+		}
+
+		containerIDs = []string{
+			jobid.Encode(job),
+			jobid.Encode(fmt.Sprintf("%s.worker", job)),
+			jobid.Encode(fmt.Sprintf("%s.scheduler", job)),
+		}
+	})
+
+	JustBeforeEach(func() {
+		writeConfig(boshRoot, job, cfg)
+	})
+
+	AfterEach(func() {
+		for _, containerID := range containerIDs {
+			err := runcCommand(runcRoot, "delete", "--force", containerID).Run()
+			if err != nil {
+				GinkgoWriter.Printf("WARNING: Failed to cleanup container: %s\n", err.Error())
+			}
+		}
+
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	It("starts, restarts and stops every process of the job", func() {
+		Expect(bpm("start", job, "--all")).To(gexec.Exit(0))
+		for _, containerID := range containerIDs {
+			Expect(runcState(runcRoot, containerID).Status).To(Equal(specs.StateRunning))
+		}
+
+		session := bpm("pid", job, "--all")
+		Expect(session).To(gexec.Exit(0))
+		Expect(session.Out).To(gbytes.Say(fmt.Sprintf("%s: %d", job, runcState(runcRoot, containerIDs[0]).Pid)))
+		Expect(session.Out).To(gbytes.Say(fmt.Sprintf("worker: %d", runcState(runcRoot, containerIDs[1]).Pid)))
+		Expect(session.Out).To(gbytes.Say(fmt.Sprintf("scheduler: %d", runcState(runcRoot, containerIDs[2]).Pid)))
+
+		oldPid := runcState(runcRoot, containerIDs[1]).Pid
+		Expect(bpm("restart", job, "--all")).To(gexec.Exit(0))
+		Expect(runcState(runcRoot, containerIDs[1]).Pid).NotTo(Equal(oldPid))
+
+		Expect(bpm("stop", job, "--all")).To(gexec.Exit(0))
+		for _, containerID := range containerIDs {
+			Expect(runcCommand(runcRoot, "state", containerID).Run()).NotTo(Succeed())
+		}
+	})
+
+	It("reports every process which failed", func() {
+		cfg.Processes[1].Hooks = &config.Hooks{PreStart: config.Hook{Path: "/does/not/exist"}}
+		cfg.Processes[2].Hooks = &config.Hooks{PreStart: config.Hook{Path: "/does/not/exist"}}
+		writeConfig(boshRoot, job, cfg)
+
+		session := bpm("start", job, "--all")
+		Expect(session).To(gexec.Exit(1))
+		Expect(session.Err).To(gbytes.Say("2 process\\(es\\) failed"))
+		Expect(session.Err).To(gbytes.Say("worker: failed to start job-process"))
+		Expect(session.Err).To(gbytes.Say("scheduler: failed to start job-process"))
+
+		Expect(runcState(runcRoot, containerIDs[0]).Status).To(Equal(specs.StateRunning))
+	})
+
+	It("reports the processes which are not running", func() {
+		startJob(boshRoot, bpmPath, job)
+
+		session := bpm("pid", job, "--all")
+		Expect(session).To(gexec.Exit(1))
+		Expect(session.Out).To(gbytes.Say(fmt.Sprintf("%s: %d", job, runcState(runcRoot, containerIDs[0]).Pid)))
+		Expect(session.Err).To(gbytes.Say("worker: process is not running or could not be found"))
+	})
+
+	Context("when processes depend on each other", func() {
+		var orderFile string
+
+		recordHook := func(event, name string) string {
+			path := filepath.Join(boshRoot, fmt.Sprintf("%s-%s", event, name))
+			script := fmt.Sprintf("#!/bin/bash\necho %s-%s >> %s\n", event, name, orderFile)
+			Expect(os.WriteFile(path, []byte(script), 0777)).To(Succeed())
+			return path
+		}
+
+		BeforeEach(func() {
+			orderFile = filepath.Join(boshRoot, "order")
+
+			// job -> scheduler -> worker
+			cfg.Processes[0].DependsOn = []string{"scheduler"}
+			cfg.Processes[2].DependsOn = []string{"worker"}
+
+			for _, process := range cfg.Processes {
+				process.Hooks = &config.Hooks{
+					PreStart: config.Hook{Path: recordHook("start", process.Name)},
+					PostStop: config.Hook{Path: recordHook("stop", process.Name)},
+				}
+			}
+		})
+
+		It("starts dependencies first and stops them last", func() {
+			Expect(bpm("start", job, "--all")).To(gexec.Exit(0))
+			Expect(bpm("stop", job, "--all")).To(gexec.Exit(0))
+
+			Expect(fileLines(orderFile)()).To(Equal([]string{
+				"start-worker",
+				"start-scheduler",
+				fmt.Sprintf("start-%s", job),
+				fmt.Sprintf("stop-%s", job),
+				"stop-scheduler",
+				"stop-worker",
+			}))
+		})
+
+		It("restarts by stopping dependents first and then starting dependencies first", func() {
+			Expect(bpm("start", job, "--all")).To(gexec.Exit(0))
+			Expect(os.Remove(orderFile)).To(Succeed())
+
+			Expect(bpm("restart", job, "--all")).To(gexec.Exit(0))
+
+			Expect(fileLines(orderFile)()).To(Equal([]string{
+				fmt.Sprintf("stop-%s", job),
+				"stop-scheduler",
+				"stop-worker",
+				"start-worker",
+				"start-scheduler",
+				fmt.Sprintf("start-%s", job),
+			}))
+		})
+
+		It("does not start a process whose dependency failed to start", func() {
+			cfg.Processes[1].Hooks.PreStart.Path = "/does/not/exist"
+			writeConfig(boshRoot, job, cfg)
+
+			session := bpm("start", job, "--all")
+			Expect(session).To(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("scheduler: dependency worker failed"))
+			Expect(runcCommand(runcRoot, "state", containerIDs[0]).Run()).NotTo(Succeed())
+		})
+	})
+
+	It("cannot be combined with --process", func() {
+		session := bpm("start", job, "--all", "-p", "worker")
+		Expect(session).To(gexec.Exit(1))
+		Expect(session.Err).To(gbytes.Say("--process cannot be used with --all"))
+	})
+})
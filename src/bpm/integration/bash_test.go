@@ -39,9 +39,40 @@ sleep 100 &
 child=$!;
 wait $child`
 
+const slowShutdownBash = `trap 'echo "Received a TERM signal"' SIGTERM;
+trap 'echo "Received a QUIT signal" && kill -9 $child' SIGQUIT;
+sleep 1000 &
+child=$!;
+while kill -0 $child 2>/dev/null; do wait $child; done`
+
+const drainBash = `trap 'echo "Received a USR1 signal"' SIGUSR1;
+trap 'echo "Received a TERM signal" && kill -9 $child' SIGTERM;
+sleep 1000 &
+child=$!;
+while kill -0 $child 2>/dev/null; do wait $child; done`
+
+const reloadBash = `trap 'echo "Received a HUP signal"' SIGHUP;
+trap 'echo "Received a TERM signal" && kill -9 $child' SIGTERM;
+sleep 1000 &
+child=$!;
+while kill -0 $child 2>/dev/null; do wait $child; done`
+
 const preStartBash = `#!/bin/bash
 echo "Executing Pre Start"`
 
+const preStopBash = `#!/bin/bash
+echo "Executing Pre Stop with TMPDIR=$TMPDIR"`
+
+const postStopBash = `#!/bin/bash
+echo "Executing Post Stop"`
+
+const failingHookBash = `#!/bin/bash
+echo "Hook failing" 1>&2
+exit 1`
+
+const containerHookBash = `#!/bin/bash
+echo "Hook running as $(whoami)"`
+
 const effectiveCapabilitiesBash = `cat /proc/1/status | grep CapEff`
 
 const netBindServiceCapabilityBash = `echo PRIVILEGED | nc -l 127.0.0.1 80`
@@ -0,0 +1,134 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	uuid "github.com/satori/go.uuid"
+
+	"bpm/jobid"
+)
+
+var _ = Describe("exec", func() {
+	var (
+		boshRoot    string
+		containerID string
+		job         string
+		runcRoot    string
+		stdout      string
+	)
+
+	bpmCommand := func(args ...string) *exec.Cmd {
+		command := exec.Command(bpmPath, args...)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+		return command
+	}
+
+	runBpm := func(status int, args ...string) *gexec.Session {
+		session, err := gexec.Start(bpmCommand(args...), GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "30s").Should(gexec.Exit(status))
+		return session
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		containerID = jobid.Encode(job)
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "exec-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		runcRoot = setupBoshDirectories(boshRoot, job)
+
+		stdout = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stdout.log", job))
+
+		logFile := filepath.Join(boshRoot, "sys", "log", job, "foo.log")
+		writeConfig(boshRoot, job, newJobConfig(job, defaultBash(logFile)))
+	})
+
+	AfterEach(func() {
+		err := runcCommand(runcRoot, "delete", "--force", containerID).Run()
+		if err != nil {
+			GinkgoWriter.Printf("WARNING: Failed to cleanup container: %s\n", err.Error())
+		}
+		copyContentsToGinkgoWrite(stdout)
+
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	Context("when the process is running", func() {
+		BeforeEach(func() {
+			startJob(boshRoot, bpmPath, job)
+		})
+
+		It("runs the command inside the container", func() {
+			session := runBpm(0, "exec", job, "--", "/bin/sh", "-c", "echo $HOME; id -un")
+			Expect(session.Out).To(gbytes.Say(filepath.Join("/var/vcap/data", job)))
+			Expect(session.Out).To(gbytes.Say("vcap"))
+		})
+
+		It("exits with the exit status of the command", func() {
+			session := runBpm(3, "exec", job, "--", "/bin/sh", "-c", "echo failing >&2; exit 3")
+			Expect(session.Err).To(gbytes.Say("failing"))
+			Expect(session.Err).NotTo(gbytes.Say("Error:"))
+		})
+
+		It("passes standard input through to the command", func() {
+			command := bpmCommand("exec", job, "--", "/bin/cat")
+			command.Stdin = strings.NewReader("from stdin\n")
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session, "30s").Should(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say("from stdin"))
+		})
+
+		It("runs the command with the given environment, user, and working directory", func() {
+			session := runBpm(0,
+				"exec", job,
+				"--env", "GREETING=hello",
+				"--user", "root",
+				"--cwd", "/var/vcap/jobs",
+				"--", "/bin/sh", "-c", "echo $GREETING; id -u; pwd",
+			)
+			Expect(session.Out).To(gbytes.Say("hello\n0\n/var/vcap/jobs\n"))
+		})
+	})
+
+	Context("when the process is not running", func() {
+		It("returns an error", func() {
+			session := runBpm(1, "exec", job, "--", "/bin/true")
+			Expect(session.Err).To(gbytes.Say("process is not running or could not be found"))
+		})
+	})
+
+	Context("when no command is specified", func() {
+		It("returns an error", func() {
+			session := runBpm(1, "exec", job)
+			Expect(session.Err).To(gbytes.Say("must specify a command to run after --"))
+		})
+	})
+})
@@ -0,0 +1,124 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	uuid "github.com/satori/go.uuid"
+
+	"bpm/config"
+	"bpm/jobid"
+)
+
+var _ = Describe("health", func() {
+	var (
+		command *exec.Cmd
+
+		cfg config.JobConfig
+
+		boshRoot    string
+		containerID string
+		job         string
+		runcRoot    string
+		stderr      string
+		stdout      string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		containerID = jobid.Encode(job)
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "health-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		runcRoot = setupBoshDirectories(boshRoot, job)
+
+		stdout = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stdout.log", job))
+		stderr = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stderr.log", job))
+
+		logFile := filepath.Join(boshRoot, "sys", "log", job, "foo.log")
+		cfg = newJobConfig(job, defaultBash(logFile))
+	})
+
+	JustBeforeEach(func() {
+		writeConfig(boshRoot, job, cfg)
+		command = exec.Command(bpmPath, "health", job)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+	})
+
+	AfterEach(func() {
+		err := runcCommand(runcRoot, "delete", "--force", containerID).Run()
+		if err != nil {
+			GinkgoWriter.Printf("WARNING: Failed to cleanup container: %s\n", err.Error())
+		}
+		copyContentsToGinkgoWrite(stdout)
+		copyContentsToGinkgoWrite(stderr)
+
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	Context("when the liveness probe passes", func() {
+		BeforeEach(func() {
+			cfg.Processes[0].Liveness = &config.Probe{Exec: []string{"/bin/true"}}
+		})
+
+		It("reports the process as healthy", func() {
+			startJob(boshRoot, bpmPath, job)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say("healthy"))
+		})
+	})
+
+	Context("when the liveness probe fails", func() {
+		BeforeEach(func() {
+			cfg.Processes[0].Liveness = &config.Probe{Exec: []string{"/bin/sh", "-c", "echo backend down; exit 1"}}
+		})
+
+		It("reports the process as unhealthy with the reason", func() {
+			startJob(boshRoot, bpmPath, job)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("Error: unhealthy: liveness probe failed: .*backend down"))
+
+			bpmLog := filepath.Join(boshRoot, "sys", "log", job, "bpm.log")
+			Expect(fileContents(bpmLog)()).To(ContainSubstring("bpm.health.check-health.unhealthy"))
+			Expect(fileContents(bpmLog)()).To(ContainSubstring("backend down"))
+		})
+	})
+
+	Context("when the process is not running", func() {
+		It("reports the process as unhealthy", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("Error: unhealthy: process is not running"))
+		})
+	})
+})
@@ -0,0 +1,134 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	uuid "github.com/satori/go.uuid"
+
+	"bpm/config"
+	"bpm/jobid"
+	"bpm/models"
+)
+
+var _ = Describe("history", func() {
+	var (
+		cfg config.JobConfig
+
+		boshRoot    string
+		containerID string
+		job         string
+		runcRoot    string
+		stdout      string
+	)
+
+	bpmCommand := func(args ...string) *exec.Cmd {
+		command := exec.Command(bpmPath, args...)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+		return command
+	}
+
+	runBpm := func(status int, args ...string) *gexec.Session {
+		session, err := gexec.Start(bpmCommand(args...), GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "30s").Should(gexec.Exit(status))
+		return session
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		containerID = jobid.Encode(job)
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "history-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		runcRoot = setupBoshDirectories(boshRoot, job)
+
+		stdout = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stdout.log", job))
+
+		logFile := filepath.Join(boshRoot, "sys", "log", job, "foo.log")
+		cfg = newJobConfig(job, defaultBash(logFile))
+	})
+
+	JustBeforeEach(func() {
+		writeConfig(boshRoot, job, cfg)
+	})
+
+	AfterEach(func() {
+		err := runcCommand(runcRoot, "delete", "--force", containerID).Run()
+		if err != nil {
+			GinkgoWriter.Printf("WARNING: Failed to cleanup container: %s\n", err.Error())
+		}
+		copyContentsToGinkgoWrite(stdout)
+
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	It("is empty for a process which has never been started", func() {
+		session := runBpm(0, "history", job, "--json")
+		Expect(session.Out).To(gbytes.Say(`\[\]`))
+	})
+
+	It("records the signal a stopped process was stopped with", func() {
+		runBpm(0, "start", job)
+		runBpm(0, "stop", job)
+
+		session := runBpm(0, "history", job)
+		Expect(session.Out).To(gbytes.Say(`Started\s+Stopped\s+Exit`))
+		Expect(session.Out).To(gbytes.Say(`stopped \(TERM\)`))
+
+		list := runBpm(0, "list", "--wide")
+		Expect(list.Out).To(gbytes.Say(fmt.Sprintf(`%s\s+-\s+stopped\s+-\s+stopped \(TERM\)`, job)))
+	})
+
+	Context("when the process exits by itself", func() {
+		BeforeEach(func() {
+			cfg = newJobConfig(job, "exit 3")
+		})
+
+		It("records the exit status of a process run in the foreground", func() {
+			runBpm(3, "run", job)
+
+			session := runBpm(0, "history", job, "--json")
+
+			var entries []models.HistoryEntry
+			Expect(json.Unmarshal(session.Out.Contents(), &entries)).To(Succeed())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].StopTime).NotTo(BeNil())
+			Expect(*entries[0].ExitStatus).To(Equal(3))
+		})
+
+		It("records the exit status of a process started in the background", func() {
+			runBpm(0, "start", job)
+			Eventually(func() specs.ContainerState { return runcState(runcRoot, containerID).Status }, "10s").Should(Equal(specs.StateStopped))
+			runBpm(0, "stop", job)
+
+			session := runBpm(0, "history", job)
+			Expect(session.Out).To(gbytes.Say(`exited \(3\)`))
+		})
+	})
+})
@@ -0,0 +1,127 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	uuid "github.com/satori/go.uuid"
+
+	"bpm/config"
+	"bpm/jobid"
+	"bpm/models"
+)
+
+var _ = Describe("inspect", func() {
+	var (
+		cfg config.JobConfig
+
+		boshRoot    string
+		containerID string
+		job         string
+		runcRoot    string
+		stdout      string
+	)
+
+	runBpm := func(status int, args ...string) *gexec.Session {
+		command := exec.Command(bpmPath, args...)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "30s").Should(gexec.Exit(status))
+		return session
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		containerID = jobid.Encode(job)
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "inspect-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		runcRoot = setupBoshDirectories(boshRoot, job)
+
+		stdout = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stdout.log", job))
+
+		memory := "64M"
+		logFile := filepath.Join(boshRoot, "sys", "log", job, "foo.log")
+		cfg = newJobConfig(job, defaultBash(logFile))
+		cfg.Processes[0].Limits = &config.Limits{Memory: &memory}
+		writeConfig(boshRoot, job, cfg)
+	})
+
+	AfterEach(func() {
+		err := runcCommand(runcRoot, "delete", "--force", containerID).Run()
+		if err != nil {
+			GinkgoWriter.Printf("WARNING: Failed to cleanup container: %s\n", err.Error())
+		}
+		copyContentsToGinkgoWrite(stdout)
+
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	Context("when the process is running", func() {
+		BeforeEach(func() {
+			startJob(boshRoot, bpmPath, job)
+		})
+
+		It("summarises the container", func() {
+			session := runBpm(0, "inspect", job)
+			Expect(session.Out).To(gbytes.Say(`Name:\s+%s`, job))
+			Expect(session.Out).To(gbytes.Say(`Status:\s+running`))
+			Expect(session.Out).To(gbytes.Say(`Executable:\s+\S+/tini`))
+			Expect(session.Out).To(gbytes.Say(`Memory\s+64M`))
+			Expect(session.Out).To(gbytes.Say(`Mounts:`))
+		})
+
+		It("prints the summary as JSON", func() {
+			session := runBpm(0, "inspect", job, "--json")
+
+			var inspection models.ProcessInspection
+			Expect(json.Unmarshal(session.Out.Contents(), &inspection)).To(Succeed())
+			Expect(inspection.Name).To(Equal(job))
+			Expect(inspection.Pid).To(Equal(runcState(runcRoot, containerID).Pid))
+			Expect(inspection.Args).To(ContainElement("/bin/bash"))
+			Expect(*inspection.Limits.MemoryBytes).To(BeEquivalentTo(64 * 1024 * 1024))
+		})
+
+		It("prints the spec from the bundle", func() {
+			session := runBpm(0, "inspect", job, "--raw-spec")
+
+			var spec specs.Spec
+			Expect(json.Unmarshal(session.Out.Contents(), &spec)).To(Succeed())
+			Expect(spec.Process.Args).To(ContainElement("/bin/bash"))
+		})
+	})
+
+	Context("when the process does not exist", func() {
+		It("fails", func() {
+			session := runBpm(1, "inspect", job)
+			Expect(session.Err).To(gbytes.Say("process could not be found"))
+		})
+	})
+})
@@ -133,6 +133,15 @@ func prepareTini(packagePath string) {
 	Expect(err).NotTo(HaveOccurred())
 }
 
+func prepareBpm(packagePath string) {
+	// The container of a process runs bpm itself to record its exit status.
+	err := copyFile(filepath.Join(packagePath, "bpm"), bpmPath)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = os.Chmod(filepath.Join(packagePath, "bpm"), 0777)
+	Expect(err).NotTo(HaveOccurred())
+}
+
 func setupBoshDirectories(root, job string) string {
 	jobsDataDir := filepath.Join(root, "data", job)
 	Expect(os.MkdirAll(jobsDataDir, 0755)).To(Succeed())
@@ -151,6 +160,7 @@ func setupBoshDirectories(root, job string) string {
 
 	prepareRunc(bpmPackagePath)
 	prepareTini(bpmPackagePath)
+	prepareBpm(bpmPackagePath)
 
 	return runDir
 }
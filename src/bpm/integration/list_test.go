@@ -16,6 +16,7 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -136,4 +137,45 @@ var _ = Describe("list", func() {
 		Expect(session.Out).NotTo(gbytes.Say(unimplementedJob))
 		Expect(session.Err).NotTo(gbytes.Say(unimplementedJob))
 	})
+
+	It("lists the jobs as JSON", func() {
+		startJob(boshRoot, bpmPath, job)
+
+		Eventually(func() specs.ContainerState { return runcState(runcRoot, containerID).Status }).Should(Equal(specs.StateRunning))
+
+		command.Args = append(command.Args, "--output", "json")
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		state := runcState(runcRoot, containerID)
+		Eventually(session).Should(gexec.Exit(0))
+
+		var processes []map[string]interface{}
+		Expect(json.Unmarshal(session.Out.Contents(), &processes)).To(Succeed())
+
+		var running map[string]interface{}
+		for _, process := range processes {
+			if process["name"] == job {
+				running = process
+			}
+		}
+
+		Expect(running).NotTo(BeNil())
+		Expect(running["job"]).To(Equal(job))
+		Expect(running["process"]).To(Equal(job))
+		Expect(running["pid"]).To(Equal(float64(state.Pid)))
+		Expect(running["status"]).To(Equal(string(state.Status)))
+		Expect(running["start_time"]).NotTo(BeNil())
+		Expect(running["bundle_path"]).To(Equal(filepath.Join(boshRoot, "data", "bpm", "bundles", job, job)))
+		Expect(running["config_path"]).To(Equal(filepath.Join(boshRoot, "jobs", job, "config", "bpm.yml")))
+	})
+
+	It("rejects unknown output formats", func() {
+		command.Args = append(command.Args, "--output", "xml")
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Eventually(session).Should(gexec.Exit(1))
+		Expect(session.Err).To(gbytes.Say("invalid output format"))
+	})
 })
@@ -0,0 +1,157 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	uuid "github.com/satori/go.uuid"
+
+	"bpm/config"
+	"bpm/jobid"
+)
+
+var _ = Describe("reload", func() {
+	var (
+		cfg config.JobConfig
+
+		boshRoot    string
+		containerID string
+		job         string
+		runcRoot    string
+		stdout      string
+	)
+
+	runBpm := func(status int, args ...string) *gexec.Session {
+		command := exec.Command(bpmPath, args...)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "30s").Should(gexec.Exit(status))
+		return session
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		containerID = jobid.Encode(job)
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "reload-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		runcRoot = setupBoshDirectories(boshRoot, job)
+
+		stdout = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stdout.log", job))
+
+		cfg = newJobConfig(job, reloadBash)
+		cfg.Processes[0].ReloadSignal = "HUP"
+		writeConfig(boshRoot, job, cfg)
+
+		startJob(boshRoot, bpmPath, job)
+	})
+
+	AfterEach(func() {
+		err := runcCommand(runcRoot, "delete", "--force", containerID).Run()
+		if err != nil {
+			GinkgoWriter.Printf("WARNING: Failed to cleanup container: %s\n", err.Error())
+		}
+		copyContentsToGinkgoWrite(stdout)
+
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	It("sends the reload signal to the process", func() {
+		runBpm(0, "reload", job)
+
+		Eventually(fileContents(stdout)).Should(ContainSubstring("Received a HUP signal"))
+		Expect(runcState(runcRoot, containerID).Status).To(BeEquivalentTo("running"))
+	})
+
+	Context("when the limits of the process have changed", func() {
+		var pid int
+
+		BeforeEach(func() {
+			pid = runcState(runcRoot, containerID).Pid
+
+			processes := int64(100)
+			cfg.Processes[0].Limits = &config.Limits{Processes: &processes}
+			writeConfig(boshRoot, job, cfg)
+		})
+
+		It("refuses to reload the process", func() {
+			session := runBpm(1, "reload", job)
+			Expect(session.Err).To(gbytes.Say("process must be restarted to apply changes to: limits"))
+
+			Consistently(fileContents(stdout)).ShouldNot(ContainSubstring("Received a HUP signal"))
+			Expect(runcState(runcRoot, containerID).Pid).To(Equal(pid))
+		})
+
+		It("restarts the process with --restart-if-needed", func() {
+			runBpm(0, "reload", job, "--restart-if-needed")
+
+			Expect(fileContents(stdout)()).To(ContainSubstring("Received a TERM signal"))
+			Expect(runcState(runcRoot, containerID).Pid).NotTo(Equal(pid))
+		})
+	})
+
+	Context("when only the environment of the process has changed", func() {
+		BeforeEach(func() {
+			cfg.Processes[0].Env = map[string]string{"RELOAD_TEST": "changed"}
+			writeConfig(boshRoot, job, cfg)
+		})
+
+		It("refuses to reload the process", func() {
+			session := runBpm(1, "reload", job)
+			Expect(session.Err).To(gbytes.Say("process must be restarted to apply changes to: process"))
+
+			Consistently(fileContents(stdout)).ShouldNot(ContainSubstring("Received a HUP signal"))
+		})
+	})
+
+	Context("when only reload-safe fields of the process have changed", func() {
+		BeforeEach(func() {
+			cfg.Processes[0].ShutdownTimeout = "5s"
+			cfg.Processes[0].RestartPolicy = &config.RestartPolicy{Policy: config.RestartNever}
+			writeConfig(boshRoot, job, cfg)
+		})
+
+		It("sends the reload signal to the process", func() {
+			runBpm(0, "reload", job)
+
+			Eventually(fileContents(stdout)).Should(ContainSubstring("Received a HUP signal"))
+		})
+	})
+
+	Context("when the process does not have a reload signal", func() {
+		BeforeEach(func() {
+			cfg.Processes[0].ReloadSignal = ""
+			writeConfig(boshRoot, job, cfg)
+		})
+
+		It("returns an error", func() {
+			session := runBpm(1, "reload", job)
+			Expect(session.Err).To(gbytes.Say("process does not have a reload_signal"))
+		})
+	})
+})
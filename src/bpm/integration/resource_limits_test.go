@@ -23,6 +23,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	uuid "github.com/satori/go.uuid"
@@ -116,6 +117,28 @@ var _ = Describe("resource limits", func() {
 		})
 	})
 
+	Context("memory", func() {
+		BeforeEach(func() {
+			cfg = newJobConfig(job, `x=$(head -c 256M /dev/zero | tr '\0' a); echo "allocated"`)
+			limit := "32M"
+			cfg.Processes[0].Limits = &config.Limits{Memory: &limit}
+		})
+
+		It("reports a process which is killed by the OOM killer", func() {
+			command = exec.Command(bpmPath, "run", job)
+			command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session, "30s").Should(gexec.Exit(122))
+			Expect(session.Err).To(gbytes.Say("job-process was killed by the OOM killer"))
+
+			Expect(fileContents(stdout)()).NotTo(ContainSubstring("allocated"))
+			Expect(fileContents(stderr)()).To(ContainSubstring("bpm: process was killed by the OOM killer"))
+			Expect(fileContents(filepath.Join(boshRoot, "sys", "log", job, "bpm.log"))()).To(ContainSubstring("oom-killed"))
+		})
+	})
+
 	Context("core file size", func() {
 		BeforeEach(func() {
 			limit := uint64(1048576)
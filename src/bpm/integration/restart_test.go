@@ -0,0 +1,157 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	uuid "github.com/satori/go.uuid"
+
+	"bpm/bosh"
+	"bpm/config"
+	"bpm/jobid"
+)
+
+var _ = Describe("restart", func() {
+	var (
+		command *exec.Cmd
+
+		cfg config.JobConfig
+
+		boshRoot    string
+		bpmLog      string
+		containerID string
+		job         string
+		runcRoot    string
+		stderr      string
+		stdout      string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		containerID = jobid.Encode(job)
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "restart-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		boshEnv := bosh.NewEnv(boshRoot)
+
+		runcRoot = setupBoshDirectories(boshRoot, job)
+
+		stdout = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stdout.log", job))
+		stderr = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stderr.log", job))
+		bpmLog = filepath.Join(boshRoot, "sys", "log", job, "bpm.log")
+
+		cfg = newJobConfig(job, defaultBash(boshEnv.LogDir(job).Join("foo.log").Internal()))
+	})
+
+	JustBeforeEach(func() {
+		writeConfig(boshRoot, job, cfg)
+
+		command = exec.Command(bpmPath, "restart", job)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+	})
+
+	AfterEach(func() {
+		err := runcCommand(runcRoot, "delete", "--force", containerID).Run()
+		if err != nil {
+			GinkgoWriter.Printf("WARNING: Failed to cleanup container: %s\n", err.Error())
+		}
+		copyContentsToGinkgoWrite(stdout)
+		copyContentsToGinkgoWrite(stderr)
+
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	Context("when the job is running", func() {
+		var originalPid int
+
+		JustBeforeEach(func() {
+			startJob(boshRoot, bpmPath, job)
+			Eventually(func() specs.ContainerState { return runcState(runcRoot, containerID).Status }).Should(Equal(specs.StateRunning))
+			originalPid = runcState(runcRoot, containerID).Pid
+		})
+
+		It("stops the process and starts a new one", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+
+			Eventually(fileContents(stdout)).Should(ContainSubstring("Received a TERM signal"))
+
+			state := runcState(runcRoot, containerID)
+			Expect(state.Status).To(Equal(specs.StateRunning))
+			Expect(state.Pid).NotTo(Equal(originalPid))
+		})
+
+		It("logs each phase of the restart", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+
+			Eventually(fileContents(bpmLog)).Should(ContainSubstring("bpm.restart.starting"))
+			Eventually(fileContents(bpmLog)).Should(ContainSubstring("bpm.restart.remove.forcefully-deleting-container"))
+			Eventually(fileContents(bpmLog)).Should(ContainSubstring("bpm.restart.complete"))
+		})
+
+		Context("when the process is no longer in the job configuration", func() {
+			It("fails without stopping the running process", func() {
+				command.Args = append(command.Args, "-p", "missing")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(1))
+				Expect(session.Err).To(gbytes.Say(`process "missing" not present in job configuration`))
+
+				Expect(runcState(runcRoot, containerID).Pid).To(Equal(originalPid))
+			})
+		})
+	})
+
+	Context("when the job is not running", func() {
+		It("starts the process", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+
+			Eventually(func() specs.ContainerState { return runcState(runcRoot, containerID).Status }).Should(Equal(specs.StateRunning))
+			Expect(fileContents(bpmLog)()).To(ContainSubstring("job-already-stopped"))
+		})
+	})
+
+	Context("when the process fails to start", func() {
+		BeforeEach(func() {
+			cfg.Processes[0].Executable = "/does/not/exist"
+		})
+
+		It("reports the phase that failed", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(1))
+
+			Expect(session.Err).To(gbytes.Say("failed to restart job-process: start phase"))
+		})
+	})
+})
@@ -177,6 +177,46 @@ var _ = Describe("BPM run command", func() {
 		})
 	})
 
+	Context("when the process is killed by a signal", func() {
+		BeforeEach(func() {
+			s.LoadFixture("killed", "testdata/killed.yml")
+		})
+
+		It("bpm exits with 128 plus the signal", func() {
+			cmd := s.BPMCmd("run", "killed")
+			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(143))
+			Expect(session.Err).To(gbytes.Say("killed by SIGTERM"))
+		})
+	})
+
+	Context("when a hook fails", func() {
+		BeforeEach(func() {
+			s.LoadFixture("hooked", "testdata/failing-hook.yml")
+		})
+
+		It("bpm exits with the reserved hook failure status", func() {
+			cmd := s.BPMCmd("run", "hooked")
+			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(123))
+		})
+	})
+
+	Context("when the process is not in the job configuration", func() {
+		BeforeEach(func() {
+			s.LoadFixture("odd", "testdata/odd-status.yml")
+		})
+
+		It("bpm exits with the reserved invalid configuration status", func() {
+			cmd := s.BPMCmd("run", "odd", "-p", "missing")
+			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(125))
+		})
+	})
+
 })
 
 // uniqueJobName appends a random suffix to a job name.
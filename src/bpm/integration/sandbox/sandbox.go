@@ -77,6 +77,17 @@ func New(t testing.TB) *Sandbox {
 		t.Fatalf("could not chown tini executable: %v", err)
 	}
 
+	bpmSandboxPath := filepath.Join(root, "packages", "bpm", "bin", "bpm")
+	if err := copyFile(bpmSandboxPath, BPMPath); err != nil {
+		t.Fatalf("could not copy bpm executable into sandbox: %v", err)
+	}
+	if err := os.Chown(bpmSandboxPath, 2000, 3000); err != nil {
+		t.Fatalf("could not chown bpm executable: %v", err)
+	}
+	if err := os.Chmod(bpmSandboxPath, 0700); err != nil {
+		t.Fatalf("could not chmod bpm executable: %v", err)
+	}
+
 	return &Sandbox{
 		t:    t,
 		root: root,
@@ -127,6 +127,43 @@ var _ = Describe("start", func() {
 		Consistently(session.Err).ShouldNot(gbytes.Say("Usage:"))
 	})
 
+	It("records the shell session in the bpm log", func() {
+		startJob(boshRoot, bpmPath, job)
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ttyF.Close()).NotTo(HaveOccurred())
+
+		_, err = ptyF.Write([]byte("exit\n"))
+		Expect(err).ShouldNot(HaveOccurred())
+		<-session.Exited
+		Expect(session).To(gexec.Exit(0))
+
+		bpmLog := filepath.Join(boshRoot, "sys", "log", job, "bpm.log")
+		Expect(fileContents(bpmLog)()).To(MatchRegexp(`(?s)shell-started.*"invoked_by".*shell-exited.*"duration"`))
+	})
+
+	Context("when a shell and user are given", func() {
+		JustBeforeEach(func() {
+			command.Args = append(command.Args, "--shell", "/bin/sh", "--user", "root")
+		})
+
+		It("runs that shell as that user", func() {
+			startJob(boshRoot, bpmPath, job)
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ttyF.Close()).NotTo(HaveOccurred())
+
+			_, err = ptyF.Write([]byte("echo \"uid=$(id -u) shell=$0\"\nexit\n"))
+			Expect(err).ShouldNot(HaveOccurred())
+			<-session.Exited
+			Expect(session).To(gexec.Exit(0))
+
+			Eventually(session.Out).Should(gbytes.Say("uid=0 shell=/bin/sh"))
+		})
+	})
+
 	Context("when the container does not exist", func() {
 		It("returns an error", func() {
 			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
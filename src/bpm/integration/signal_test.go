@@ -0,0 +1,115 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	uuid "github.com/satori/go.uuid"
+
+	"bpm/jobid"
+)
+
+var _ = Describe("signal", func() {
+	var (
+		boshRoot    string
+		containerID string
+		job         string
+		runcRoot    string
+		stdout      string
+	)
+
+	runBpm := func(status int, args ...string) *gexec.Session {
+		command := exec.Command(bpmPath, args...)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "30s").Should(gexec.Exit(status))
+		return session
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		containerID = jobid.Encode(job)
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "signal-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		runcRoot = setupBoshDirectories(boshRoot, job)
+
+		stdout = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stdout.log", job))
+
+		writeConfig(boshRoot, job, newJobConfig(job, reloadBash))
+	})
+
+	AfterEach(func() {
+		err := runcCommand(runcRoot, "delete", "--force", containerID).Run()
+		if err != nil {
+			GinkgoWriter.Printf("WARNING: Failed to cleanup container: %s\n", err.Error())
+		}
+		copyContentsToGinkgoWrite(stdout)
+
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	Context("when the process is running", func() {
+		BeforeEach(func() {
+			startJob(boshRoot, bpmPath, job)
+		})
+
+		It("sends the signal to the process", func() {
+			runBpm(0, "signal", job, "HUP")
+
+			Eventually(fileContents(stdout)).Should(ContainSubstring("Received a HUP signal"))
+			Expect(runcState(runcRoot, containerID).Status).To(BeEquivalentTo("running"))
+
+			bpmLog := filepath.Join(boshRoot, "sys", "log", job, "bpm.log")
+			Expect(fileContents(bpmLog)()).To(MatchRegexp(`sending-signal.*"signal":"HUP"`))
+		})
+
+		It("sends the signal to every process in the container with --all", func() {
+			runBpm(0, "signal", job, "SIGHUP", "--all")
+
+			// The sleep does not handle HUP so it exits, which in turn
+			// makes the process exit.
+			Eventually(fileContents(stdout)).Should(ContainSubstring("Received a HUP signal"))
+			Eventually(func() string {
+				return string(runBpm(0, "list").Out.Contents())
+			}, "10s").Should(MatchRegexp(fmt.Sprintf(`%s\s+-\s+failed`, job)))
+		})
+
+		It("rejects unknown signals", func() {
+			session := runBpm(1, "signal", job, "NOPE")
+			Expect(session.Err).To(gbytes.Say(`unknown signal: "NOPE"`))
+		})
+	})
+
+	Context("when the process is not running", func() {
+		It("returns an error", func() {
+			session := runBpm(1, "signal", job, "HUP")
+			Expect(session.Err).To(gbytes.Say("process is not running or could not be found"))
+		})
+	})
+})
@@ -0,0 +1,113 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/yaml.v3"
+
+	"bpm/config"
+	"bpm/exitstatus"
+)
+
+var _ = Describe("spec", func() {
+	var (
+		cfg config.JobConfig
+
+		boshRoot string
+		job      string
+	)
+
+	runBpm := func(status int, args ...string) *gexec.Session {
+		command := exec.Command(bpmPath, args...)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "30s").Should(gexec.Exit(status))
+		return session
+	}
+
+	parseSpec := func(session *gexec.Session) specs.Spec {
+		var spec specs.Spec
+		Expect(json.Unmarshal(session.Out.Contents(), &spec)).To(Succeed())
+		return spec
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "spec-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		setupBoshDirectories(boshRoot, job)
+
+		cfg = newJobConfig(job, "sleep 100")
+		writeConfig(boshRoot, job, cfg)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	It("prints the spec without setting up the process", func() {
+		session := runBpm(0, "spec", job, "-e", "EXTRA=value")
+
+		spec := parseSpec(session)
+		Expect(spec.Process.Args).To(ContainElement("sleep 100"))
+		Expect(spec.Process.Env).To(ContainElement("EXTRA=value"))
+
+		Expect(filepath.Join(boshRoot, "data", "bpm", "bundles", job)).NotTo(BeADirectory())
+		Expect(filepath.Join(boshRoot, "sys", "log", job)).NotTo(BeADirectory())
+		Expect(filepath.Join(boshRoot, "sys", "run", "bpm", job)).NotTo(BeADirectory())
+	})
+
+	It("uses a configuration which has not been deployed", func() {
+		processes := int64(42)
+		cfg.Processes[0].Limits = &config.Limits{Processes: &processes}
+		data, err := yaml.Marshal(&cfg)
+		Expect(err).NotTo(HaveOccurred())
+
+		configPath := filepath.Join(boshRoot, "new-bpm.yml")
+		Expect(os.WriteFile(configPath, data, 0644)).To(Succeed())
+
+		spec := parseSpec(runBpm(0, "spec", job, "--config", configPath))
+		Expect(*spec.Linux.Resources.Pids.Limit).To(BeEquivalentTo(42))
+	})
+
+	Context("when the configuration is invalid", func() {
+		BeforeEach(func() {
+			writeInvalidConfig(boshRoot, job)
+		})
+
+		It("fails", func() {
+			session := runBpm(exitstatus.InvalidConfig, "spec", job)
+			Expect(session.Err).To(gbytes.Say("failed to parse job configuration"))
+		})
+	})
+})
@@ -242,7 +242,7 @@ var _ = Describe("start", func() {
 			Expect(f.Close()).To(Succeed())
 
 			cfg.Processes[0].Hooks = &config.Hooks{
-				PreStart: preStart,
+				PreStart: config.Hook{Path: preStart},
 			}
 		})
 
@@ -255,6 +255,111 @@ var _ = Describe("start", func() {
 		})
 	})
 
+	Context("when a pre_start hook runs in the container", func() {
+		BeforeEach(func() {
+			binDir := filepath.Join(boshRoot, "jobs", job, "bin")
+			Expect(os.MkdirAll(binDir, 0755)).To(Succeed())
+			preStart := filepath.Join(binDir, "pre-start")
+			Expect(os.WriteFile(preStart, []byte(containerHookBash), 0755)).To(Succeed())
+
+			cfg.Processes[0].Hooks = &config.Hooks{
+				PreStart: config.Hook{
+					Path:           boshEnv.JobDir(job).Join("bin", "pre-start").Internal(),
+					RunInContainer: true,
+				},
+			}
+		})
+
+		It("runs the hook as the process user and cleans up its container", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+
+			Eventually(fileContents(stdout)).Should(ContainSubstring("Hook running as vcap"))
+			Expect(runcCommand(runcRoot, "state", jobid.Encode(fmt.Sprintf("%s+prestart", job))).Run()).NotTo(Succeed())
+			Expect(filepath.Join(boshRoot, "data", "bpm", "bundles", job, job+"+prestart")).NotTo(BeADirectory())
+		})
+	})
+
+	Context("when waiting for a process with a readiness probe", func() {
+		JustBeforeEach(func() {
+			command.Args = append(command.Args, "--wait")
+		})
+
+		Context("when the probe passes", func() {
+			BeforeEach(func() {
+				cfg.Processes[0].Readiness = &config.Probe{
+					Exec: []string{"/bin/true"},
+				}
+			})
+
+			It("starts the process and exits once it is ready", func() {
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+
+				state := runcState(runcRoot, containerID)
+				Expect(state.Status).To(Equal(specs.StateRunning))
+			})
+		})
+
+		Context("when the probe never passes", func() {
+			BeforeEach(func() {
+				cfg.Processes[0].Readiness = &config.Probe{
+					Exec:             []string{"/bin/false"},
+					Interval:         "100ms",
+					FailureThreshold: 3,
+				}
+			})
+
+			It("exits with a distinct exit status", func() {
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(3))
+				Expect(session.Err).To(gbytes.Say("job-process is not ready: process did not become ready after 3 attempts"))
+
+				Expect(fileContents(bpmLog)).To(ContainSubstring("failed-to-become-ready"))
+			})
+		})
+
+		Context("when the process exits before becoming ready", func() {
+			BeforeEach(func() {
+				cfg = newJobConfig(job, "exit 1")
+				cfg.Processes[0].Readiness = &config.Probe{
+					TCP: &config.TCPProbe{Port: 1},
+				}
+			})
+
+			It("exits with a distinct exit status", func() {
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(3))
+				Expect(session.Err).To(gbytes.Say("process stopped before becoming ready"))
+			})
+		})
+	})
+
+	Context("when a post_start hook fails", func() {
+		BeforeEach(func() {
+			postStart := filepath.Join(boshRoot, "post-start")
+			Expect(os.WriteFile(postStart, []byte(failingHookBash), 0777)).To(Succeed())
+
+			cfg.Processes[0].Hooks = &config.Hooks{
+				PostStart: config.Hook{Path: postStart},
+			}
+		})
+
+		It("fails the start and removes the container", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("post-start hook failed"))
+
+			Expect(runcCommand(runcRoot, "state", containerID).Run()).NotTo(Succeed())
+			Eventually(fileContents(stderr)).Should(ContainSubstring("Hook failing"))
+		})
+	})
+
 	Context("when persistent storage is request", func() {
 		var dataFile bosh.Path
 
@@ -0,0 +1,145 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	uuid "github.com/satori/go.uuid"
+
+	"bpm/config"
+	"bpm/jobid"
+	"bpm/models"
+)
+
+var _ = Describe("stats", func() {
+	var (
+		args []string
+		cfg  config.JobConfig
+
+		boshRoot    string
+		containerID string
+		job         string
+		runcRoot    string
+		stderr      string
+		stdout      string
+	)
+
+	runStats := func() *gexec.Session {
+		command := exec.Command(bpmPath, append([]string{"stats"}, args...)...)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).ShouldNot(HaveOccurred())
+		<-session.Exited
+
+		return session
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		containerID = jobid.Encode(job)
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "stats-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		runcRoot = setupBoshDirectories(boshRoot, job)
+
+		stdout = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stdout.log", job))
+		stderr = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stderr.log", job))
+
+		logFile := filepath.Join(boshRoot, "sys", "log", job, "foo.log")
+		cfg = newJobConfig(job, defaultBash(logFile))
+		pidLimit := int64(50)
+		cfg.Processes[0].Limits = &config.Limits{Processes: &pidLimit}
+		writeConfig(boshRoot, job, cfg)
+
+		args = []string{job}
+	})
+
+	AfterEach(func() {
+		err := runcCommand(runcRoot, "delete", "--force", containerID).Run()
+		if err != nil {
+			GinkgoWriter.Printf("WARNING: Failed to cleanup container: %s\n", err.Error())
+		}
+		copyContentsToGinkgoWrite(stdout)
+		copyContentsToGinkgoWrite(stderr)
+
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	It("prints the resource usage of the process", func() {
+		startJob(boshRoot, bpmPath, job)
+
+		session := runStats()
+		Expect(session).To(gexec.Exit(0))
+		Expect(session.Out).Should(gbytes.Say("Name\\s+Pid\\s+Memory\\s+Peak\\s+Limit\\s+CPU\\s+Pids\\s+Open Files"))
+		Expect(session.Out).Should(gbytes.Say(job))
+	})
+
+	Context("when --json is specified", func() {
+		BeforeEach(func() {
+			args = append(args, "--json")
+		})
+
+		It("prints the resource usage as JSON", func() {
+			startJob(boshRoot, bpmPath, job)
+
+			session := runStats()
+			Expect(session).To(gexec.Exit(0))
+
+			var stats []models.ProcessStats
+			Expect(json.Unmarshal(session.Out.Contents(), &stats)).To(Succeed())
+			Expect(stats).To(HaveLen(1))
+
+			state := runcState(runcRoot, containerID)
+			Expect(stats[0].Job).To(Equal(job))
+			Expect(stats[0].Pid).To(Equal(state.Pid))
+			Expect(stats[0].PidsCurrent).To(BeNumerically(">", 0))
+			Expect(stats[0].PidsLimit).NotTo(BeNil())
+			Expect(*stats[0].PidsLimit).To(Equal(uint64(50)))
+		})
+	})
+
+	Context("when the container does not exist", func() {
+		It("returns an error", func() {
+			session := runStats()
+			Expect(session).To(gexec.Exit(1))
+			Expect(session.Err).Should(gbytes.Say("Error: process is not running or could not be found"))
+		})
+	})
+
+	Context("when --all is specified with a job", func() {
+		BeforeEach(func() {
+			args = append(args, "--all")
+		})
+
+		It("returns an error", func() {
+			session := runStats()
+			Expect(session).To(gexec.Exit(1))
+			Expect(session.Err).Should(gbytes.Say("a job cannot be specified when using --all"))
+		})
+	})
+})
@@ -20,6 +20,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -238,6 +239,109 @@ var _ = Describe("stop", func() {
 		})
 	})
 
+	Context("when the process does not exit after the shutdown signal", func() {
+		BeforeEach(func() {
+			cfg = newJobConfig(job, slowShutdownBash)
+			cfg.Processes[0].ShutdownTimeout = "1s"
+		})
+
+		It("sends a SIGQUIT after the configured shutdown timeout", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+
+			Eventually(fileContents(stdout)).Should(ContainSubstring("Received a TERM signal"))
+			Eventually(fileContents(stdout)).Should(ContainSubstring("Received a QUIT signal"))
+		})
+
+		Context("when a timeout is passed on the command line", func() {
+			BeforeEach(func() {
+				cfg.Processes[0].ShutdownTimeout = "5m"
+			})
+
+			It("uses the flag instead of the configured shutdown timeout", func() {
+				command.Args = append(command.Args, "--timeout", "1s")
+
+				session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+
+				Eventually(fileContents(stdout)).Should(ContainSubstring("Received a QUIT signal"))
+			})
+		})
+	})
+
+	Context("when pre_stop and post_stop hooks are specified", func() {
+		BeforeEach(func() {
+			preStop := filepath.Join(boshRoot, "pre-stop")
+			Expect(os.WriteFile(preStop, []byte(preStopBash), 0777)).To(Succeed())
+			postStop := filepath.Join(boshRoot, "post-stop")
+			Expect(os.WriteFile(postStop, []byte(postStopBash), 0777)).To(Succeed())
+
+			cfg.Processes[0].Hooks = &config.Hooks{
+				PreStop:  config.Hook{Path: preStop},
+				PostStop: config.Hook{Path: postStop},
+			}
+		})
+
+		It("runs the hooks around stopping the process", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(0))
+
+			tmpDir := boshEnv.DataDir(job).Join("tmp").Internal()
+			Eventually(fileContents(stdout)).Should(MatchRegexp(
+				"(?s)Executing Pre Stop with TMPDIR=%s.*Received a TERM signal.*Executing Post Stop", tmpDir,
+			))
+		})
+	})
+
+	Context("when the process has a shutdown sequence", func() {
+		BeforeEach(func() {
+			cfg = newJobConfig(job, drainBash)
+			cfg.Processes[0].Shutdown = []config.ShutdownStep{
+				{Signal: "USR1", Wait: "1s"},
+				{Signal: "TERM", Wait: "5s"},
+				{Signal: "KILL"},
+			}
+		})
+
+		It("sends each signal in turn and logs every step", func() {
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(session, 10*time.Second).Should(gexec.Exit(0))
+
+			Eventually(fileContents(stdout)).Should(ContainSubstring("Received a USR1 signal"))
+			Eventually(fileContents(stdout)).Should(ContainSubstring("Received a TERM signal"))
+
+			Expect(fileContents(bpmLog)()).To(ContainSubstring("bpm.stop.shutdown-step.sending-signal"))
+			Expect(fileContents(bpmLog)()).To(ContainSubstring("bpm.stop.shutdown-step.process-stopped"))
+			Expect(fileContents(bpmLog)()).NotTo(ContainSubstring(`"signal":"KILL"`))
+		})
+
+		It("does not allow the timeout to be overridden", func() {
+			command.Args = append(command.Args, "--timeout", "1s")
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("cannot be used with a process which has a shutdown sequence"))
+		})
+	})
+
+	Context("when the timeout flag is out of range", func() {
+		It("exits 1 without stopping the process", func() {
+			command.Args = append(command.Args, "--timeout", "1h")
+
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(1))
+
+			Expect(session.Err).To(gbytes.Say("timeout must be between"))
+			Expect(runcCommand(runcRoot, "state", containerID).Run()).To(Succeed())
+		})
+	})
+
 	Context("when the shutdown signal is SIGINT", func() {
 		BeforeEach(func() {
 			cfg.Processes[0].ShutdownSignal = "INT"
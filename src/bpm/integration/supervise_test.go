@@ -0,0 +1,175 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	uuid "github.com/satori/go.uuid"
+
+	"bpm/config"
+	"bpm/jobid"
+	"bpm/supervisor"
+)
+
+var _ = Describe("supervise", func() {
+	var (
+		cfg config.JobConfig
+
+		boshRoot    string
+		containerID string
+		job         string
+		runcRoot    string
+		stateFile   string
+		stdout      string
+	)
+
+	bpmCommand := func(args ...string) *exec.Cmd {
+		command := exec.Command(bpmPath, args...)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+		return command
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		containerID = jobid.Encode(job)
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "supervise-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		runcRoot = setupBoshDirectories(boshRoot, job)
+
+		stdout = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stdout.log", job))
+		stateFile = filepath.Join(boshRoot, "sys", "run", "bpm", job, fmt.Sprintf("%s.supervisor.json", job))
+
+		logFile := filepath.Join(boshRoot, "sys", "log", job, "foo.log")
+		cfg = newJobConfig(job, defaultBash(logFile))
+	})
+
+	JustBeforeEach(func() {
+		writeConfig(boshRoot, job, cfg)
+	})
+
+	AfterEach(func() {
+		err := runcCommand(runcRoot, "delete", "--force", containerID).Run()
+		if err != nil {
+			GinkgoWriter.Printf("WARNING: Failed to cleanup container: %s\n", err.Error())
+		}
+		copyContentsToGinkgoWrite(stdout)
+
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	Context("when the process keeps failing", func() {
+		BeforeEach(func() {
+			cfg = newJobConfig(job, "echo crashing; exit 3")
+			cfg.Processes[0].RestartPolicy = &config.RestartPolicy{
+				Policy:     config.RestartOnFailure,
+				MaxRetries: 2,
+				Backoff:    "100ms",
+			}
+		})
+
+		It("restarts it until the retries run out and then gives up", func() {
+			session, err := gexec.Start(bpmCommand("supervise", job), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session, "20s").Should(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("job-process exited with status 3 and will not be restarted"))
+
+			Expect(fileLines(stdout)()).To(Equal([]string{"crashing", "crashing", "crashing"}))
+
+			state, err := supervisor.ReadState(stateFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Status).To(Equal(supervisor.StatusFailed))
+			Expect(state.Restarts).To(Equal(2))
+			Expect(*state.LastExitStatus).To(Equal(3))
+
+			list, err := gexec.Start(bpmCommand("list"), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(list).Should(gexec.Exit(0))
+			Expect(list.Out).To(gbytes.Say(fmt.Sprintf(`%s\s+failed`, job)))
+		})
+
+		It("no longer reports the failure once the process is started and stopped by hand", func() {
+			session, err := gexec.Start(bpmCommand("supervise", job), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session, "20s").Should(gexec.Exit(1))
+
+			start, err := gexec.Start(bpmCommand("start", job), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(start, "10s").Should(gexec.Exit(0))
+			Expect(stateFile).NotTo(BeAnExistingFile())
+
+			stop, err := gexec.Start(bpmCommand("stop", job), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(stop, "30s").Should(gexec.Exit(0))
+
+			list, err := gexec.Start(bpmCommand("list"), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(list).Should(gexec.Exit(0))
+			Expect(list.Out).To(gbytes.Say(fmt.Sprintf(`%s\s+stopped`, job)))
+		})
+	})
+
+	Context("when the process is stopped with bpm stop", func() {
+		It("does not restart it", func() {
+			session, err := gexec.Start(bpmCommand("supervise", job), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() specs.ContainerState { return runcState(runcRoot, containerID).Status }, "10s").Should(Equal(specs.StateRunning))
+
+			stop, err := gexec.Start(bpmCommand("stop", job), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(stop, "30s").Should(gexec.Exit(0))
+
+			Eventually(session, "10s").Should(gexec.Exit(0))
+			Expect(runcCommand(runcRoot, "state", containerID).Run()).NotTo(Succeed())
+
+			state, err := supervisor.ReadState(stateFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Status).To(Equal(supervisor.StatusStopped))
+		})
+	})
+
+	Context("when the process is restarted with bpm restart", func() {
+		It("stops the supervisor instead of racing it", func() {
+			session, err := gexec.Start(bpmCommand("supervise", job), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() specs.ContainerState { return runcState(runcRoot, containerID).Status }, "10s").Should(Equal(specs.StateRunning))
+			pid := runcState(runcRoot, containerID).Pid
+
+			restart, err := gexec.Start(bpmCommand("restart", job), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(restart, "30s").Should(gexec.Exit(0))
+
+			Eventually(session, "10s").Should(gexec.Exit(0))
+
+			state := runcState(runcRoot, containerID)
+			Expect(state.Status).To(Equal(specs.StateRunning))
+			Expect(state.Pid).NotTo(Equal(pid))
+		})
+	})
+})
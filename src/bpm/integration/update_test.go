@@ -0,0 +1,138 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	uuid "github.com/satori/go.uuid"
+
+	"bpm/config"
+	"bpm/jobid"
+	"bpm/models"
+)
+
+var _ = Describe("update", func() {
+	var (
+		cfg config.JobConfig
+
+		boshRoot    string
+		containerID string
+		job         string
+		runcRoot    string
+		stdout      string
+		pid         int
+	)
+
+	runBpm := func(status int, args ...string) *gexec.Session {
+		command := exec.Command(bpmPath, args...)
+		command.Env = append(command.Env, fmt.Sprintf("BPM_BOSH_ROOT=%s", boshRoot))
+
+		session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, "30s").Should(gexec.Exit(status))
+		return session
+	}
+
+	stats := func() models.ProcessStats {
+		session := runBpm(0, "stats", job, "--json")
+
+		var stats []models.ProcessStats
+		Expect(json.Unmarshal(session.Out.Contents(), &stats)).To(Succeed())
+		Expect(stats).To(HaveLen(1))
+		return stats[0]
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		job = uuid.NewV4().String()
+		containerID = jobid.Encode(job)
+		boshRoot, err = os.MkdirTemp(bpmTmpDir, "update-test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chmod(boshRoot, 0755)).To(Succeed())
+		runcRoot = setupBoshDirectories(boshRoot, job)
+
+		stdout = filepath.Join(boshRoot, "sys", "log", job, fmt.Sprintf("%s.stdout.log", job))
+
+		memory := "64M"
+		processes := int64(100)
+		logFile := filepath.Join(boshRoot, "sys", "log", job, "foo.log")
+		cfg = newJobConfig(job, defaultBash(logFile))
+		cfg.Processes[0].Limits = &config.Limits{Memory: &memory, Processes: &processes}
+		writeConfig(boshRoot, job, cfg)
+
+		startJob(boshRoot, bpmPath, job)
+		pid = runcState(runcRoot, containerID).Pid
+	})
+
+	AfterEach(func() {
+		err := runcCommand(runcRoot, "delete", "--force", containerID).Run()
+		if err != nil {
+			GinkgoWriter.Printf("WARNING: Failed to cleanup container: %s\n", err.Error())
+		}
+		copyContentsToGinkgoWrite(stdout)
+
+		Expect(os.RemoveAll(boshRoot)).To(Succeed())
+	})
+
+	It("applies new limits without restarting the process", func() {
+		memory := "128M"
+		processes := int64(200)
+		cfg.Processes[0].Limits = &config.Limits{Memory: &memory, Processes: &processes}
+		writeConfig(boshRoot, job, cfg)
+
+		runBpm(0, "update", job)
+
+		s := stats()
+		Expect(*s.MemoryLimit).To(BeEquivalentTo(128 * 1024 * 1024))
+		Expect(*s.PidsLimit).To(BeEquivalentTo(200))
+		Expect(runcState(runcRoot, containerID).Pid).To(Equal(pid))
+	})
+
+	It("applies new limits alongside other changes and reports that those need a restart", func() {
+		processes := int64(200)
+		cfg.Processes[0].Limits.Processes = &processes
+		cfg.Processes[0].Env = map[string]string{"UPDATE_TEST": "changed"}
+		writeConfig(boshRoot, job, cfg)
+
+		session := runBpm(0, "update", job)
+		Expect(session.Err).To(gbytes.Say("must be restarted to apply changes to: process"))
+
+		Expect(*stats().PidsLimit).To(BeEquivalentTo(200))
+		Expect(runcState(runcRoot, containerID).Pid).To(Equal(pid))
+	})
+
+	It("rejects changes which need a restart", func() {
+		openFiles := uint64(1024)
+		cfg.Processes[0].Limits.OpenFiles = &openFiles
+		writeConfig(boshRoot, job, cfg)
+
+		session := runBpm(1, "update", job)
+		Expect(session.Err).To(gbytes.Say("process must be restarted to apply changes to: open_files or core_file_size limits"))
+
+		Expect(*stats().PidsLimit).To(BeEquivalentTo(100))
+		Expect(runcState(runcRoot, containerID).Pid).To(Equal(pid))
+	})
+})
@@ -15,6 +15,8 @@
 
 package models
 
+import "time"
+
 const (
 	ProcessStateFailed   = "failed"
 	ProcessStateRunning  = "running"
@@ -24,7 +26,126 @@ const (
 )
 
 type Process struct {
-	Name   string
-	Pid    int
-	Status string
+	// Name is the encoded container ID of the process.
+	Name     string
+	JobName  string
+	ProcName string
+	Pid      int
+	Status   string
+
+	// OOMKilled is whether a process in a container which has stopped was
+	// killed by the OOM killer.
+	OOMKilled bool
+
+	// StartTime is the time the container was created. It is the zero time
+	// if the process is not running.
+	StartTime  time.Time
+	BundlePath string
+	ConfigPath string
+
+	// LastExit is the most recent run of the process which has ended. It is
+	// nil if the process has never stopped.
+	LastExit *HistoryEntry
+}
+
+// HistoryEntry is a single run of a process. StopTime is nil while the
+// process is still running. Signal is set if the process was stopped by bpm
+// and ExitStatus if it exited by itself with a known status. OOMKilled is set
+// if a process in the container was killed by the OOM killer. The JSON form of
+// this type is part of the public interface of `bpm history --json`.
+type HistoryEntry struct {
+	StartTime  time.Time  `json:"start_time"`
+	StopTime   *time.Time `json:"stop_time"`
+	ExitStatus *int       `json:"exit_status"`
+	Signal     string     `json:"signal,omitempty"`
+	OOMKilled  bool       `json:"oom_killed"`
+}
+
+// ProcessStats is a sample of the resources used by a running process. Limits
+// are nil when the process is unlimited. The JSON form of this type is part of
+// the public interface of `bpm stats --json`.
+type ProcessStats struct {
+	Name    string `json:"name"`
+	Job     string `json:"job"`
+	Process string `json:"process"`
+	Pid     int    `json:"pid"`
+
+	MemoryCurrent uint64  `json:"memory_current_bytes"`
+	MemoryPeak    *uint64 `json:"memory_peak_bytes"`
+	MemoryLimit   *uint64 `json:"memory_limit_bytes"`
+
+	CPUUsage   float64  `json:"cpu_usage_seconds"`
+	CPUPercent *float64 `json:"cpu_percent,omitempty"`
+
+	PidsCurrent uint64  `json:"pids_current"`
+	PidsLimit   *uint64 `json:"pids_limit"`
+
+	OpenFiles      uint64  `json:"open_files"`
+	OpenFilesLimit *uint64 `json:"open_files_limit"`
+}
+
+// ProcessInspection describes the container of a process as it was created by
+// bpm. Limits are nil when the process is unlimited. The JSON form of this type
+// is part of the public interface of `bpm inspect --json`.
+type ProcessInspection struct {
+	Name    string `json:"name"`
+	Job     string `json:"job"`
+	Process string `json:"process"`
+	Status  string `json:"status"`
+	Pid     int    `json:"pid"`
+	Bundle  string `json:"bundle"`
+
+	// Executable is the init process which wraps the executable of the
+	// process, which is included in Args.
+	Executable string   `json:"executable"`
+	Args       []string `json:"args"`
+	Env        []string `json:"env"`
+	User       string   `json:"user"`
+	WorkDir    string   `json:"workdir"`
+
+	Mounts       []MountInspection `json:"mounts"`
+	Capabilities []string          `json:"capabilities"`
+	Namespaces   []string          `json:"namespaces"`
+	Seccomp      bool              `json:"seccomp"`
+
+	Limits LimitsInspection `json:"limits"`
+
+	// CgroupPaths maps each cgroup subsystem to the path of the cgroup of
+	// the container. The only subsystem is "" on hosts which use cgroup v2.
+	CgroupPaths map[string]string `json:"cgroup_paths"`
+}
+
+type MountInspection struct {
+	Destination     string `json:"destination"`
+	Source          string `json:"source"`
+	Type            string `json:"type"`
+	Writable        bool   `json:"writable"`
+	AllowExecutions bool   `json:"allow_executions"`
+}
+
+type LimitsInspection struct {
+	MemoryBytes            *int64               `json:"memory_bytes"`
+	MemoryReservationBytes *int64               `json:"memory_reservation_bytes"`
+	MemoryHighBytes        *int64               `json:"memory_high_bytes"`
+	SwapBytes              *int64               `json:"swap_bytes"`
+	OOMScoreAdj            *int                 `json:"oom_score_adj"`
+	CPUShares              *uint64              `json:"cpu_shares"`
+	CPUQuota               *int64               `json:"cpu_quota_us"`
+	CPUPeriod              *uint64              `json:"cpu_period_us"`
+	CPUSet                 string               `json:"cpuset_cpus,omitempty"`
+	IOWeight               *uint16              `json:"io_weight"`
+	IODevices              []IODeviceInspection `json:"io_devices"`
+	Processes              *int64               `json:"processes"`
+	OpenFiles              *uint64              `json:"open_files"`
+	CoreFileSize           *uint64              `json:"core_file_size"`
+}
+
+// IODeviceInspection is the throttling applied to a single block device,
+// which is identified by its major and minor numbers, e.g. "8:0".
+type IODeviceInspection struct {
+	Device    string  `json:"device"`
+	ReadBPS   *uint64 `json:"read_bps"`
+	WriteBPS  *uint64 `json:"write_bps"`
+	ReadIOPS  *uint64 `json:"read_iops"`
+	WriteIOPS *uint64 `json:"write_iops"`
 }
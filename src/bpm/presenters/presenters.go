@@ -16,11 +16,17 @@
 package presenters
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
+	yaml "gopkg.in/yaml.v3"
 
 	"bpm/jobid"
 	"bpm/models"
@@ -41,12 +47,413 @@ func PrintJobs(processes []*models.Process, stdout io.Writer) error {
 			pid = strconv.Itoa(process.Pid)
 		}
 
-		printRow(tw, name, pid, process.Status)
+		printRow(tw, name, pid, displayStatus(process))
+	}
+
+	return tw.Flush()
+}
+
+// PrintJobsWide prints the same table as PrintJobs with additional columns
+// describing how each process last exited and where it lives on disk.
+func PrintJobsWide(processes []*models.Process, stdout io.Writer) error {
+	tw := tabwriter.NewWriter(stdout, 0, 0, 1, ' ', 0)
+
+	printRow(tw, "Name", "Pid", "Status", "Started", "Last Exit", "Bundle", "Config")
+	for _, process := range processes {
+		name, err := jobid.Decode(process.Name)
+		if err != nil {
+			return err
+		}
+
+		pid := "-"
+		if process.Pid > 0 {
+			pid = strconv.Itoa(process.Pid)
+		}
+
+		started := "-"
+		if !process.StartTime.IsZero() {
+			started = process.StartTime.UTC().Format(time.RFC3339)
+		}
+
+		lastExit := "-"
+		if process.LastExit != nil {
+			lastExit = describeExit(*process.LastExit)
+		}
+
+		printRow(tw, name, pid, displayStatus(process), started, lastExit, process.BundlePath, process.ConfigPath)
+	}
+
+	return tw.Flush()
+}
+
+// displayStatus is the status of a process as shown in a table, which notes
+// why a process has stopped if it is known.
+func displayStatus(process *models.Process) string {
+	if process.OOMKilled {
+		return fmt.Sprintf("%s (oom-killed)", process.Status)
+	}
+
+	return process.Status
+}
+
+// PrintHistory prints a table of the runs of a process, oldest first.
+func PrintHistory(entries []models.HistoryEntry, stdout io.Writer) error {
+	tw := tabwriter.NewWriter(stdout, 0, 0, 1, ' ', 0)
+
+	printRow(tw, "Started", "Stopped", "Exit")
+	for _, entry := range entries {
+		stopped, exit := "-", "running"
+		if entry.StopTime != nil {
+			stopped = entry.StopTime.UTC().Format(time.RFC3339)
+			exit = describeExit(entry)
+		}
+
+		printRow(tw, entry.StartTime.UTC().Format(time.RFC3339), stopped, exit)
+	}
+
+	return tw.Flush()
+}
+
+func PrintHistoryJSON(entries []models.HistoryEntry, stdout io.Writer) error {
+	if entries == nil {
+		entries = []models.HistoryEntry{}
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// describeExit summarises how a run which has ended exited. The exit status
+// is not known if the process did not write one, e.g. because it was started
+// by an older version of bpm.
+func describeExit(entry models.HistoryEntry) string {
+	switch {
+	case entry.OOMKilled && entry.ExitStatus != nil:
+		return fmt.Sprintf("oom-killed (%d)", *entry.ExitStatus)
+	case entry.OOMKilled:
+		return "oom-killed"
+	case entry.Signal != "":
+		return fmt.Sprintf("stopped (%s)", entry.Signal)
+	case entry.ExitStatus != nil:
+		return fmt.Sprintf("exited (%d)", *entry.ExitStatus)
+	default:
+		return "exited (unknown)"
+	}
+}
+
+// processOutput is the machine-readable representation of a process. It is
+// part of the public interface of bpm and so fields must not be removed or
+// changed in meaning.
+type processOutput struct {
+	Name       string     `json:"name" yaml:"name"`
+	Job        string     `json:"job" yaml:"job"`
+	Process    string     `json:"process" yaml:"process"`
+	Pid        int        `json:"pid" yaml:"pid"`
+	Status     string     `json:"status" yaml:"status"`
+	OOMKilled  bool       `json:"oom_killed" yaml:"oom_killed"`
+	StartTime  *time.Time `json:"start_time" yaml:"start_time"`
+	BundlePath string     `json:"bundle_path" yaml:"bundle_path"`
+	ConfigPath string     `json:"config_path" yaml:"config_path"`
+}
+
+func newProcessOutput(process *models.Process) (processOutput, error) {
+	name, err := jobid.Decode(process.Name)
+	if err != nil {
+		return processOutput{}, err
+	}
+
+	out := processOutput{
+		Name:       name,
+		Job:        process.JobName,
+		Process:    process.ProcName,
+		Pid:        process.Pid,
+		Status:     process.Status,
+		OOMKilled:  process.OOMKilled,
+		BundlePath: process.BundlePath,
+		ConfigPath: process.ConfigPath,
+	}
+
+	if !process.StartTime.IsZero() {
+		started := process.StartTime.UTC()
+		out.StartTime = &started
+	}
+
+	return out, nil
+}
+
+func newProcessOutputs(processes []*models.Process) ([]processOutput, error) {
+	outs := []processOutput{}
+	for _, process := range processes {
+		out, err := newProcessOutput(process)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, out)
+	}
+
+	return outs, nil
+}
+
+func PrintJobsJSON(processes []*models.Process, stdout io.Writer) error {
+	outs, err := newProcessOutputs(processes)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(outs)
+}
+
+func PrintJobsYAML(processes []*models.Process, stdout io.Writer) error {
+	outs, err := newProcessOutputs(processes)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(stdout)
+	defer enc.Close() //nolint:errcheck
+	return enc.Encode(outs)
+}
+
+// PrintProcessJSON prints a single process using the same schema as each
+// element of PrintJobsJSON.
+func PrintProcessJSON(process *models.Process, stdout io.Writer) error {
+	out, err := newProcessOutput(process)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(stdout).Encode(out)
+}
+
+func PrintStats(stats []*models.ProcessStats, stdout io.Writer) error {
+	tw := tabwriter.NewWriter(stdout, 0, 0, 1, ' ', 0)
+
+	printRow(tw, "Name", "Pid", "Memory", "Peak", "Limit", "CPU", "Pids", "Open Files")
+	for _, s := range stats {
+		cpu := fmt.Sprintf("%.2fs", s.CPUUsage)
+		if s.CPUPercent != nil {
+			cpu = fmt.Sprintf("%s (%.1f%%)", cpu, *s.CPUPercent)
+		}
+
+		printRow(
+			tw,
+			s.Name,
+			strconv.Itoa(s.Pid),
+			bytefmt.ByteSize(s.MemoryCurrent),
+			optionalBytes(s.MemoryPeak, "-"),
+			optionalBytes(s.MemoryLimit, "unlimited"),
+			cpu,
+			fmt.Sprintf("%d/%s", s.PidsCurrent, optionalCount(s.PidsLimit)),
+			fmt.Sprintf("%d/%s", s.OpenFiles, optionalCount(s.OpenFilesLimit)),
+		)
 	}
 
 	return tw.Flush()
 }
 
+func PrintStatsJSON(stats []*models.ProcessStats, stdout io.Writer) error {
+	return json.NewEncoder(stdout).Encode(stats)
+}
+
+// PrintInspection prints a description of the container of a process, followed
+// by tables of its limits, environment, and mounts.
+func PrintInspection(inspection *models.ProcessInspection, stdout io.Writer) error {
+	tw := tabwriter.NewWriter(stdout, 0, 0, 1, ' ', 0)
+
+	pid := "-"
+	if inspection.Pid > 0 {
+		pid = strconv.Itoa(inspection.Pid)
+	}
+
+	seccomp := "disabled"
+	if inspection.Seccomp {
+		seccomp = "enabled"
+	}
+
+	printRow(tw, "Name:", inspection.Name)
+	printRow(tw, "Status:", inspection.Status)
+	printRow(tw, "Pid:", pid)
+	printRow(tw, "Bundle:", inspection.Bundle)
+	printRow(tw, "Executable:", inspection.Executable)
+	printRow(tw, "Args:", quoteArgs(inspection.Args))
+	printRow(tw, "User:", inspection.User)
+	printRow(tw, "Working Directory:", inspection.WorkDir)
+	printRow(tw, "Capabilities:", optionalList(inspection.Capabilities))
+	printRow(tw, "Namespaces:", optionalList(inspection.Namespaces))
+	printRow(tw, "Seccomp:", seccomp)
+	for _, subsystem := range sortedKeys(inspection.CgroupPaths) {
+		label := "Cgroup:"
+		if subsystem != "" {
+			label = fmt.Sprintf("Cgroup (%s):", subsystem)
+		}
+		printRow(tw, label, inspection.CgroupPaths[subsystem])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	limits := inspection.Limits
+	cpuSet := limits.CPUSet
+	if cpuSet == "" {
+		cpuSet = "-"
+	}
+
+	oomScoreAdj := "-"
+	if limits.OOMScoreAdj != nil {
+		oomScoreAdj = strconv.Itoa(*limits.OOMScoreAdj)
+	}
+
+	ioWeight := "-"
+	if limits.IOWeight != nil {
+		ioWeight = strconv.Itoa(int(*limits.IOWeight))
+	}
+
+	fmt.Fprintln(stdout, "\nLimits:") //nolint:errcheck
+	tw = tabwriter.NewWriter(stdout, 0, 0, 1, ' ', 0)
+	printRow(tw, "  Memory", optionalSignedBytes(limits.MemoryBytes))
+	printRow(tw, "  Memory Reservation", optionalSignedBytes(limits.MemoryReservationBytes))
+	printRow(tw, "  Memory High", optionalSignedBytes(limits.MemoryHighBytes))
+	printRow(tw, "  Swap", optionalSignedBytes(limits.SwapBytes))
+	printRow(tw, "  OOM Score Adjustment", oomScoreAdj)
+	printRow(tw, "  CPU Shares", optionalCount(limits.CPUShares))
+	printRow(tw, "  CPU Quota", optionalCPUQuota(limits.CPUQuota, limits.CPUPeriod))
+	printRow(tw, "  CPU Set", cpuSet)
+	printRow(tw, "  I/O Weight", ioWeight)
+	for _, device := range limits.IODevices {
+		printRow(tw, fmt.Sprintf("  I/O (%s)", device.Device), ioDeviceLimits(device))
+	}
+	printRow(tw, "  Processes", optionalSignedCount(limits.Processes))
+	printRow(tw, "  Open Files", optionalCount(limits.OpenFiles))
+	printRow(tw, "  Core File Size", optionalBytes(limits.CoreFileSize, "unlimited"))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, "\nEnvironment:") //nolint:errcheck
+	for _, env := range inspection.Env {
+		fmt.Fprintf(stdout, "  %s\n", env) //nolint:errcheck
+	}
+
+	fmt.Fprintln(stdout, "\nMounts:") //nolint:errcheck
+	tw = tabwriter.NewWriter(stdout, 0, 0, 1, ' ', 0)
+	printRow(tw, "  Destination", "Source", "Options")
+	for _, m := range inspection.Mounts {
+		options := "ro"
+		if m.Writable {
+			options = "rw"
+		}
+		if !m.AllowExecutions {
+			options += ",noexec"
+		}
+
+		printRow(tw, "  "+m.Destination, m.Source, options)
+	}
+
+	return tw.Flush()
+}
+
+func PrintInspectionJSON(inspection *models.ProcessInspection, stdout io.Writer) error {
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(inspection)
+}
+
+// quoteArgs joins arguments so that those containing whitespace can still be
+// told apart.
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+func optionalList(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+
+	return strings.Join(values, ", ")
+}
+
+func optionalSignedBytes(value *int64) string {
+	if value == nil || *value < 0 {
+		return "unlimited"
+	}
+
+	return bytefmt.ByteSize(uint64(*value))
+}
+
+func optionalSignedCount(value *int64) string {
+	if value == nil || *value <= 0 {
+		return "unlimited"
+	}
+
+	return strconv.FormatInt(*value, 10)
+}
+
+// optionalCPUQuota describes a CFS quota as the number of CPUs it amounts to.
+func optionalCPUQuota(quota *int64, period *uint64) string {
+	if quota == nil || *quota <= 0 || period == nil || *period == 0 {
+		return "unlimited"
+	}
+
+	return fmt.Sprintf("%dus per %dus (%.2f CPUs)", *quota, *period, float64(*quota)/float64(*period))
+}
+
+// ioDeviceLimits describes the throttling of a block device, e.g. "read 10M/s,
+// write 100 IOPS".
+func ioDeviceLimits(device models.IODeviceInspection) string {
+	var limits []string
+	if device.ReadBPS != nil {
+		limits = append(limits, fmt.Sprintf("read %s/s", bytefmt.ByteSize(*device.ReadBPS)))
+	}
+	if device.WriteBPS != nil {
+		limits = append(limits, fmt.Sprintf("write %s/s", bytefmt.ByteSize(*device.WriteBPS)))
+	}
+	if device.ReadIOPS != nil {
+		limits = append(limits, fmt.Sprintf("read %d IOPS", *device.ReadIOPS))
+	}
+	if device.WriteIOPS != nil {
+		limits = append(limits, fmt.Sprintf("write %d IOPS", *device.WriteIOPS))
+	}
+
+	return optionalList(limits)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func optionalBytes(value *uint64, missing string) string {
+	if value == nil {
+		return missing
+	}
+
+	return bytefmt.ByteSize(*value)
+}
+
+func optionalCount(value *uint64) string {
+	if value == nil {
+		return "unlimited"
+	}
+
+	return strconv.FormatUint(*value, 10)
+}
+
 func printRow(w io.Writer, args ...string) {
 	row := strings.Join(args, "\t")
 	fmt.Fprintf(w, "%s\n", row) //nolint:errcheck
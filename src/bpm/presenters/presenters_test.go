@@ -16,11 +16,14 @@
 package presenters_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
+	"gopkg.in/yaml.v3"
 
 	"bpm/jobid"
 	"bpm/models"
@@ -39,6 +42,7 @@ var _ = Describe("Presenters", func() {
 				{Name: jobid.Encode("job-process-2"), Pid: 23456, Status: "created"},
 				{Name: jobid.Encode("job-process-1"), Pid: 34567, Status: "running"},
 				{Name: jobid.Encode("job-process-3"), Pid: 0, Status: "failed"},
+				{Name: jobid.Encode("job-process-4"), Pid: 0, Status: "failed", OOMKilled: true},
 			}
 
 			output = gbytes.NewBuffer()
@@ -50,6 +54,272 @@ var _ = Describe("Presenters", func() {
 			Expect(output).Should(gbytes.Say(fmt.Sprintf("%s\\s+%d\\s+%s", "job-process-2", 23456, "created")))
 			Expect(output).Should(gbytes.Say(fmt.Sprintf("%s\\s+%d\\s+%s", "job-process-1", 34567, "running")))
 			Expect(output).Should(gbytes.Say(fmt.Sprintf("%s\\s+%s\\s+%s", "job-process-3", "-", "failed")))
+			Expect(output).Should(gbytes.Say(fmt.Sprintf("%s\\s+%s\\s+%s", "job-process-4", "-", "failed \\(oom-killed\\)")))
+		})
+
+		Context("with paths and start times", func() {
+			BeforeEach(func() {
+				processes[1].JobName = "job"
+				processes[1].ProcName = "process-1"
+				processes[1].StartTime = time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+				processes[1].BundlePath = "/var/vcap/data/bpm/bundles/job/process-1"
+				processes[1].ConfigPath = "/var/vcap/jobs/job/config/bpm.yml"
+
+				exitStatus := 2
+				processes[2].LastExit = &models.HistoryEntry{ExitStatus: &exitStatus}
+			})
+
+			It("prints the jobs in a wide table", func() {
+				Expect(presenters.PrintJobsWide(processes, output)).To(Succeed())
+				Expect(output).Should(gbytes.Say("Name\\s+Pid\\s+Status\\s+Started\\s+Last Exit\\s+Bundle\\s+Config"))
+				Expect(output).Should(gbytes.Say("job-process-2\\s+23456\\s+created\\s+-\\s+-"))
+				Expect(output).Should(gbytes.Say("job-process-1\\s+34567\\s+running\\s+2018-03-04T05:06:07Z\\s+-\\s+/var/vcap/data/bpm/bundles/job/process-1\\s+/var/vcap/jobs/job/config/bpm.yml"))
+				Expect(output).Should(gbytes.Say("job-process-3\\s+-\\s+failed\\s+-\\s+exited \\(2\\)"))
+			})
+
+			It("prints the jobs as JSON", func() {
+				Expect(presenters.PrintJobsJSON(processes, output)).To(Succeed())
+
+				var decoded []map[string]interface{}
+				Expect(json.Unmarshal(output.Contents(), &decoded)).To(Succeed())
+				Expect(decoded).To(HaveLen(4))
+				Expect(decoded[0]["start_time"]).To(BeNil())
+				Expect(decoded[3]["oom_killed"]).To(BeTrue())
+				Expect(decoded[1]).To(Equal(map[string]interface{}{
+					"name":        "job-process-1",
+					"job":         "job",
+					"process":     "process-1",
+					"pid":         float64(34567),
+					"status":      "running",
+					"oom_killed":  false,
+					"start_time":  "2018-03-04T05:06:07Z",
+					"bundle_path": "/var/vcap/data/bpm/bundles/job/process-1",
+					"config_path": "/var/vcap/jobs/job/config/bpm.yml",
+				}))
+			})
+
+			It("prints the jobs as YAML", func() {
+				Expect(presenters.PrintJobsYAML(processes, output)).To(Succeed())
+
+				var decoded []map[string]interface{}
+				Expect(yaml.Unmarshal(output.Contents(), &decoded)).To(Succeed())
+				Expect(decoded).To(HaveLen(4))
+				Expect(decoded[1]["name"]).To(Equal("job-process-1"))
+				Expect(decoded[1]["pid"]).To(Equal(34567))
+				Expect(decoded[1]["bundle_path"]).To(Equal("/var/vcap/data/bpm/bundles/job/process-1"))
+			})
+
+			It("prints a single process as JSON", func() {
+				Expect(presenters.PrintProcessJSON(processes[1], output)).To(Succeed())
+
+				var decoded map[string]interface{}
+				Expect(json.Unmarshal(output.Contents(), &decoded)).To(Succeed())
+				Expect(decoded["name"]).To(Equal("job-process-1"))
+				Expect(decoded["pid"]).To(Equal(float64(34567)))
+				Expect(decoded["start_time"]).To(Equal("2018-03-04T05:06:07Z"))
+			})
+		})
+	})
+
+	Describe("PrintHistory", func() {
+		var (
+			entries []models.HistoryEntry
+			output  *gbytes.Buffer
+		)
+
+		BeforeEach(func() {
+			start := time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)
+			stop := start.Add(time.Hour)
+			exitStatus := 3
+
+			entries = []models.HistoryEntry{
+				{StartTime: start, StopTime: &stop, Signal: "TERM"},
+				{StartTime: start.Add(2 * time.Hour), StopTime: &stop, ExitStatus: &exitStatus},
+				{StartTime: start.Add(3 * time.Hour), StopTime: &stop},
+				{StartTime: start.Add(4 * time.Hour), StopTime: &stop, OOMKilled: true},
+				{StartTime: start.Add(5 * time.Hour)},
+			}
+
+			output = gbytes.NewBuffer()
+		})
+
+		It("prints how each run ended in a table", func() {
+			Expect(presenters.PrintHistory(entries, output)).To(Succeed())
+			Expect(output).Should(gbytes.Say("Started\\s+Stopped\\s+Exit"))
+			Expect(output).Should(gbytes.Say("2018-03-04T05:06:07Z\\s+2018-03-04T06:06:07Z\\s+stopped \\(TERM\\)"))
+			Expect(output).Should(gbytes.Say("2018-03-04T07:06:07Z\\s+2018-03-04T06:06:07Z\\s+exited \\(3\\)"))
+			Expect(output).Should(gbytes.Say("2018-03-04T08:06:07Z\\s+2018-03-04T06:06:07Z\\s+exited \\(unknown\\)"))
+			Expect(output).Should(gbytes.Say("2018-03-04T09:06:07Z\\s+2018-03-04T06:06:07Z\\s+oom-killed"))
+			Expect(output).Should(gbytes.Say("2018-03-04T10:06:07Z\\s+-\\s+running"))
+		})
+
+		It("prints the runs as JSON", func() {
+			Expect(presenters.PrintHistoryJSON(entries, output)).To(Succeed())
+
+			var decoded []map[string]interface{}
+			Expect(json.Unmarshal(output.Contents(), &decoded)).To(Succeed())
+			Expect(decoded).To(HaveLen(5))
+			Expect(decoded[0]).To(Equal(map[string]interface{}{
+				"start_time":  "2018-03-04T05:06:07Z",
+				"stop_time":   "2018-03-04T06:06:07Z",
+				"exit_status": nil,
+				"signal":      "TERM",
+				"oom_killed":  false,
+			}))
+			Expect(decoded[1]["exit_status"]).To(Equal(float64(3)))
+			Expect(decoded[3]["oom_killed"]).To(BeTrue())
+			Expect(decoded[4]["stop_time"]).To(BeNil())
+		})
+
+		It("prints an empty list for a process without any history", func() {
+			Expect(presenters.PrintHistoryJSON(nil, output)).To(Succeed())
+			Expect(string(output.Contents())).To(Equal("[]\n"))
+		})
+	})
+
+	Describe("PrintStats", func() {
+		var (
+			stats  []*models.ProcessStats
+			output *gbytes.Buffer
+		)
+
+		BeforeEach(func() {
+			memoryLimit := uint64(1024 * 1024 * 1024)
+			pidsLimit := uint64(100)
+			openFilesLimit := uint64(1024)
+			percent := 12.5
+
+			stats = []*models.ProcessStats{
+				{
+					Name:           "job.worker",
+					Pid:            1234,
+					MemoryCurrent:  256 * 1024 * 1024,
+					MemoryLimit:    &memoryLimit,
+					CPUUsage:       1.5,
+					CPUPercent:     &percent,
+					PidsCurrent:    4,
+					PidsLimit:      &pidsLimit,
+					OpenFiles:      12,
+					OpenFilesLimit: &openFilesLimit,
+				},
+				{
+					Name:          "job",
+					Pid:           5678,
+					MemoryCurrent: 1024,
+					PidsCurrent:   1,
+				},
+			}
+
+			output = gbytes.NewBuffer()
+		})
+
+		It("prints the stats in a table", func() {
+			Expect(presenters.PrintStats(stats, output)).To(Succeed())
+			Expect(output).Should(gbytes.Say("Name\\s+Pid\\s+Memory\\s+Peak\\s+Limit\\s+CPU\\s+Pids\\s+Open Files"))
+			Expect(output).Should(gbytes.Say("job.worker\\s+1234\\s+256M\\s+-\\s+1G\\s+1.50s \\(12.5%\\)\\s+4/100\\s+12/1024"))
+			Expect(output).Should(gbytes.Say("job\\s+5678\\s+1K\\s+-\\s+unlimited\\s+0.00s\\s+1/unlimited\\s+0/unlimited"))
+		})
+
+		It("prints the stats as JSON", func() {
+			Expect(presenters.PrintStatsJSON(stats, output)).To(Succeed())
+			Expect(output).Should(gbytes.Say(`"name":"job.worker"`))
+			Expect(output).Should(gbytes.Say(`"memory_limit_bytes":1073741824`))
+			Expect(output).Should(gbytes.Say(`"name":"job"`))
+			Expect(output).Should(gbytes.Say(`"memory_limit_bytes":null`))
+		})
+	})
+
+	Describe("PrintInspection", func() {
+		var (
+			inspection *models.ProcessInspection
+			output     *gbytes.Buffer
+		)
+
+		BeforeEach(func() {
+			memory := int64(1024 * 1024 * 1024)
+			reservation := int64(512 * 1024 * 1024)
+			swap := int64(-1)
+			oomScoreAdj := 500
+			ioWeight := uint16(100)
+			readBPS := uint64(10 * 1024 * 1024)
+			writeIOPS := uint64(100)
+			processes := int64(100)
+			openFiles := uint64(1024)
+
+			inspection = &models.ProcessInspection{
+				Name:         "job.worker",
+				Job:          "job",
+				Process:      "worker",
+				Status:       "running",
+				Pid:          1234,
+				Bundle:       "/var/vcap/data/bpm/bundles/job/worker",
+				Executable:   "/var/vcap/packages/bpm/bin/tini",
+				Args:         []string{"-w", "-s", "--", "/var/vcap/jobs/job/bin/worker", "--name", "two words"},
+				Env:          []string{"HOME=/var/vcap/data/job/worker"},
+				User:         "1000:1000",
+				WorkDir:      "/var/vcap/jobs/job",
+				Capabilities: []string{"CAP_NET_BIND_SERVICE"},
+				Namespaces:   []string{"ipc", "mount"},
+				Seccomp:      true,
+				Mounts: []models.MountInspection{
+					{Destination: "/var/vcap/jobs/job", Source: "/var/vcap/jobs/job", Type: "bind"},
+					{Destination: "/var/vcap/store/job", Source: "/var/vcap/store/job", Type: "bind", Writable: true},
+				},
+				Limits: models.LimitsInspection{
+					MemoryBytes:            &memory,
+					MemoryReservationBytes: &reservation,
+					SwapBytes:              &swap,
+					OOMScoreAdj:            &oomScoreAdj,
+					IOWeight:               &ioWeight,
+					IODevices: []models.IODeviceInspection{
+						{Device: "8:0", ReadBPS: &readBPS, WriteIOPS: &writeIOPS},
+					},
+					Processes: &processes,
+					OpenFiles: &openFiles,
+				},
+				CgroupPaths: map[string]string{"": "/sys/fs/cgroup/bpm-job.worker"},
+			}
+
+			output = gbytes.NewBuffer()
+		})
+
+		It("prints the container configuration", func() {
+			Expect(presenters.PrintInspection(inspection, output)).To(Succeed())
+			Expect(output).Should(gbytes.Say(`Name:\s+job.worker`))
+			Expect(output).Should(gbytes.Say(`Pid:\s+1234`))
+			Expect(output).Should(gbytes.Say(`Executable:\s+/var/vcap/packages/bpm/bin/tini`))
+			Expect(output).Should(gbytes.Say(`Args:\s+-w -s -- /var/vcap/jobs/job/bin/worker --name "two words"`))
+			Expect(output).Should(gbytes.Say(`Capabilities:\s+CAP_NET_BIND_SERVICE`))
+			Expect(output).Should(gbytes.Say(`Namespaces:\s+ipc, mount`))
+			Expect(output).Should(gbytes.Say(`Seccomp:\s+enabled`))
+			Expect(output).Should(gbytes.Say(`Cgroup:\s+/sys/fs/cgroup/bpm-job.worker`))
+			Expect(output).Should(gbytes.Say(`Memory\s+1G`))
+			Expect(output).Should(gbytes.Say(`Memory Reservation\s+512M`))
+			Expect(output).Should(gbytes.Say(`Memory High\s+unlimited`))
+			Expect(output).Should(gbytes.Say(`Swap\s+unlimited`))
+			Expect(output).Should(gbytes.Say(`OOM Score Adjustment\s+500`))
+			Expect(output).Should(gbytes.Say(`CPU Quota\s+unlimited`))
+			Expect(output).Should(gbytes.Say(`I/O Weight\s+100`))
+			Expect(output).Should(gbytes.Say(`I/O \(8:0\)\s+read 10M/s, write 100 IOPS`))
+			Expect(output).Should(gbytes.Say(`Processes\s+100`))
+			Expect(output).Should(gbytes.Say(`Open Files\s+1024`))
+			Expect(output).Should(gbytes.Say(`HOME=/var/vcap/data/job/worker`))
+			Expect(output).Should(gbytes.Say(`/var/vcap/jobs/job\s+/var/vcap/jobs/job\s+ro,noexec`))
+			Expect(output).Should(gbytes.Say(`/var/vcap/store/job\s+/var/vcap/store/job\s+rw,noexec`))
+		})
+
+		It("prints the container configuration as JSON", func() {
+			Expect(presenters.PrintInspectionJSON(inspection, output)).To(Succeed())
+			Expect(output).Should(gbytes.Say(`"name": "job.worker"`))
+			Expect(output).Should(gbytes.Say(`"executable": "/var/vcap/packages/bpm/bin/tini"`))
+			Expect(output).Should(gbytes.Say(`"writable": true`))
+			Expect(output).Should(gbytes.Say(`"memory_bytes": 1073741824`))
+			Expect(output).Should(gbytes.Say(`"memory_high_bytes": null`))
+			Expect(output).Should(gbytes.Say(`"oom_score_adj": 500`))
+			Expect(output).Should(gbytes.Say(`"cpu_shares": null`))
+			Expect(output).Should(gbytes.Say(`"device": "8:0"`))
+			Expect(output).Should(gbytes.Say(`"read_bps": 10485760`))
+			Expect(output).Should(gbytes.Say(`"cgroup_paths": {`))
 		})
 	})
 })
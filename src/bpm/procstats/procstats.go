@@ -0,0 +1,298 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+// Package procstats collects live resource usage for a bpm container from the
+// cgroup filesystem and procfs so that it can be compared to the limits the
+// process was started with.
+package procstats
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	procRoot = "/proc"
+
+	// cgroup v1 reports an "unlimited" memory limit as a huge page-aligned
+	// number rather than a sentinel. Anything this large is not a real limit.
+	cgroupV1Unlimited = uint64(1) << 62
+)
+
+// Stats is a point-in-time sample of the resources used by a container.
+// Limits are nil when the container is unlimited.
+type Stats struct {
+	MemoryCurrent uint64
+	MemoryPeak    *uint64
+	MemoryLimit   *uint64
+
+	CPUUsage time.Duration
+
+	PidsCurrent uint64
+	PidsLimit   *uint64
+
+	// OpenFiles is the largest number of open file descriptors held by any
+	// single process in the container. The limit applies per-process so this
+	// is the figure which is closest to being exhausted.
+	OpenFiles      uint64
+	OpenFilesLimit *uint64
+}
+
+// Collector reads container statistics from the host.
+type Collector struct {
+	procRoot string
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		procRoot: procRoot,
+	}
+}
+
+// Collect returns the statistics for a container. The paths are the cgroup
+// directories of the container keyed by controller as recorded by runc, where
+// the cgroup v2 unified hierarchy is keyed by the empty string.
+func (c *Collector) Collect(paths map[string]string) (*Stats, error) {
+	var (
+		stats     *Stats
+		procsPath string
+		err       error
+	)
+
+	if path, ok := paths[""]; ok {
+		stats, err = c.collectV2(path)
+		procsPath = path
+	} else {
+		stats, err = c.collectV1(paths)
+		procsPath = paths["pids"]
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.collectOpenFiles(stats, procsPath); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (c *Collector) collectV2(path string) (*Stats, error) {
+	stats := &Stats{}
+
+	var err error
+	if stats.MemoryCurrent, err = readUint(path, "memory.current"); err != nil {
+		return nil, err
+	}
+
+	// memory.peak was only added in Linux 5.19.
+	if peak, err := readUint(path, "memory.peak"); err == nil {
+		stats.MemoryPeak = &peak
+	}
+
+	if stats.MemoryLimit, err = readLimit(path, "memory.max"); err != nil {
+		return nil, err
+	}
+
+	usage, err := readKeyedUint(path, "cpu.stat", "usage_usec")
+	if err != nil {
+		return nil, err
+	}
+	stats.CPUUsage = time.Duration(usage) * time.Microsecond
+
+	if stats.PidsCurrent, err = readUint(path, "pids.current"); err != nil {
+		return nil, err
+	}
+
+	if stats.PidsLimit, err = readLimit(path, "pids.max"); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (c *Collector) collectV1(paths map[string]string) (*Stats, error) {
+	stats := &Stats{}
+
+	var err error
+	if stats.MemoryCurrent, err = readUint(paths["memory"], "memory.usage_in_bytes"); err != nil {
+		return nil, err
+	}
+
+	peak, err := readUint(paths["memory"], "memory.max_usage_in_bytes")
+	if err != nil {
+		return nil, err
+	}
+	stats.MemoryPeak = &peak
+
+	limit, err := readUint(paths["memory"], "memory.limit_in_bytes")
+	if err != nil {
+		return nil, err
+	}
+	if limit < cgroupV1Unlimited {
+		stats.MemoryLimit = &limit
+	}
+
+	usage, err := readUint(paths["cpuacct"], "cpuacct.usage")
+	if err != nil {
+		return nil, err
+	}
+	stats.CPUUsage = time.Duration(usage)
+
+	if stats.PidsCurrent, err = readUint(paths["pids"], "pids.current"); err != nil {
+		return nil, err
+	}
+
+	if stats.PidsLimit, err = readLimit(paths["pids"], "pids.max"); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (c *Collector) collectOpenFiles(stats *Stats, cgroupPath string) error {
+	data, err := os.ReadFile(filepath.Join(cgroupPath, "cgroup.procs"))
+	if err != nil {
+		return err
+	}
+
+	for _, field := range strings.Fields(string(data)) {
+		pidDir := filepath.Join(c.procRoot, field)
+
+		// Processes may exit while we are looking at them so errors are
+		// skipped rather than failing the whole sample.
+		fds, err := os.ReadDir(filepath.Join(pidDir, "fd"))
+		if err != nil {
+			continue
+		}
+
+		if count := uint64(len(fds)); count >= stats.OpenFiles {
+			limit, err := readOpenFilesLimit(pidDir)
+			if err != nil {
+				continue
+			}
+
+			stats.OpenFiles = count
+			stats.OpenFilesLimit = limit
+		}
+	}
+
+	return nil
+}
+
+// readOpenFilesLimit returns the soft RLIMIT_NOFILE of a process.
+func readOpenFilesLimit(pidDir string) (*uint64, error) {
+	f, err := os.Open(filepath.Join(pidDir, "limits"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "Max open files") {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimPrefix(line, "Max open files"))
+		if len(fields) < 1 {
+			break
+		}
+
+		return parseLimit(fields[0], "unlimited")
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, errors.New("open files limit not found")
+}
+
+// OOMKills returns how many processes in a container have been killed by the
+// OOM killer. The paths are the cgroup directories of the container keyed by
+// controller as recorded by runc, where the cgroup v2 unified hierarchy is
+// keyed by the empty string. This can be used after the container has exited
+// as long as it has not been deleted.
+func OOMKills(paths map[string]string) (uint64, error) {
+	if path, ok := paths[""]; ok {
+		return readKeyedUint(path, "memory.events", "oom_kill")
+	}
+
+	if path, ok := paths["memory"]; ok {
+		// oom_kill was only added to memory.oom_control in Linux 4.13.
+		return readKeyedUint(path, "memory.oom_control", "oom_kill")
+	}
+
+	return 0, errors.New("no memory cgroup found")
+}
+
+func readUint(dir, file string) (uint64, error) {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func readLimit(dir, file string) (*uint64, error) {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLimit(strings.TrimSpace(string(data)), "max")
+}
+
+func parseLimit(value, unlimited string) (*uint64, error) {
+	if value == unlimited {
+		return nil, nil
+	}
+
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &limit, nil
+}
+
+func readKeyedUint(dir, file, key string) (uint64, error) {
+	f, err := os.Open(filepath.Join(dir, file))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 2 && fields[0] == key {
+			return strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("%s not found in %s", key, file)
+}
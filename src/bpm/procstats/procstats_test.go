@@ -0,0 +1,192 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package procstats
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Collector", func() {
+	var (
+		root      string
+		collector *Collector
+	)
+
+	writeFile := func(path, contents string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(contents), 0600)).To(Succeed())
+	}
+
+	addProcess := func(pid, fds int, limit string) {
+		pidDir := filepath.Join(root, "proc", strconv.Itoa(pid))
+		Expect(os.MkdirAll(filepath.Join(pidDir, "fd"), 0700)).To(Succeed())
+		for i := 0; i < fds; i++ {
+			writeFile(filepath.Join(pidDir, "fd", strconv.Itoa(i)), "")
+		}
+		writeFile(filepath.Join(pidDir, "limits"), "Limit                     Soft Limit           Hard Limit           Units\n"+
+			"Max processes             unlimited            unlimited            processes\n"+
+			"Max open files            "+limit+"                 4096                 files\n")
+	}
+
+	BeforeEach(func() {
+		var err error
+		root, err = os.MkdirTemp("", "procstats")
+		Expect(err).NotTo(HaveOccurred())
+
+		collector = &Collector{
+			procRoot: filepath.Join(root, "proc"),
+		}
+
+		addProcess(1, 3, "1024")
+		addProcess(2, 5, "2048")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	Context("on cgroup v2", func() {
+		var paths map[string]string
+
+		BeforeEach(func() {
+			cg := filepath.Join(root, "cgroup", "bpm", "job.proc")
+			paths = map[string]string{"": cg}
+
+			writeFile(filepath.Join(cg, "memory.current"), "1048576\n")
+			writeFile(filepath.Join(cg, "memory.peak"), "2097152\n")
+			writeFile(filepath.Join(cg, "memory.max"), "max\n")
+			writeFile(filepath.Join(cg, "cpu.stat"), "usage_usec 1500000\nuser_usec 1000000\n")
+			writeFile(filepath.Join(cg, "pids.current"), "4\n")
+			writeFile(filepath.Join(cg, "pids.max"), "100\n")
+			writeFile(filepath.Join(cg, "cgroup.procs"), "1\n2\n")
+		})
+
+		It("collects the statistics of the container", func() {
+			stats, err := collector.Collect(paths)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stats.MemoryCurrent).To(Equal(uint64(1048576)))
+			Expect(*stats.MemoryPeak).To(Equal(uint64(2097152)))
+			Expect(stats.MemoryLimit).To(BeNil())
+			Expect(stats.CPUUsage).To(Equal(1500 * time.Millisecond))
+			Expect(stats.PidsCurrent).To(Equal(uint64(4)))
+			Expect(*stats.PidsLimit).To(Equal(uint64(100)))
+			Expect(stats.OpenFiles).To(Equal(uint64(5)))
+			Expect(*stats.OpenFilesLimit).To(Equal(uint64(2048)))
+		})
+
+		Context("when memory.peak is not available", func() {
+			BeforeEach(func() {
+				Expect(os.Remove(filepath.Join(root, "cgroup", "bpm", "job.proc", "memory.peak"))).To(Succeed())
+			})
+
+			It("leaves the peak empty", func() {
+				stats, err := collector.Collect(paths)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stats.MemoryPeak).To(BeNil())
+			})
+		})
+
+		Context("when the cgroup does not exist", func() {
+			It("returns an error", func() {
+				_, err := collector.Collect(map[string]string{"": filepath.Join(root, "cgroup", "missing")})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("on cgroup v1", func() {
+		var paths map[string]string
+
+		BeforeEach(func() {
+			paths = map[string]string{
+				"memory":  filepath.Join(root, "cgroup", "memory", "job.proc"),
+				"cpu":     filepath.Join(root, "cgroup", "cpu,cpuacct", "job.proc"),
+				"cpuacct": filepath.Join(root, "cgroup", "cpu,cpuacct", "job.proc"),
+				"pids":    filepath.Join(root, "cgroup", "pids", "job.proc"),
+			}
+
+			memory := filepath.Join(root, "cgroup", "memory", "job.proc")
+			writeFile(filepath.Join(memory, "memory.usage_in_bytes"), "1048576\n")
+			writeFile(filepath.Join(memory, "memory.max_usage_in_bytes"), "2097152\n")
+			writeFile(filepath.Join(memory, "memory.limit_in_bytes"), "4194304\n")
+
+			writeFile(filepath.Join(root, "cgroup", "cpu,cpuacct", "job.proc", "cpuacct.usage"), "2000000000\n")
+
+			pids := filepath.Join(root, "cgroup", "pids", "job.proc")
+			writeFile(filepath.Join(pids, "pids.current"), "2\n")
+			writeFile(filepath.Join(pids, "pids.max"), "max\n")
+			writeFile(filepath.Join(pids, "cgroup.procs"), "1\n")
+		})
+
+		It("collects the statistics of the container", func() {
+			stats, err := collector.Collect(paths)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stats.MemoryCurrent).To(Equal(uint64(1048576)))
+			Expect(*stats.MemoryPeak).To(Equal(uint64(2097152)))
+			Expect(*stats.MemoryLimit).To(Equal(uint64(4194304)))
+			Expect(stats.CPUUsage).To(Equal(2 * time.Second))
+			Expect(stats.PidsCurrent).To(Equal(uint64(2)))
+			Expect(stats.PidsLimit).To(BeNil())
+			Expect(stats.OpenFiles).To(Equal(uint64(3)))
+			Expect(*stats.OpenFilesLimit).To(Equal(uint64(1024)))
+		})
+
+		Context("when the memory is unlimited", func() {
+			BeforeEach(func() {
+				writeFile(filepath.Join(root, "cgroup", "memory", "job.proc", "memory.limit_in_bytes"), "9223372036854771712\n")
+			})
+
+			It("leaves the limit empty", func() {
+				stats, err := collector.Collect(paths)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stats.MemoryLimit).To(BeNil())
+			})
+		})
+	})
+
+	Describe("OOMKills", func() {
+		It("reads the OOM kill count on cgroup v2", func() {
+			cg := filepath.Join(root, "cgroup", "bpm", "job.proc")
+			writeFile(filepath.Join(cg, "memory.events"), "low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n")
+
+			kills, err := OOMKills(map[string]string{"": cg})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kills).To(Equal(uint64(1)))
+		})
+
+		It("reads the OOM kill count on cgroup v1", func() {
+			cg := filepath.Join(root, "cgroup", "memory", "job.proc")
+			writeFile(filepath.Join(cg, "memory.oom_control"), "oom_kill_disable 0\nunder_oom 0\noom_kill 2\n")
+
+			kills, err := OOMKills(map[string]string{"memory": cg, "pids": "/unused"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kills).To(Equal(uint64(2)))
+		})
+
+		It("returns an error if there is no memory cgroup", func() {
+			_, err := OOMKills(map[string]string{"pids": "/unused"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -27,6 +27,7 @@ import (
 	"code.cloudfoundry.org/lager/v3"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 
+	"bpm/blockdev"
 	"bpm/config"
 	"bpm/hostlock"
 	"bpm/runc/specbuilder"
@@ -46,6 +47,10 @@ type GlobFunc func(string) ([]string, error)
 
 type MountShare func(string) error
 
+// DeviceResolver is a function which when given a file path returns the block
+// device which backs it.
+type DeviceResolver func(string) (blockdev.Device, error)
+
 type VolumeLocker interface {
 	LockVolume(string) (hostlock.LockedLock, error)
 }
@@ -56,34 +61,36 @@ type RuncAdapter struct {
 	shareMount     MountShare
 	locker         VolumeLocker
 	cgroupsPathFor func(containerID string) (string, error)
+	resolveDevice  DeviceResolver
 }
 
-func NewRuncAdapter(features sysfeat.Features, glob GlobFunc, mountSharer MountShare, locker VolumeLocker, cgroupsPathFor func(containerID string) (string, error)) *RuncAdapter {
+func NewRuncAdapter(features sysfeat.Features, glob GlobFunc, mountSharer MountShare, locker VolumeLocker, cgroupsPathFor func(containerID string) (string, error), resolveDevice DeviceResolver) *RuncAdapter {
 	return &RuncAdapter{
 		features:       features,
 		glob:           glob,
 		shareMount:     mountSharer,
 		locker:         locker,
 		cgroupsPathFor: cgroupsPathFor,
+		resolveDevice:  resolveDevice,
 	}
 }
 
-func (a *RuncAdapter) CreateJobPrerequisites(
-	bpmCfg *config.BPMConfig,
-	procCfg *config.ProcessConfig,
-	user specs.User,
-) (*os.File, *os.File, error) {
-	err := os.MkdirAll(bpmCfg.PidDir().External(), 0700)
-	if err != nil {
-		return nil, nil, err
-	}
+// jobPrerequisites are the changes to the host which must be made before a
+// process can be started.
+type jobPrerequisites struct {
+	sharedVolumes []config.Volume
+	dirsToChmod   []string
+	dirsToCreate  []string
+	pathsToChown  []string
+}
 
-	var dirsToCreate, pathsToChown []string
+// planJobPrerequisites works out which changes CreateJobPrerequisites would
+// make without making any of them.
+func planJobPrerequisites(bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) (*jobPrerequisites, error) {
+	plan := &jobPrerequisites{}
 	for _, vol := range procCfg.AdditionalVolumes {
 		if vol.Shared {
-			if err := a.makeShared(vol); err != nil {
-				return nil, nil, err
-			}
+			plan.sharedVolumes = append(plan.sharedVolumes, vol)
 		}
 
 		if vol.MountOnly {
@@ -92,49 +99,86 @@ func (a *RuncAdapter) CreateJobPrerequisites(
 
 		fi, err := os.Stat(vol.Path)
 		if os.IsNotExist(err) {
-			dirsToCreate = append(dirsToCreate, vol.Path)
+			plan.dirsToCreate = append(plan.dirsToCreate, vol.Path)
 		} else if err != nil {
-			return nil, nil, err
+			return nil, err
 		} else if fi.IsDir() && fi.Mode() != 0700 {
-			if err := os.Chmod(vol.Path, 0700); err != nil {
-				return nil, nil, err
-			}
+			plan.dirsToChmod = append(plan.dirsToChmod, vol.Path)
 		}
 
-		pathsToChown = append(pathsToChown, vol.Path)
+		plan.pathsToChown = append(plan.pathsToChown, vol.Path)
 	}
 
-	dirsToCreate = append(
-		dirsToCreate,
+	plan.dirsToCreate = append(
+		plan.dirsToCreate,
 		bpmCfg.LogDir().External(),
 		bpmCfg.SocketDir().External(),
 		bpmCfg.TempDir().External(),
 	)
 
 	if procCfg.EphemeralDisk {
-		dirsToCreate = append(dirsToCreate, bpmCfg.DataDir().External())
+		plan.dirsToCreate = append(plan.dirsToCreate, bpmCfg.DataDir().External())
 	}
 
 	if procCfg.PersistentDisk {
 		storeDir := bpmCfg.StoreDir().External()
 		storeExists, err := checkDirExists(filepath.Dir(storeDir))
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		if !storeExists {
-			return nil, nil, errors.New("requested persistent disk does not exist")
+			return nil, errors.New("requested persistent disk does not exist")
 		}
 
-		dirsToCreate = append(dirsToCreate, storeDir)
+		plan.dirsToCreate = append(plan.dirsToCreate, storeDir)
 	}
 
-	err = createDirs(dirsToCreate, user)
+	return plan, nil
+}
+
+// CheckJobPrerequisites returns the error which CreateJobPrerequisites would
+// return for a process that cannot be set up, e.g. because it needs a
+// persistent disk which does not exist. It does not change anything on the
+// host.
+func (a *RuncAdapter) CheckJobPrerequisites(bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+	_, err := planJobPrerequisites(bpmCfg, procCfg)
+	return err
+}
+
+func (a *RuncAdapter) CreateJobPrerequisites(
+	bpmCfg *config.BPMConfig,
+	procCfg *config.ProcessConfig,
+	user specs.User,
+) (*os.File, *os.File, error) {
+	plan, err := planJobPrerequisites(bpmCfg, procCfg)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	err = chownPaths(pathsToChown, user)
+	err = os.MkdirAll(bpmCfg.PidDir().External(), 0700)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, vol := range plan.sharedVolumes {
+		if err := a.makeShared(vol); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, dir := range plan.dirsToChmod {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err = createDirs(plan.dirsToCreate, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = chownPaths(plan.pathsToChown, user)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -204,6 +248,18 @@ func createLogFiles(bpmCfg *config.BPMConfig, user specs.User) (*os.File, *os.Fi
 	return files[0], files[1], nil
 }
 
+// OpenLogFiles opens the standard output and standard error log files of the
+// process for appending. They must be closed by the caller.
+func (a *RuncAdapter) OpenLogFiles(bpmCfg *config.BPMConfig, user specs.User) (*os.File, *os.File, error) {
+	return createLogFiles(bpmCfg, user)
+}
+
+// ProcessEnvironment returns the environment variables which the process is
+// run with.
+func (a *RuncAdapter) ProcessEnvironment(bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) []string {
+	return processEnvironment(procCfg.Env, bpmCfg)
+}
+
 func (a *RuncAdapter) BuildSpec(
 	logger lager.Logger,
 	bpmCfg *config.BPMConfig,
@@ -266,13 +322,8 @@ func (a *RuncAdapter) BuildSpec(
 	)
 
 	if procCfg.Limits != nil {
-		if procCfg.Limits.Memory != nil {
-			memLimit, err := bytefmt.ToBytes(*procCfg.Limits.Memory)
-			if err != nil {
-				return specs.Spec{}, err
-			}
-
-			specbuilder.Apply(spec, specbuilder.WithMemoryLimit(int64(memLimit), a.features))
+		if err := a.applyMemoryLimits(spec, procCfg.Limits); err != nil {
+			return specs.Spec{}, err
 		}
 
 		if procCfg.Limits.Processes != nil {
@@ -286,6 +337,28 @@ func (a *RuncAdapter) BuildSpec(
 		if procCfg.Limits.CoreFileSize != nil {
 			specbuilder.Apply(spec, specbuilder.WithCoreFileSizeLimit(*procCfg.Limits.CoreFileSize))
 		}
+
+		if quota, period, ok := procCfg.Limits.CPUQuotaAndPeriod(); ok {
+			specbuilder.Apply(spec, specbuilder.WithCPUQuota(quota, period))
+		}
+
+		if procCfg.Limits.CPUShares != nil {
+			specbuilder.Apply(spec, specbuilder.WithCPUShares(*procCfg.Limits.CPUShares))
+		}
+
+		if procCfg.Limits.CPUWeight != nil {
+			specbuilder.Apply(spec, specbuilder.WithCPUWeight(*procCfg.Limits.CPUWeight, a.features))
+		}
+
+		if procCfg.Limits.CPUSetCPUs != nil {
+			specbuilder.Apply(spec, specbuilder.WithCPUSet(*procCfg.Limits.CPUSetCPUs))
+		}
+
+		if procCfg.Limits.IO != nil {
+			if err := a.applyIOLimits(spec, procCfg.Limits.IO); err != nil {
+				return specs.Spec{}, err
+			}
+		}
 	}
 
 	if procCfg.Unsafe == nil || !procCfg.Unsafe.HostPidNamespace {
@@ -309,6 +382,101 @@ func (a *RuncAdapter) BuildSpec(
 	return *spec, nil
 }
 
+func (a *RuncAdapter) applyMemoryLimits(spec *specs.Spec, limits *config.Limits) error {
+	if limits.Memory != nil {
+		memLimit, err := bytefmt.ToBytes(*limits.Memory)
+		if err != nil {
+			return err
+		}
+
+		specbuilder.Apply(spec, specbuilder.WithMemoryLimit(int64(memLimit), a.features))
+
+		if limits.Swap != nil {
+			if !a.features.SwapLimitSupported {
+				return errors.New("a swap limit was requested but this system does not support limiting swap")
+			}
+
+			swap, err := limits.SwapBytes()
+			if err != nil {
+				return err
+			}
+
+			specbuilder.Apply(spec, specbuilder.WithSwapLimit(int64(memLimit+swap)))
+		}
+	}
+
+	if limits.MemoryReservation != nil {
+		reservation, err := bytefmt.ToBytes(*limits.MemoryReservation)
+		if err != nil {
+			return err
+		}
+
+		specbuilder.Apply(spec, specbuilder.WithMemoryReservation(int64(reservation)))
+	}
+
+	if limits.MemoryHigh != nil {
+		if !a.features.CgroupV2 {
+			return errors.New("memory_high was requested but it is only supported on cgroup v2 systems (consider memory_reservation)")
+		}
+
+		high, err := bytefmt.ToBytes(*limits.MemoryHigh)
+		if err != nil {
+			return err
+		}
+
+		specbuilder.Apply(spec, specbuilder.WithMemoryHigh(int64(high)))
+	}
+
+	if limits.OOMScoreAdj != nil {
+		specbuilder.Apply(spec, specbuilder.WithOOMScoreAdj(*limits.OOMScoreAdj))
+	}
+
+	return nil
+}
+
+func (a *RuncAdapter) applyIOLimits(spec *specs.Spec, io *config.IO) error {
+	if !a.features.IOControllerSupported {
+		return errors.New("io limits were requested but the block I/O cgroup controller is not available on this system")
+	}
+
+	if io.Weight != nil {
+		specbuilder.Apply(spec, specbuilder.WithBlockIOWeight(*io.Weight))
+	}
+
+	for _, dev := range io.Devices {
+		device, err := a.resolveDevice(dev.Path)
+		if err != nil {
+			return fmt.Errorf("unable to resolve io device for %s: %w", dev.Path, err)
+		}
+
+		var limit specbuilder.BlockIODeviceLimit
+
+		if dev.ReadBPS != nil {
+			if limit.ReadBPS, err = bytefmt.ToBytes(*dev.ReadBPS); err != nil {
+				return err
+			}
+		}
+
+		if dev.WriteBPS != nil {
+			if limit.WriteBPS, err = bytefmt.ToBytes(*dev.WriteBPS); err != nil {
+				return err
+			}
+		}
+
+		if dev.ReadIOPS != nil {
+			limit.ReadIOPS = *dev.ReadIOPS
+		}
+
+		if dev.WriteIOPS != nil {
+			limit.WriteIOPS = *dev.WriteIOPS
+		}
+
+		specbuilder.Apply(spec, specbuilder.WithBlockIODeviceLimit(device.Major, device.Minor, limit))
+	}
+
+	return nil
+}
+
 func filterVolumesUnderBoshMounts(boshMounts []specs.Mount, unrestrictedVolumes []config.Volume) []config.Volume {
 	var filteredVolumes []config.Volume
 	for _, v := range unrestrictedVolumes {
@@ -339,7 +507,7 @@ func filterVolumesUnderBoshMounts(boshMounts []specs.Mount, unrestrictedVolumes
 
 func wrapWithInit(bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) (string, []string) {
 	exe := bpmCfg.TiniPath().Internal()
-	args := append([]string{"-w", "-s", "--", procCfg.Executable}, procCfg.Args...)
+	args := append([]string{"-w", "-s", "--", bpmCfg.BPMPath().Internal(), "wrap", procCfg.Executable}, procCfg.Args...)
 	return exe, args
 }
 
@@ -32,6 +32,7 @@ import (
 	"github.com/onsi/gomega/types"
 	"github.com/opencontainers/runtime-spec/specs-go"
 
+	"bpm/blockdev"
 	"bpm/bosh"
 	"bpm/config"
 	"bpm/hostlock"
@@ -57,6 +58,7 @@ var _ = Describe("RuncAdapter", func() {
 		volumeLocker *fakeVolumeLocker
 
 		cgroupsPathForFn func(containerID string) (string, error)
+		deviceResolver   DeviceResolver
 	)
 
 	BeforeEach(func() {
@@ -92,6 +94,10 @@ var _ = Describe("RuncAdapter", func() {
 		cgroupsPathForFn = func(containerID string) (string, error) {
 			return "", fmt.Errorf("not on cgroup v2")
 		}
+
+		deviceResolver = func(path string) (blockdev.Device, error) {
+			return blockdev.Device{Major: 8, Minor: 16}, nil
+		}
 	})
 
 	JustBeforeEach(func() {
@@ -100,7 +106,7 @@ var _ = Describe("RuncAdapter", func() {
 		identityGlob := func(pattern string) ([]string, error) {
 			return []string{pattern}, nil
 		}
-		runcAdapter = NewRuncAdapter(features, identityGlob, mountSharer.MakeShared, volumeLocker, cgroupsPathForFn)
+		runcAdapter = NewRuncAdapter(features, identityGlob, mountSharer.MakeShared, volumeLocker, cgroupsPathForFn, deviceResolver)
 	})
 
 	AfterEach(func() {
@@ -382,6 +388,70 @@ var _ = Describe("RuncAdapter", func() {
 		})
 	})
 
+	Describe("CheckJobPrerequisites", func() {
+		BeforeEach(func() {
+			procCfg.PersistentDisk = true
+			procCfg.AdditionalVolumes = append(procCfg.AdditionalVolumes, config.Volume{
+				Path:   filepath.Join(systemRoot, "share", "me"),
+				Shared: true,
+			})
+		})
+
+		It("does not change anything on the host", func() {
+			Expect(runcAdapter.CheckJobPrerequisites(bpmCfg, procCfg)).To(Succeed())
+
+			Expect(bpmCfg.PidDir().External()).NotTo(BeADirectory())
+			Expect(bpmCfg.LogDir().External()).NotTo(BeADirectory())
+			Expect(bpmCfg.StoreDir().External()).NotTo(BeADirectory())
+			Expect(filepath.Join(systemRoot, "another", "location")).NotTo(BeADirectory())
+			Expect(mountSharer.sharedMounts).To(BeEmpty())
+
+			dirInfo, err := os.Stat(filepath.Join(systemRoot, "some", "directory"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dirInfo.Mode() & os.ModePerm).To(Equal(os.FileMode(0600)))
+		})
+
+		Context("when the persistent disk directory does not exist", func() {
+			BeforeEach(func() {
+				Expect(os.RemoveAll(filepath.Join(systemRoot, "store"))).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				err := runcAdapter.CheckJobPrerequisites(bpmCfg, procCfg)
+				Expect(err).To(MatchError("requested persistent disk does not exist"))
+			})
+		})
+	})
+
+	Describe("OpenLogFiles", func() {
+		It("opens the log files of the process for appending", func() {
+			Expect(os.MkdirAll(bpmCfg.LogDir().External(), 0700)).To(Succeed())
+			Expect(os.WriteFile(bpmCfg.Stdout().External(), []byte("existing\n"), 0600)).To(Succeed())
+
+			stdout, stderr, err := runcAdapter.OpenLogFiles(bpmCfg, user)
+			Expect(err).NotTo(HaveOccurred())
+			defer stdout.Close()
+			defer stderr.Close()
+
+			_, err = stdout.WriteString("appended\n")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.ReadFile(bpmCfg.Stdout().External())).To(Equal([]byte("existing\nappended\n")))
+			Expect(stderr.Name()).To(Equal(bpmCfg.Stderr().External()))
+		})
+	})
+
+	Describe("ProcessEnvironment", func() {
+		It("returns the environment which the process is run with", func() {
+			procCfg.Env = map[string]string{"FOO": "BAR"}
+
+			env := runcAdapter.ProcessEnvironment(bpmCfg, procCfg)
+			Expect(env).To(ContainElement("FOO=BAR"))
+			Expect(env).To(ContainElement("TMPDIR=/var/vcap/data/example/tmp"))
+			Expect(env).To(ContainElement("HOME=/var/vcap/data/example"))
+		})
+	})
+
 	Describe("BuildSpec", func() {
 		BeforeEach(func() {
 			procCfg = &config.ProcessConfig{
@@ -432,6 +502,8 @@ var _ = Describe("RuncAdapter", func() {
 				"-w",
 				"-s",
 				"--",
+				"/var/vcap/packages/bpm/bin/bpm",
+				"wrap",
 				procCfg.Executable,
 			}, procCfg.Args...)
 
@@ -798,6 +870,54 @@ var _ = Describe("RuncAdapter", func() {
 					})
 				})
 
+				Context("when a swap limit is provided", func() {
+					BeforeEach(func() {
+						swap := "1G"
+						procCfg.Limits.Swap = &swap
+					})
+
+					Context("when the system supports swap", func() {
+						BeforeEach(func() {
+							features.SwapLimitSupported = true
+						})
+
+						It("sets the combined memory and swap limit", func() {
+							spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+							Expect(err).NotTo(HaveOccurred())
+
+							expectedMemoryLimitInBytes, err := bytefmt.ToBytes(expectedMemoryLimit)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(*spec.Linux.Resources.Memory.Limit).To(Equal(int64(expectedMemoryLimitInBytes)))
+							Expect(*spec.Linux.Resources.Memory.Swap).To(Equal(int64(expectedMemoryLimitInBytes + bytefmt.GIGABYTE)))
+						})
+
+						Context("when the swap limit is zero", func() {
+							BeforeEach(func() {
+								swap := "0"
+								procCfg.Limits.Swap = &swap
+							})
+
+							It("sets the swap limit equal to the memory limit", func() {
+								spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+								Expect(err).NotTo(HaveOccurred())
+
+								Expect(*spec.Linux.Resources.Memory.Swap).To(Equal(*spec.Linux.Resources.Memory.Limit))
+							})
+						})
+					})
+
+					Context("when the system does not support swap", func() {
+						BeforeEach(func() {
+							features.SwapLimitSupported = false
+						})
+
+						It("returns an error", func() {
+							_, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+							Expect(err).To(MatchError(ContainSubstring("does not support limiting swap")))
+						})
+					})
+				})
+
 				Context("when the memory limit is invalid", func() {
 					BeforeEach(func() {
 						memoryLimit := "invalid byte value"
@@ -811,6 +931,66 @@ var _ = Describe("RuncAdapter", func() {
 				})
 			})
 
+			Context("MemoryReservation", func() {
+				BeforeEach(func() {
+					reservation := "512M"
+					procCfg.Limits.MemoryReservation = &reservation
+				})
+
+				It("sets the memory reservation on the container", func() {
+					spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(*spec.Linux.Resources.Memory.Reservation).To(Equal(int64(512 * bytefmt.MEGABYTE)))
+					Expect(spec.Linux.Resources.Memory.Limit).To(BeNil())
+				})
+			})
+
+			Context("MemoryHigh", func() {
+				BeforeEach(func() {
+					high := "768M"
+					procCfg.Limits.MemoryHigh = &high
+				})
+
+				Context("when the system uses cgroup v2", func() {
+					BeforeEach(func() {
+						features.CgroupV2 = true
+					})
+
+					It("sets memory.high on the container", func() {
+						spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(spec.Linux.Resources.Unified).To(HaveKeyWithValue("memory.high", fmt.Sprintf("%d", 768*bytefmt.MEGABYTE)))
+					})
+				})
+
+				Context("when the system uses cgroup v1", func() {
+					BeforeEach(func() {
+						features.CgroupV2 = false
+					})
+
+					It("returns an error", func() {
+						_, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+						Expect(err).To(MatchError(ContainSubstring("only supported on cgroup v2")))
+					})
+				})
+			})
+
+			Context("OOMScoreAdj", func() {
+				BeforeEach(func() {
+					adj := 500
+					procCfg.Limits.OOMScoreAdj = &adj
+				})
+
+				It("sets the oom score adjustment of the process", func() {
+					spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(*spec.Process.OOMScoreAdj).To(Equal(500))
+				})
+			})
+
 			Context("OpenFiles", func() {
 				var expectedOpenFilesLimit uint64
 
@@ -855,6 +1035,191 @@ var _ = Describe("RuncAdapter", func() {
 				})
 			})
 
+			Context("CPU", func() {
+				Context("when cpus is provided", func() {
+					BeforeEach(func() {
+						cpus := 1.5
+						procCfg.Limits.CPUs = &cpus
+					})
+
+					It("sets a CPU quota using the default period", func() {
+						spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(spec.Linux.Resources.CPU).NotTo(BeNil())
+						Expect(*spec.Linux.Resources.CPU.Quota).To(Equal(int64(150000)))
+						Expect(*spec.Linux.Resources.CPU.Period).To(Equal(uint64(100000)))
+					})
+
+					Context("and a period is provided", func() {
+						BeforeEach(func() {
+							period := uint64(20000)
+							procCfg.Limits.CPUPeriod = &period
+						})
+
+						It("scales the quota by the period", func() {
+							spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+							Expect(err).NotTo(HaveOccurred())
+
+							Expect(*spec.Linux.Resources.CPU.Quota).To(Equal(int64(30000)))
+							Expect(*spec.Linux.Resources.CPU.Period).To(Equal(uint64(20000)))
+						})
+					})
+				})
+
+				Context("when cpu_quota is provided", func() {
+					BeforeEach(func() {
+						quota := int64(25000)
+						procCfg.Limits.CPUQuota = &quota
+					})
+
+					It("sets the CPU quota", func() {
+						spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(*spec.Linux.Resources.CPU.Quota).To(Equal(int64(25000)))
+						Expect(*spec.Linux.Resources.CPU.Period).To(Equal(uint64(100000)))
+					})
+				})
+
+				Context("when cpu_shares is provided", func() {
+					BeforeEach(func() {
+						shares := uint64(512)
+						procCfg.Limits.CPUShares = &shares
+					})
+
+					It("sets the CPU shares", func() {
+						spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(*spec.Linux.Resources.CPU.Shares).To(Equal(uint64(512)))
+					})
+				})
+
+				Context("when cpu_weight is provided", func() {
+					BeforeEach(func() {
+						weight := uint64(100)
+						procCfg.Limits.CPUWeight = &weight
+					})
+
+					Context("when the system uses cgroup v2", func() {
+						BeforeEach(func() {
+							features.CgroupV2 = true
+						})
+
+						It("sets cpu.weight directly", func() {
+							spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+							Expect(err).NotTo(HaveOccurred())
+
+							Expect(spec.Linux.Resources.Unified).To(HaveKeyWithValue("cpu.weight", "100"))
+							Expect(spec.Linux.Resources.CPU).To(BeNil())
+						})
+					})
+
+					Context("when the system uses cgroup v1", func() {
+						BeforeEach(func() {
+							features.CgroupV2 = false
+						})
+
+						It("converts the weight into shares", func() {
+							spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+							Expect(err).NotTo(HaveOccurred())
+
+							Expect(spec.Linux.Resources.Unified).To(BeEmpty())
+							Expect(*spec.Linux.Resources.CPU.Shares).To(Equal(uint64(2597)))
+						})
+					})
+				})
+
+				Context("when cpuset_cpus is provided", func() {
+					BeforeEach(func() {
+						cpuset := "0-1"
+						procCfg.Limits.CPUSetCPUs = &cpuset
+					})
+
+					It("pins the container to the CPUs", func() {
+						spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(spec.Linux.Resources.CPU.Cpus).To(Equal("0-1"))
+					})
+				})
+			})
+
+			Context("IO", func() {
+				BeforeEach(func() {
+					weight := uint16(300)
+					readBPS := "10M"
+					writeIOPS := uint64(500)
+					procCfg.Limits.IO = &config.IO{
+						Weight: &weight,
+						Devices: []config.IODevice{
+							{Path: "/var/vcap/store", ReadBPS: &readBPS, WriteIOPS: &writeIOPS},
+						},
+					}
+				})
+
+				Context("when the io controller is available", func() {
+					var resolvedPaths []string
+
+					BeforeEach(func() {
+						features.IOControllerSupported = true
+
+						resolvedPaths = nil
+						deviceResolver = func(path string) (blockdev.Device, error) {
+							resolvedPaths = append(resolvedPaths, path)
+							return blockdev.Device{Major: 253, Minor: 2}, nil
+						}
+					})
+
+					It("sets the block io limits on the container", func() {
+						spec, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(resolvedPaths).To(ConsistOf("/var/vcap/store"))
+
+						blkio := spec.Linux.Resources.BlockIO
+						Expect(blkio).NotTo(BeNil())
+						Expect(*blkio.Weight).To(Equal(uint16(300)))
+
+						Expect(blkio.ThrottleReadBpsDevice).To(HaveLen(1))
+						Expect(blkio.ThrottleReadBpsDevice[0].Major).To(Equal(int64(253)))
+						Expect(blkio.ThrottleReadBpsDevice[0].Minor).To(Equal(int64(2)))
+						Expect(blkio.ThrottleReadBpsDevice[0].Rate).To(Equal(uint64(10 * bytefmt.MEGABYTE)))
+
+						Expect(blkio.ThrottleWriteIOPSDevice).To(HaveLen(1))
+						Expect(blkio.ThrottleWriteIOPSDevice[0].Rate).To(Equal(uint64(500)))
+
+						Expect(blkio.ThrottleWriteBpsDevice).To(BeEmpty())
+						Expect(blkio.ThrottleReadIOPSDevice).To(BeEmpty())
+					})
+
+					Context("when the device cannot be resolved", func() {
+						BeforeEach(func() {
+							deviceResolver = func(path string) (blockdev.Device, error) {
+								return blockdev.Device{}, errors.New("not a block device")
+							}
+						})
+
+						It("returns an error", func() {
+							_, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+							Expect(err).To(MatchError(ContainSubstring("/var/vcap/store")))
+						})
+					})
+				})
+
+				Context("when the io controller is not available", func() {
+					BeforeEach(func() {
+						features.IOControllerSupported = false
+					})
+
+					It("returns a clear error", func() {
+						_, err := runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+						Expect(err).To(MatchError(ContainSubstring("controller is not available")))
+					})
+				})
+			})
+
 			Context("Pids", func() {
 				var pidLimit int64
 
@@ -895,7 +1260,7 @@ var _ = Describe("RuncAdapter", func() {
 				identityGlob := func(pattern string) ([]string, error) {
 					return []string{pattern}, nil
 				}
-				runcAdapter = NewRuncAdapter(features, identityGlob, mountSharer.MakeShared, volumeLocker, cgroupsPathForFn)
+				runcAdapter = NewRuncAdapter(features, identityGlob, mountSharer.MakeShared, volumeLocker, cgroupsPathForFn, deviceResolver)
 			})
 
 			It("disables seccomp in the spec", func() {
@@ -961,7 +1326,7 @@ var _ = Describe("RuncAdapter", func() {
 				identityGlob := func(pattern string) ([]string, error) {
 					return []string{pattern}, nil
 				}
-				runcAdapter = NewRuncAdapter(features, identityGlob, mountSharer.MakeShared, volumeLocker, cgroupsPathForFn)
+				runcAdapter = NewRuncAdapter(features, identityGlob, mountSharer.MakeShared, volumeLocker, cgroupsPathForFn, deviceResolver)
 			})
 
 			It("includes seccomp in the spec", func() {
@@ -1111,7 +1476,7 @@ var _ = Describe("RuncAdapter", func() {
 							return []string{pattern}, nil
 						}
 					}
-					runcAdapter = NewRuncAdapter(features, fakeGlob, mountSharer.MakeShared, volumeLocker, cgroupsPathForFn)
+					runcAdapter = NewRuncAdapter(features, fakeGlob, mountSharer.MakeShared, volumeLocker, cgroupsPathForFn, deviceResolver)
 				})
 
 				It("adds volumes for whatever the volume matches", func() {
@@ -1155,7 +1520,7 @@ var _ = Describe("RuncAdapter", func() {
 						fail := func(path string) ([]string, error) {
 							return nil, errors.New("doomed from the start")
 						}
-						runcAdapter = NewRuncAdapter(features, fail, mountSharer.MakeShared, volumeLocker, cgroupsPathForFn)
+						runcAdapter = NewRuncAdapter(features, fail, mountSharer.MakeShared, volumeLocker, cgroupsPathForFn, deviceResolver)
 					})
 
 					It("returns an error", func() {
@@ -16,39 +16,24 @@
 package client
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	specs "github.com/opencontainers/runtime-spec/specs-go"
-)
-
-type Signal int
 
-const (
-	Term Signal = iota
-	Quit
-	Int
+	"bpm/exitstatus"
 )
 
-func (s Signal) String() string {
-	switch s {
-	case Term:
-		return "TERM"
-	case Quit:
-		return "QUIT"
-	case Int:
-		return "INT"
-	default:
-		return "unknown"
-	}
-}
-
 // ContainerState see https://github.com/opencontainers/runc/blob/master/list.go#L24-L45
 type ContainerState struct {
 	// ID is the container ID
@@ -57,8 +42,16 @@ type ContainerState struct {
 	InitProcessPid int `json:"pid"`
 	// Status is the current status of the container, running, paused, ...
 	Status string `json:"status"`
+	// Bundle is the path on the filesystem to the bundle
+	Bundle string `json:"bundle"`
+	// Created is the unix timestamp for the creation time of the container in UTC
+	Created time.Time `json:"created"`
 }
 
+// execKillDelay is how long a command run with ExecCommand is given to exit
+// after its context is cancelled before it is killed.
+const execKillDelay = 1 * time.Second
+
 type RuncClient struct {
 	runcPath string
 	runcRoot string
@@ -90,7 +83,7 @@ func (*RuncClient) CreateBundle(
 		return err
 	}
 
-	f, err := os.OpenFile(filepath.Join(bundlePath, "config.json"), os.O_RDWR|os.O_CREATE, 0600)
+	f, err := os.OpenFile(filepath.Join(bundlePath, "config.json"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		// This is super hard to test as we are root.
 		return err
@@ -102,23 +95,50 @@ func (*RuncClient) CreateBundle(
 	return enc.Encode(&jobSpec)
 }
 
-func (c *RuncClient) RunContainer(pidFilePath, bundlePath, containerID string, detach bool, stdout, stderr io.Writer) (int, error) {
+// BundleSpec returns the spec which the bundle was created with.
+func (*RuncClient) BundleSpec(bundlePath string) (specs.Spec, error) {
+	data, err := os.ReadFile(filepath.Join(bundlePath, "config.json"))
+	if err != nil {
+		return specs.Spec{}, err
+	}
+
+	var spec specs.Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return specs.Spec{}, err
+	}
+
+	return spec, nil
+}
+
+// RunContainer runs the container and, unless it is detached, waits for it to
+// exit. The exit status follows shell conventions if runc was killed by a
+// signal. Any extra files are passed on to the process of the container as
+// file descriptors starting at 3.
+func (c *RuncClient) RunContainer(pidFilePath, bundlePath, containerID string, detach bool, stdout, stderr io.Writer, extraFiles ...*os.File) (int, error) {
 	args := []string{
 		"--bundle", bundlePath,
 	}
+	if len(extraFiles) > 0 {
+		args = append(args, "--preserve-fds", strconv.Itoa(len(extraFiles)))
+	}
 	if detach {
 		args = append(args, "--pid-file", pidFilePath)
 		args = append(args, "--detach")
+	} else {
+		// The container is kept once it has exited so that its cgroup can
+		// be inspected. The caller must delete it.
+		args = append(args, "--keep")
 	}
 	args = append(args, containerID)
 
 	runcCmd := c.buildCmd("run", args...)
 	runcCmd.Stdout = stdout
 	runcCmd.Stderr = stderr
+	runcCmd.ExtraFiles = extraFiles
 
 	if err := runcCmd.Run(); err != nil {
 		if status, ok := runcCmd.ProcessState.Sys().(syscall.WaitStatus); ok {
-			return status.ExitStatus(), err
+			return exitstatus.FromWaitStatus(status), err
 		}
 
 		// If we can't get the exit status for some reason then make
@@ -129,21 +149,74 @@ func (c *RuncClient) RunContainer(pidFilePath, bundlePath, containerID string, d
 	return 0, nil
 }
 
-// Exec assumes you are launching an interactive shell.
-// We should improve the interface to mirror `runc exec` more generally.
-func (c *RuncClient) Exec(containerID, command string, stdin io.Reader, stdout, stderr io.Writer) error {
-	runcCmd := c.buildCmd(
-		"exec",
-		"--tty",
-		"--env", fmt.Sprintf("TERM=%s", os.Getenv("TERM")),
-		containerID,
-		command,
-	)
+// ExecOptions configures a command run with Exec. They mirror the flags of
+// `runc exec`.
+type ExecOptions struct {
+	// TTY allocates a pseudo-terminal for the command. TERM is passed through
+	// from the environment of bpm when it is set.
+	TTY bool
+
+	// Env holds additional environment variables in KEY=VALUE form.
+	Env []string
 
+	// User is the UID[:GID] to run the command as. The user of the
+	// container's process is used when it is empty.
+	User string
+
+	// Cwd is the working directory of the command. The working directory of
+	// the container's process is used when it is empty.
+	Cwd string
+}
+
+// Exec runs a command in the container and waits for it to exit. The exit
+// status follows shell conventions if the command was killed by a signal.
+func (c *RuncClient) Exec(containerID string, args []string, opts ExecOptions, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	var execArgs []string
+	if opts.TTY {
+		execArgs = append(execArgs, "--tty", "--env", fmt.Sprintf("TERM=%s", os.Getenv("TERM")))
+	}
+	for _, e := range opts.Env {
+		execArgs = append(execArgs, "--env", e)
+	}
+	if opts.User != "" {
+		execArgs = append(execArgs, "--user", opts.User)
+	}
+	if opts.Cwd != "" {
+		execArgs = append(execArgs, "--cwd", opts.Cwd)
+	}
+	execArgs = append(execArgs, containerID)
+	execArgs = append(execArgs, args...)
+
+	runcCmd := c.buildCmd("exec", execArgs...)
 	runcCmd.Stdin = stdin
 	runcCmd.Stdout = stdout
 	runcCmd.Stderr = stderr
 
+	if err := runcCmd.Run(); err != nil {
+		// runc was never started if there is no process state.
+		if runcCmd.ProcessState != nil {
+			if status, ok := runcCmd.ProcessState.Sys().(syscall.WaitStatus); ok {
+				return exitstatus.FromWaitStatus(status), err
+			}
+		}
+
+		return 1, err
+	}
+
+	return 0, nil
+}
+
+// ExecCommand runs a command in the container without a TTY and waits for it
+// to exit. If the context is cancelled then the command is sent a SIGTERM
+// (which runc forwards to the process in the container) and is killed if it
+// has not exited shortly afterwards.
+func (c *RuncClient) ExecCommand(ctx context.Context, containerID string, args []string, stdout, stderr io.Writer) error {
+	runcCmd := exec.CommandContext(ctx, c.runcPath, c.buildArgs("exec", append([]string{containerID}, args...)...)...)
+	runcCmd.Stdout = stdout
+	runcCmd.Stderr = stderr
+	runcCmd.Cancel = func() error { return runcCmd.Process.Signal(syscall.SIGTERM) }
+	runcCmd.WaitDelay = execKillDelay
+
 	return runcCmd.Run()
 }
 
@@ -188,6 +261,25 @@ func decodeContainerStateErr(b []byte, err error) error {
 	return err
 }
 
+// CgroupPaths returns the cgroup directories of a container keyed by
+// controller. This is read from the state runc keeps for the container and so
+// is available until the container is deleted, even if it has stopped.
+func (c *RuncClient) CgroupPaths(containerID string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(c.runcRoot, containerID, "state.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var state struct {
+		CgroupPaths map[string]string `json:"cgroup_paths"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	return state.CgroupPaths, nil
+}
+
 func (c *RuncClient) ListContainers() ([]ContainerState, error) {
 	runcCmd := c.buildCmd(
 		"list",
@@ -212,7 +304,42 @@ func (c *RuncClient) SignalContainer(containerID string, signal Signal) error {
 	runcCmd := c.buildCmd(
 		"kill",
 		containerID,
-		signal.String(),
+		strconv.Itoa(int(signal.Number())),
+	)
+
+	return runcCmd.Run()
+}
+
+// UpdateContainer applies the resource limits to the running container. Only
+// the limits which are set are changed.
+func (c *RuncClient) UpdateContainer(containerID string, resources *specs.LinuxResources) error {
+	data, err := json.Marshal(resources)
+	if err != nil {
+		return err
+	}
+
+	runcCmd := c.buildCmd(
+		"update",
+		"--resources", "-",
+		containerID,
+	)
+	runcCmd.Stdin = bytes.NewReader(data)
+
+	if output, err := runcCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// SignalAllProcesses sends the signal to every process in the container
+// rather than only to its init process.
+func (c *RuncClient) SignalAllProcesses(containerID string, signal Signal) error {
+	runcCmd := c.buildCmd(
+		"kill",
+		"--all",
+		containerID,
+		strconv.Itoa(int(signal.Number())),
 	)
 
 	return runcCmd.Run()
@@ -233,11 +360,14 @@ func (*RuncClient) DestroyBundle(bundlePath string) error {
 }
 
 func (c *RuncClient) buildCmd(command string, extra ...string) *exec.Cmd {
+	return exec.Command(c.runcPath, c.buildArgs(command, extra...)...)
+}
+
+func (c *RuncClient) buildArgs(command string, extra ...string) []string {
 	args := []string{"--root", c.runcRoot}
 	if c.inSystemd {
 		args = append(args, "--systemd-cgroup")
 	}
 	args = append(args, command)
-	args = append(args, extra...)
-	return exec.Command(c.runcPath, args...)
+	return append(args, extra...)
 }
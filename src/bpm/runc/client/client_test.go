@@ -16,10 +16,15 @@
 package client_test
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -108,6 +113,16 @@ var _ = Describe("RuncClient", func() {
 			Expect(configData).To(MatchJSON(expectedConfigData))
 		})
 
+		It("can read back the spec which the bundle was created with", func() {
+			jobSpec.Process = &specs.Process{Args: []string{"/bin/sleep", "100"}}
+			err := runcClient.CreateBundle(bundlePath, jobSpec, user)
+			Expect(err).ToNot(HaveOccurred())
+
+			spec, err := runcClient.BundleSpec(bundlePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec).To(Equal(jobSpec))
+		})
+
 		Context("when creating the bundle directory fails", func() {
 			BeforeEach(func() {
 				_, err := os.Create(bundlePath)
@@ -251,6 +266,232 @@ fi
 		})
 	})
 
+	Describe("RunContainer", func() {
+		var fakeRuncPath string
+
+		BeforeEach(func() {
+			fakeRuncPath = filepath.Join(GinkgoT().TempDir(), "fakeRunc")
+			contents := []byte(`#!/bin/sh
+echo "$@"
+case "$7" in
+  exits) exit 3 ;;
+  killed) kill -KILL $$ ;;
+esac
+`)
+			Expect(os.WriteFile(fakeRuncPath, contents, 0700)).To(Succeed())
+
+			runcClient = client.NewRuncClient(fakeRuncPath, "/path/to/things", false)
+		})
+
+		It("keeps a container run in the foreground once it exits", func() {
+			var stdout bytes.Buffer
+			status, err := runcClient.RunContainer("/pidfile", "/bundle", "succeeds", false, &stdout, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(0))
+			Expect(stdout.String()).To(Equal("--root /path/to/things run --bundle /bundle --keep succeeds\n"))
+		})
+
+		It("passes any extra files on to the container", func() {
+			extraFile, err := os.Create(filepath.Join(GinkgoT().TempDir(), "extra"))
+			Expect(err).NotTo(HaveOccurred())
+			defer extraFile.Close() //nolint:errcheck
+
+			var stdout bytes.Buffer
+			_, err = runcClient.RunContainer("/pidfile", "/bundle", "succeeds", true, &stdout, GinkgoWriter, extraFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout.String()).To(Equal("--root /path/to/things run --bundle /bundle --preserve-fds 1 --pid-file /pidfile --detach succeeds\n"))
+		})
+
+		It("returns the exit status of the container", func() {
+			status, err := runcClient.RunContainer("/pidfile", "/bundle", "exits", false, GinkgoWriter, GinkgoWriter)
+			Expect(err).To(HaveOccurred())
+			Expect(status).To(Equal(3))
+		})
+
+		It("returns 128 plus the signal if it is killed by a signal", func() {
+			status, err := runcClient.RunContainer("/pidfile", "/bundle", "killed", false, GinkgoWriter, GinkgoWriter)
+			Expect(err).To(HaveOccurred())
+			Expect(status).To(Equal(137))
+		})
+	})
+
+	Describe("CgroupPaths", func() {
+		var runcRoot string
+
+		BeforeEach(func() {
+			runcRoot = GinkgoT().TempDir()
+			runcClient = client.NewRuncClient("/unused/runc", runcRoot, false)
+		})
+
+		It("reads the cgroup paths from the state of the container", func() {
+			Expect(os.MkdirAll(filepath.Join(runcRoot, "job"), 0700)).To(Succeed())
+			Expect(os.WriteFile(
+				filepath.Join(runcRoot, "job", "state.json"),
+				[]byte(`{"id":"job","cgroup_paths":{"":"/sys/fs/cgroup/bpm/job"}}`),
+				0600,
+			)).To(Succeed())
+
+			paths, err := runcClient.CgroupPaths("job")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal(map[string]string{"": "/sys/fs/cgroup/bpm/job"}))
+		})
+
+		It("returns a not exist error if the container does not exist", func() {
+			_, err := runcClient.CgroupPaths("missing")
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("Exec", func() {
+		var fakeRuncPath string
+
+		BeforeEach(func() {
+			fakeRuncPath = filepath.Join(GinkgoT().TempDir(), "fakeRunc")
+			contents := []byte(`#!/bin/sh
+echo "$@"
+cat
+for arg in "$@"; do
+  if [ "$arg" = "fail" ]; then
+    exit 3
+  fi
+done
+`)
+
+			err := os.WriteFile(fakeRuncPath, contents, 0700)
+			Expect(err).NotTo(HaveOccurred())
+
+			runcClient = client.NewRuncClient(fakeRuncPath, "/path/to/things", false)
+		})
+
+		It("runs the command in the container without a tty", func() {
+			var stdout bytes.Buffer
+			status, err := runcClient.Exec("foo", []string{"cmd", "--flag"}, client.ExecOptions{}, strings.NewReader("input\n"), &stdout, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(0))
+			Expect(stdout.String()).To(Equal("--root /path/to/things exec foo cmd --flag\ninput\n"))
+		})
+
+		It("passes the options to runc", func() {
+			var stdout bytes.Buffer
+			opts := client.ExecOptions{
+				TTY:  true,
+				Env:  []string{"FOO=bar"},
+				User: "1000:1000",
+				Cwd:  "/var/vcap/jobs/foo",
+			}
+			_, err := runcClient.Exec("foo", []string{"cmd"}, opts, strings.NewReader(""), &stdout, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout.String()).To(Equal(fmt.Sprintf(
+				"--root /path/to/things exec --tty --env TERM=%s --env FOO=bar --user 1000:1000 --cwd /var/vcap/jobs/foo foo cmd\n",
+				os.Getenv("TERM"),
+			)))
+		})
+
+		It("returns the exit status of the command", func() {
+			status, err := runcClient.Exec("foo", []string{"fail"}, client.ExecOptions{}, strings.NewReader(""), GinkgoWriter, GinkgoWriter)
+			Expect(err).To(HaveOccurred())
+			Expect(status).To(Equal(3))
+		})
+	})
+
+	Describe("UpdateContainer", func() {
+		var (
+			tempDir      string
+			fakeRuncPath string
+		)
+
+		BeforeEach(func() {
+			tempDir = GinkgoT().TempDir()
+			fakeRuncPath = filepath.Join(tempDir, "fakeRunc")
+			contents := []byte(fmt.Sprintf(`#!/bin/sh
+echo "$@" > %[1]s/args
+cat > %[1]s/stdin
+if [ "$6" = "fail" ]; then
+  echo "container not running" 1>&2
+  exit 1
+fi
+`, tempDir))
+
+			err := os.WriteFile(fakeRuncPath, contents, 0700)
+			Expect(err).NotTo(HaveOccurred())
+
+			runcClient = client.NewRuncClient(fakeRuncPath, "/path/to/things", false)
+		})
+
+		It("passes the resources to runc update", func() {
+			limit := int64(1024)
+			err := runcClient.UpdateContainer("foo", &specs.LinuxResources{Memory: &specs.LinuxMemory{Limit: &limit}})
+			Expect(err).NotTo(HaveOccurred())
+
+			args, err := os.ReadFile(filepath.Join(tempDir, "args"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(args)).To(Equal("--root /path/to/things update --resources - foo\n"))
+
+			stdin, err := os.ReadFile(filepath.Join(tempDir, "stdin"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdin).To(MatchJSON(`{"memory":{"limit":1024}}`))
+		})
+
+		It("returns the output of runc if it fails", func() {
+			err := runcClient.UpdateContainer("fail", &specs.LinuxResources{})
+			Expect(err).To(MatchError(ContainSubstring("container not running")))
+		})
+	})
+
+	Describe("ExecCommand", func() {
+		var (
+			tempDir      string
+			fakeRuncPath string
+		)
+
+		BeforeEach(func() {
+			var err error
+			tempDir, err = os.MkdirTemp("", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeRuncPath = filepath.Join(tempDir, "fakeRunc")
+			contents := []byte(`#!/bin/sh
+echo "$@"
+if [ "$5" = "sleep" ]; then
+  exec sleep 10
+fi
+[ "$5" = "succeed" ]
+`)
+
+			err = os.WriteFile(fakeRuncPath, contents, 0700)
+			Expect(err).NotTo(HaveOccurred())
+
+			runcClient = client.NewRuncClient(fakeRuncPath, "/path/to/things", false)
+		})
+
+		AfterEach(func() {
+			err := os.RemoveAll(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("runs the command in the container without a tty", func() {
+			var stdout bytes.Buffer
+			err := runcClient.ExecCommand(context.Background(), "foo", []string{"succeed", "--flag"}, &stdout, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdout.String()).To(Equal("--root /path/to/things exec foo succeed --flag\n"))
+		})
+
+		It("returns an error if the command fails", func() {
+			err := runcClient.ExecCommand(context.Background(), "foo", []string{"fail"}, GinkgoWriter, GinkgoWriter)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("stops the command when the context is cancelled", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			err := runcClient.ExecCommand(ctx, "foo", []string{"sleep"}, GinkgoWriter, GinkgoWriter)
+			Expect(err).To(HaveOccurred())
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+	})
+
 	Describe("ContainerState", func() {
 		var (
 			tempDir      string
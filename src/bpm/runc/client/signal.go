@@ -0,0 +1,127 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+type Signal int
+
+const (
+	Term Signal = iota
+	Quit
+	Int
+	Hup
+	Kill
+	Usr1
+	Usr2
+	Abrt
+	Alrm
+	Bus
+	Chld
+	Cont
+	Fpe
+	Ill
+	Pipe
+	Poll
+	Prof
+	Segv
+	Stop
+	Sys
+	Trap
+	Tstp
+	Ttin
+	Ttou
+	Urg
+	Vtalrm
+	Xcpu
+	Xfsz
+	Winch
+)
+
+var signals = []struct {
+	signal Signal
+	name   string
+	number syscall.Signal
+}{
+	{Term, "TERM", syscall.SIGTERM},
+	{Quit, "QUIT", syscall.SIGQUIT},
+	{Int, "INT", syscall.SIGINT},
+	{Hup, "HUP", syscall.SIGHUP},
+	{Kill, "KILL", syscall.SIGKILL},
+	{Usr1, "USR1", syscall.SIGUSR1},
+	{Usr2, "USR2", syscall.SIGUSR2},
+	{Abrt, "ABRT", syscall.SIGABRT},
+	{Alrm, "ALRM", syscall.SIGALRM},
+	{Bus, "BUS", syscall.SIGBUS},
+	{Chld, "CHLD", syscall.SIGCHLD},
+	{Cont, "CONT", syscall.SIGCONT},
+	{Fpe, "FPE", syscall.SIGFPE},
+	{Ill, "ILL", syscall.SIGILL},
+	{Pipe, "PIPE", syscall.SIGPIPE},
+	{Poll, "POLL", syscall.SIGIO},
+	{Prof, "PROF", syscall.SIGPROF},
+	{Segv, "SEGV", syscall.SIGSEGV},
+	{Stop, "STOP", syscall.SIGSTOP},
+	{Sys, "SYS", syscall.SIGSYS},
+	{Trap, "TRAP", syscall.SIGTRAP},
+	{Tstp, "TSTP", syscall.SIGTSTP},
+	{Ttin, "TTIN", syscall.SIGTTIN},
+	{Ttou, "TTOU", syscall.SIGTTOU},
+	{Urg, "URG", syscall.SIGURG},
+	{Vtalrm, "VTALRM", syscall.SIGVTALRM},
+	{Xcpu, "XCPU", syscall.SIGXCPU},
+	{Xfsz, "XFSZ", syscall.SIGXFSZ},
+	{Winch, "WINCH", syscall.SIGWINCH},
+}
+
+// ParseSignal converts a signal name such as "TERM" or "SIGTERM" into a
+// Signal. Names are case-insensitive.
+func ParseSignal(name string) (Signal, error) {
+	name = strings.TrimPrefix(strings.ToUpper(name), "SIG")
+	for _, s := range signals {
+		if s.name == name {
+			return s.signal, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown signal: %q", name)
+}
+
+func (s Signal) String() string {
+	for _, sig := range signals {
+		if sig.signal == s {
+			return sig.name
+		}
+	}
+
+	return "unknown"
+}
+
+// Number returns the signal number which is sent to the process. runc is
+// given the number rather than the name as it does not accept every alias.
+func (s Signal) Number() syscall.Signal {
+	for _, sig := range signals {
+		if sig.signal == s {
+			return sig.number
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package client_test
+
+import (
+	"syscall"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bpm/runc/client"
+)
+
+var _ = Describe("Signal", func() {
+	Describe("ParseSignal", func() {
+		It("parses signal names with or without the SIG prefix", func() {
+			Expect(client.ParseSignal("TERM")).To(Equal(client.Term))
+			Expect(client.ParseSignal("SIGUSR1")).To(Equal(client.Usr1))
+			Expect(client.ParseSignal("kill")).To(Equal(client.Kill))
+		})
+
+		It("returns an error for unknown signals", func() {
+			_, err := client.ParseSignal("FOO")
+			Expect(err).To(MatchError(`unknown signal: "FOO"`))
+		})
+	})
+
+	It("keeps the values of the original signals", func() {
+		Expect(int(client.Term)).To(Equal(0))
+		Expect(int(client.Quit)).To(Equal(1))
+		Expect(int(client.Int)).To(Equal(2))
+	})
+
+	It("round trips every signal through its name", func() {
+		for s := client.Term; s <= client.Winch; s++ {
+			parsed, err := client.ParseSignal(s.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(s))
+			Expect(s.Number()).NotTo(BeZero())
+		}
+	})
+
+	It("maps signals to their numbers", func() {
+		Expect(client.Term.Number()).To(Equal(syscall.SIGTERM))
+		Expect(client.Poll.Number()).To(Equal(syscall.SIGIO))
+		Expect(client.Signal(-1).String()).To(Equal("unknown"))
+	})
+})
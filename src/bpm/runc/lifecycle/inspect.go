@@ -0,0 +1,208 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"fmt"
+	"strconv"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"bpm/config"
+	"bpm/jobid"
+	"bpm/models"
+)
+
+// ProcessSpec returns the spec which the container of the process was created
+// with.
+func (j *RuncLifecycle) ProcessSpec(cfg *config.BPMConfig) (specs.Spec, error) {
+	state, err := j.runcClient.ContainerState(cfg.ContainerID())
+	if err != nil {
+		return specs.Spec{}, err
+	}
+
+	if state == nil {
+		return specs.Spec{}, isNotExistError
+	}
+
+	return j.runcClient.BundleSpec(cfg.BundlePath())
+}
+
+// InspectProcess describes the container of the process from the spec it was
+// created with and its current state.
+func (j *RuncLifecycle) InspectProcess(cfg *config.BPMConfig) (*models.ProcessInspection, error) {
+	state, err := j.runcClient.ContainerState(cfg.ContainerID())
+	if err != nil {
+		return nil, err
+	}
+
+	if state == nil {
+		return nil, isNotExistError
+	}
+
+	spec, err := j.runcClient.BundleSpec(cfg.BundlePath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %s", err)
+	}
+
+	name, err := jobid.Decode(cfg.ContainerID())
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := &models.ProcessInspection{
+		Name:    name,
+		Job:     cfg.JobName(),
+		Process: cfg.ProcName(),
+		Status:  containerStateToString(state.Status),
+		Pid:     state.Pid,
+		Bundle:  cfg.BundlePath(),
+	}
+
+	// The cgroup paths are only known while runc still has the container.
+	if paths, err := j.runcClient.CgroupPaths(cfg.ContainerID()); err == nil {
+		inspection.CgroupPaths = paths
+	}
+
+	if p := spec.Process; p != nil {
+		if len(p.Args) > 0 {
+			inspection.Executable = p.Args[0]
+			inspection.Args = p.Args[1:]
+		}
+		inspection.Env = p.Env
+		inspection.User = fmt.Sprintf("%d:%d", p.User.UID, p.User.GID)
+		inspection.WorkDir = p.Cwd
+
+		if p.Capabilities != nil {
+			inspection.Capabilities = p.Capabilities.Bounding
+		}
+		inspection.Limits.OOMScoreAdj = p.OOMScoreAdj
+
+		for _, rlimit := range p.Rlimits {
+			limit := rlimit.Hard
+			switch rlimit.Type {
+			case "RLIMIT_NOFILE":
+				inspection.Limits.OpenFiles = &limit
+			case "RLIMIT_CORE":
+				inspection.Limits.CoreFileSize = &limit
+			}
+		}
+	}
+
+	for _, m := range spec.Mounts {
+		inspection.Mounts = append(inspection.Mounts, models.MountInspection{
+			Destination:     m.Destination,
+			Source:          m.Source,
+			Type:            m.Type,
+			Writable:        !hasOption(m.Options, "ro"),
+			AllowExecutions: !hasOption(m.Options, "noexec"),
+		})
+	}
+
+	if linux := spec.Linux; linux != nil {
+		for _, ns := range linux.Namespaces {
+			inspection.Namespaces = append(inspection.Namespaces, string(ns.Type))
+		}
+		inspection.Seccomp = linux.Seccomp != nil
+
+		if r := linux.Resources; r != nil {
+			if r.Memory != nil {
+				inspection.Limits.MemoryBytes = r.Memory.Limit
+				inspection.Limits.MemoryReservationBytes = r.Memory.Reservation
+				inspection.Limits.SwapBytes = swapBytes(r.Memory)
+			}
+			if high, err := strconv.ParseInt(r.Unified["memory.high"], 10, 64); err == nil {
+				inspection.Limits.MemoryHighBytes = &high
+			}
+			if r.CPU != nil {
+				inspection.Limits.CPUShares = r.CPU.Shares
+				inspection.Limits.CPUQuota = r.CPU.Quota
+				inspection.Limits.CPUPeriod = r.CPU.Period
+				inspection.Limits.CPUSet = r.CPU.Cpus
+			}
+			if r.Pids != nil {
+				inspection.Limits.Processes = r.Pids.Limit
+			}
+			if r.BlockIO != nil {
+				inspection.Limits.IOWeight = r.BlockIO.Weight
+				inspection.Limits.IODevices = ioDevices(r.BlockIO)
+			}
+		}
+	}
+
+	return inspection, nil
+}
+
+// swapBytes returns how much swap the container may use on top of its memory.
+// The spec limits memory and swap together.
+func swapBytes(memory *specs.LinuxMemory) *int64 {
+	if memory.Swap == nil {
+		return nil
+	}
+
+	swap := *memory.Swap
+	if swap >= 0 && memory.Limit != nil && *memory.Limit >= 0 {
+		swap -= *memory.Limit
+	}
+
+	return &swap
+}
+
+// ioDevices gathers the throttling of each block device, which the spec lists
+// separately for each kind of throttling.
+func ioDevices(blkio *specs.LinuxBlockIO) []models.IODeviceInspection {
+	var devices []models.IODeviceInspection
+	device := func(d specs.LinuxThrottleDevice) *models.IODeviceInspection {
+		name := fmt.Sprintf("%d:%d", d.Major, d.Minor)
+		for i := range devices {
+			if devices[i].Device == name {
+				return &devices[i]
+			}
+		}
+
+		devices = append(devices, models.IODeviceInspection{Device: name})
+		return &devices[len(devices)-1]
+	}
+
+	for _, d := range blkio.ThrottleReadBpsDevice {
+		rate := d.Rate
+		device(d).ReadBPS = &rate
+	}
+	for _, d := range blkio.ThrottleWriteBpsDevice {
+		rate := d.Rate
+		device(d).WriteBPS = &rate
+	}
+	for _, d := range blkio.ThrottleReadIOPSDevice {
+		rate := d.Rate
+		device(d).ReadIOPS = &rate
+	}
+	for _, d := range blkio.ThrottleWriteIOPSDevice {
+		rate := d.Rate
+		device(d).WriteIOPS = &rate
+	}
+
+	return devices
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
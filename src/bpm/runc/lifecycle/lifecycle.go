@@ -16,11 +16,16 @@
 package lifecycle
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	specs "github.com/opencontainers/runtime-spec/specs-go"
@@ -29,6 +34,7 @@ import (
 	"code.cloudfoundry.org/lager/v3"
 
 	"bpm/config"
+	"bpm/history"
 	"bpm/models"
 	"bpm/runc/client"
 	"bpm/usertools"
@@ -54,6 +60,28 @@ func IsNotExist(err error) bool {
 	return err == isNotExistError
 }
 
+// hookError is returned when a hook fails or does not complete in time.
+type hookError struct{ error }
+
+// IsHookFailure returns whether the error was caused by a failing hook.
+func IsHookFailure(err error) bool {
+	var herr hookError
+	return errors.As(err, &herr)
+}
+
+// setupError is returned by RunProcess when the process could not be run
+// because its container could not be set up.
+type setupError struct{ error }
+
+func (e setupError) Unwrap() error { return e.error }
+
+// IsSetupFailure returns whether the error returned by RunProcess means that
+// the process was never run.
+func IsSetupFailure(err error) bool {
+	var serr setupError
+	return errors.As(err, &serr)
+}
+
 type UserFinder interface {
 	Lookup(username string) (specs.User, error)
 }
@@ -64,16 +92,24 @@ type CommandRunner interface {
 
 type RuncAdapter interface {
 	CreateJobPrerequisites(bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig, user specs.User) (*os.File, *os.File, error)
+	CheckJobPrerequisites(bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error
 	BuildSpec(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig, user specs.User) (specs.Spec, error)
+	OpenLogFiles(bpmCfg *config.BPMConfig, user specs.User) (*os.File, *os.File, error)
+	ProcessEnvironment(bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) []string
 }
 
 type RuncClient interface {
 	CreateBundle(bundlePath string, jobSpec specs.Spec, user specs.User) error
-	RunContainer(pidFilePath, bundlePath, containerID string, detach bool, stdout, stderr io.Writer) (int, error)
-	Exec(containerID, command string, stdin io.Reader, stdout, stderr io.Writer) error
+	BundleSpec(bundlePath string) (specs.Spec, error)
+	RunContainer(pidFilePath, bundlePath, containerID string, detach bool, stdout, stderr io.Writer, extraFiles ...*os.File) (int, error)
+	Exec(containerID string, args []string, opts client.ExecOptions, stdin io.Reader, stdout, stderr io.Writer) (int, error)
+	ExecCommand(ctx context.Context, containerID string, args []string, stdout, stderr io.Writer) error
 	ContainerState(containerID string) (*specs.State, error)
+	CgroupPaths(containerID string) (map[string]string, error)
 	ListContainers() ([]client.ContainerState, error)
 	SignalContainer(containerID string, signal client.Signal) error
+	SignalAllProcesses(containerID string, signal client.Signal) error
+	UpdateContainer(containerID string, resources *specs.LinuxResources) error
 	DeleteContainer(containerID string) error
 	DestroyBundle(bundlePath string) error
 }
@@ -117,6 +153,14 @@ func (j *RuncLifecycle) StartProcess(logger lager.Logger, bpmCfg *config.BPMConf
 	defer stdout.Close() //nolint:errcheck
 	defer stderr.Close() //nolint:errcheck
 
+	// Nothing is left waiting for a process started in the background so it
+	// writes its own exit status for when its container is removed.
+	exitStatusFile, err := createExitStatusFile(bpmCfg)
+	if err != nil {
+		return err
+	}
+	defer exitStatusFile.Close() //nolint:errcheck
+
 	logger.Info("running-container")
 	_, err = j.runcClient.RunContainer(
 		bpmCfg.PidFile().External(),
@@ -125,9 +169,26 @@ func (j *RuncLifecycle) StartProcess(logger lager.Logger, bpmCfg *config.BPMConf
 		true,
 		stdout,
 		stderr,
+		exitStatusFile,
 	)
+	if err != nil {
+		return err
+	}
+	j.recordStart(logger, bpmCfg)
+
+	if procCfg.Hooks != nil {
+		err := j.runHook(logger, "post-start", procCfg.Hooks.PostStart, config.DefaultHookTimeout, bpmCfg, procCfg, stdout, stderr)
+		if err != nil {
+			// The start has failed so the process should not be left running
+			// where it would be mistaken for a successful start.
+			if rerr := j.RemoveProcess(logger, bpmCfg); rerr != nil {
+				logger.Error("failed-to-cleanup", rerr)
+			}
+			return err
+		}
+	}
 
-	return err
+	return nil
 }
 
 func (j *RuncLifecycle) RunProcess(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) (int, error) {
@@ -137,13 +198,14 @@ func (j *RuncLifecycle) RunProcess(logger lager.Logger, bpmCfg *config.BPMConfig
 
 	stdout, stderr, err := j.setupProcess(logger, bpmCfg, procCfg)
 	if err != nil {
-		return 0, err
+		return 0, setupError{err}
 	}
 	defer stdout.Close() //nolint:errcheck
 	defer stderr.Close() //nolint:errcheck
 
 	logger.Info("running-container")
-	return j.runcClient.RunContainer(
+	j.recordStart(logger, bpmCfg)
+	status, err := j.runcClient.RunContainer(
 		bpmCfg.PidFile().External(),
 		bpmCfg.BundlePath(),
 		bpmCfg.ContainerID(),
@@ -151,6 +213,20 @@ func (j *RuncLifecycle) RunProcess(logger lager.Logger, bpmCfg *config.BPMConfig
 		io.MultiWriter(stdout, os.Stdout),
 		io.MultiWriter(stderr, os.Stderr),
 	)
+
+	oomKilled := j.checkOOMKilled(logger, bpmCfg, stderr)
+	j.recordExit(logger, bpmCfg, &status, oomKilled)
+
+	logger.Info("deleting-container")
+	if derr := j.runcClient.DeleteContainer(bpmCfg.ContainerID()); derr != nil {
+		logger.Error("failed-to-delete-container", derr)
+	}
+
+	if oomKilled && status != 0 {
+		return status, oomKilledError
+	}
+
+	return status, err
 }
 
 func (j *RuncLifecycle) setupProcess(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) (io.WriteCloser, io.WriteCloser, error) {
@@ -178,20 +254,189 @@ func (j *RuncLifecycle) setupProcess(logger lager.Logger, bpmCfg *config.BPMConf
 	}
 
 	if procCfg.Hooks != nil {
-		preStartCmd := exec.Command(procCfg.Hooks.PreStart)
-		preStartCmd.Env = spec.Process.Env
-		preStartCmd.Stdout = stdout
-		preStartCmd.Stderr = stderr
-
-		err := j.commandRunner.Run(preStartCmd)
+		err := j.runHook(logger, "prestart", procCfg.Hooks.PreStart, 0, bpmCfg, procCfg, stdout, stderr)
 		if err != nil {
-			return nil, nil, fmt.Errorf("prestart hook failed: %s", err.Error())
+			return nil, nil, err
 		}
 	}
 
 	return stdout, stderr, nil
 }
 
+// runHook runs the hook if it has been configured, either on the host or in
+// its own container. The hook is killed if it is still running after its
+// timeout (or defaultTimeout if it does not have one).
+func (j *RuncLifecycle) runHook(
+	logger lager.Logger,
+	name string,
+	hook config.Hook,
+	defaultTimeout time.Duration,
+	bpmCfg *config.BPMConfig,
+	procCfg *config.ProcessConfig,
+	stdout, stderr io.Writer,
+) error {
+	if hook.Path == "" {
+		return nil
+	}
+
+	logger = logger.Session("hook", lager.Data{
+		"hook":             name,
+		"path":             hook.Path,
+		"run-in-container": hook.RunInContainer,
+	})
+	logger.Info("running")
+
+	timeout := hook.ParseTimeout(defaultTimeout)
+
+	var timedOut bool
+	var err error
+	if hook.RunInContainer {
+		timedOut, err = j.runHookInContainer(logger, name, hook, timeout, bpmCfg, procCfg, stdout, stderr)
+	} else {
+		env := j.runcAdapter.ProcessEnvironment(bpmCfg, procCfg)
+		timedOut, err = j.runHookOnHost(hook, timeout, env, stdout, stderr)
+	}
+
+	if err != nil {
+		logger.Error("failed", err)
+		if timedOut {
+			return hookError{fmt.Errorf("%s hook did not complete within %s", name, timeout)}
+		}
+		return hookError{fmt.Errorf("%s hook failed: %s", name, err.Error())}
+	}
+
+	logger.Info("complete")
+	return nil
+}
+
+func (j *RuncLifecycle) runHookOnHost(hook config.Hook, timeout time.Duration, env []string, stdout, stderr io.Writer) (bool, error) {
+	var ctx context.Context
+	cmd := exec.Command(hook.Path)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		cmd = exec.CommandContext(ctx, hook.Path)
+	}
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := j.commandRunner.Run(cmd)
+	timedOut := ctx != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+
+	return timedOut, err
+}
+
+// runHookInContainer runs the hook in a container of its own which has the
+// same mounts, user, capabilities, and limits as the process.
+func (j *RuncLifecycle) runHookInContainer(
+	logger lager.Logger,
+	name string,
+	hook config.Hook,
+	timeout time.Duration,
+	bpmCfg *config.BPMConfig,
+	procCfg *config.ProcessConfig,
+	stdout, stderr io.Writer,
+) (bool, error) {
+	hookCfg := bpmCfg.ForHook(name)
+	hookProcCfg := *procCfg
+	hookProcCfg.Executable = hook.Path
+	hookProcCfg.Args = nil
+
+	user, err := j.userFinder.Lookup(usertools.VcapUser)
+	if err != nil {
+		return false, err
+	}
+
+	logger.Info("building-spec")
+	spec, err := j.runcAdapter.BuildSpec(logger, hookCfg, &hookProcCfg, user)
+	if err != nil {
+		return false, err
+	}
+
+	// A container may have been left behind if bpm was interrupted while
+	// the hook was last running.
+	_ = j.runcClient.DeleteContainer(hookCfg.ContainerID()) //nolint:errcheck
+
+	logger.Info("creating-bundle")
+	if err := j.runcClient.CreateBundle(hookCfg.BundlePath(), spec, user); err != nil {
+		return false, fmt.Errorf("bundle build failure: %s", err.Error())
+	}
+	defer func() {
+		if err := j.runcClient.DeleteContainer(hookCfg.ContainerID()); err != nil {
+			logger.Error("failed-to-delete-container", err)
+		}
+		if err := j.runcClient.DestroyBundle(hookCfg.BundlePath()); err != nil {
+			logger.Error("failed-to-destroy-bundle", err)
+		}
+	}()
+
+	var timedOut atomic.Bool
+	done := make(chan struct{})
+	defer close(done)
+
+	if timeout > 0 {
+		timer := j.clock.NewTimer(timeout)
+		defer timer.Stop()
+
+		go func() {
+			select {
+			case <-timer.C():
+				timedOut.Store(true)
+				if err := j.runcClient.SignalContainer(hookCfg.ContainerID(), client.Kill); err != nil {
+					logger.Error("failed-to-kill", err)
+				}
+			case <-done:
+			}
+		}()
+	}
+
+	logger.Info("running-container")
+	_, err = j.runcClient.RunContainer(
+		hookCfg.PidFile().External(),
+		hookCfg.BundlePath(),
+		hookCfg.ContainerID(),
+		false,
+		stdout,
+		stderr,
+	)
+
+	return timedOut.Load(), err
+}
+
+// runHookOutsideStart runs a hook which is not part of starting the process
+// (and so does not have the log files and environment already prepared).
+func (j *RuncLifecycle) runHookOutsideStart(logger lager.Logger, name string, hook config.Hook, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+	if hook.Path == "" {
+		return nil
+	}
+
+	user, err := j.userFinder.Lookup(usertools.VcapUser)
+	if err != nil {
+		return err
+	}
+
+	stdout, stderr, err := j.runcAdapter.OpenLogFiles(bpmCfg, user)
+	if err != nil {
+		return fmt.Errorf("failed to open log files: %s", err.Error())
+	}
+	defer stdout.Close() //nolint:errcheck
+	defer stderr.Close() //nolint:errcheck
+
+	return j.runHook(logger, name, hook, config.DefaultHookTimeout, bpmCfg, procCfg, stdout, stderr)
+}
+
+// RunPostStopHook runs the post_stop hook of the process, if it has one. It
+// should be called once the process has been stopped and removed.
+func (j *RuncLifecycle) RunPostStopHook(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+	if procCfg.Hooks == nil {
+		return nil
+	}
+
+	return j.runHookOutsideStart(logger, "post-stop", procCfg.Hooks.PostStop, bpmCfg, procCfg)
+}
+
 func (j *RuncLifecycle) StatProcess(cfg *config.BPMConfig) (*models.Process, error) {
 	container, err := j.runcClient.ContainerState(cfg.ContainerID())
 	if err != nil {
@@ -209,8 +454,65 @@ func (j *RuncLifecycle) StatProcess(cfg *config.BPMConfig) (*models.Process, err
 	), nil
 }
 
-func (j *RuncLifecycle) OpenShell(cfg *config.BPMConfig, stdin io.Reader, stdout, stderr io.Writer) error {
-	return j.runcClient.Exec(cfg.ContainerID(), "/bin/bash", stdin, stdout, stderr)
+// CgroupPaths returns the cgroup directories of the container of the process
+// keyed by controller as recorded by runc.
+func (j *RuncLifecycle) CgroupPaths(cfg *config.BPMConfig) (map[string]string, error) {
+	paths, err := j.runcClient.CgroupPaths(cfg.ContainerID())
+	if os.IsNotExist(err) {
+		return nil, isNotExistError
+	}
+
+	return paths, err
+}
+
+// ExecOptions configures a command run in the container of a process with
+// ExecProcess.
+type ExecOptions struct {
+	// TTY allocates a pseudo-terminal for the command.
+	TTY bool
+
+	// Env holds additional environment variables in KEY=VALUE form.
+	Env []string
+
+	// User is the name of the user to run the command as. The user of the
+	// process is used when it is empty.
+	User string
+
+	// Cwd is the working directory of the command.
+	Cwd string
+}
+
+// ExecProcess runs a command in the container of a running process and waits
+// for it to exit. It returns the exit status of the command.
+func (j *RuncLifecycle) ExecProcess(cfg *config.BPMConfig, args []string, opts ExecOptions, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	execOpts := client.ExecOptions{
+		TTY: opts.TTY,
+		Env: opts.Env,
+		Cwd: opts.Cwd,
+	}
+
+	if opts.User != "" {
+		user, err := j.userFinder.Lookup(opts.User)
+		if err != nil {
+			return 1, fmt.Errorf("failed to look up user %q: %s", opts.User, err)
+		}
+		execOpts.User = fmt.Sprintf("%d:%d", user.UID, user.GID)
+	}
+
+	return j.runcClient.Exec(cfg.ContainerID(), args, execOpts, stdin, stdout, stderr)
+}
+
+// SignalProcess sends the signal to the process. It is sent to tini, which
+// forwards it to the process, unless all is set in which case it is sent to
+// every process in the container.
+func (j *RuncLifecycle) SignalProcess(logger lager.Logger, cfg *config.BPMConfig, signal client.Signal, all bool) error {
+	logger.Info("sending-signal", lager.Data{"signal": signal.String(), "all": all})
+
+	if all {
+		return j.runcClient.SignalAllProcesses(cfg.ContainerID(), signal)
+	}
+
+	return j.runcClient.SignalContainer(cfg.ContainerID(), signal)
 }
 
 func (j *RuncLifecycle) ListProcesses() ([]*models.Process, error) {
@@ -221,59 +523,132 @@ func (j *RuncLifecycle) ListProcesses() ([]*models.Process, error) {
 
 	var processes []*models.Process
 	for _, c := range containers {
-		processes = append(processes, newProcessFromContainerState(
+		// Hooks which run in a container are not processes of the job.
+		if config.IsHookContainerID(c.ID) {
+			continue
+		}
+
+		process := newProcessFromContainerState(
 			c.ID,
 			containerStateFromString(c.Status),
 			c.InitProcessPid,
-		))
+		)
+		process.StartTime = c.Created
+		process.BundlePath = c.Bundle
+
+		if process.Status == models.ProcessStateFailed {
+			// This is only informational and so errors are ignored.
+			process.OOMKilled, _ = j.isOOMKilled(c.ID)
+		}
+
+		processes = append(processes, process)
 	}
 
 	return processes, nil
 }
 
+// StopProcess runs the pre_stop hook and then works through the shutdown
+// sequence of the process, sending each signal in turn and waiting for the
+// container to stop. If no sequence is configured then the shutdown signal is
+// sent followed by a SIGQUIT once exitTimeout has passed. A timeout error is
+// returned if the container is still running at the end of the sequence.
 func (j *RuncLifecycle) StopProcess(logger lager.Logger, cfg *config.BPMConfig, procCfg *config.ProcessConfig, exitTimeout time.Duration) error {
-	err := j.runcClient.SignalContainer(cfg.ContainerID(), procCfg.ParseShutdownSignal())
-	if err != nil {
-		return err
+	if procCfg.Hooks != nil {
+		// A failing pre_stop hook should not prevent the process from
+		// being stopped.
+		if err := j.runHookOutsideStart(logger, "pre-stop", procCfg.Hooks.PreStop, cfg, procCfg); err != nil {
+			logger.Error("pre-stop-hook-failed", err)
+		}
 	}
 
-	state, err := j.runcClient.ContainerState(cfg.ContainerID())
-	if err != nil {
-		logger.Error("failed-to-fetch-state", err)
-	} else {
-		if state.Status == ContainerStateStopped {
+	for i, step := range shutdownSteps(procCfg, exitTimeout) {
+		stepLogger := logger.Session("shutdown-step", lager.Data{
+			"step":   i + 1,
+			"signal": step.ParseSignal().String(),
+			"wait":   step.ParseWait().String(),
+		})
+
+		stepLogger.Info("sending-signal")
+		err := j.runcClient.SignalContainer(cfg.ContainerID(), step.ParseSignal())
+		if err != nil {
+			if i == 0 {
+				return err
+			}
+
+			stepLogger.Error("failed-to-signal", err)
+		}
+
+		if j.waitForStop(stepLogger, cfg, step.ParseWait()) {
+			stepLogger.Info("process-stopped")
+			j.recordStop(stepLogger, cfg, step.ParseSignal().String())
 			return nil
 		}
+
+		stepLogger.Info("process-still-running")
+	}
+
+	return timeoutError
+}
+
+func shutdownSteps(procCfg *config.ProcessConfig, exitTimeout time.Duration) []config.ShutdownStep {
+	if len(procCfg.Shutdown) > 0 {
+		return procCfg.Shutdown
+	}
+
+	return []config.ShutdownStep{
+		{Signal: procCfg.ParseShutdownSignal().String(), Wait: exitTimeout.String()},
+		{Signal: client.Quit.String(), Wait: ContainerSigQuitGracePeriod.String()},
+	}
+}
+
+// waitForStop polls the container state until the container has stopped or
+// the wait has passed. It returns whether the container stopped.
+func (j *RuncLifecycle) waitForStop(logger lager.Logger, cfg *config.BPMConfig, wait time.Duration) bool {
+	if j.isStopped(logger, cfg) {
+		return true
+	}
+
+	if wait <= 0 {
+		return false
 	}
 
-	timeout := j.clock.NewTimer(exitTimeout)
+	timeout := j.clock.NewTimer(wait)
+	defer timeout.Stop()
 	stateTicker := j.clock.NewTicker(ContainerStatePollInterval)
 	defer stateTicker.Stop()
 
 	for {
 		select {
 		case <-stateTicker.C():
-			state, err = j.runcClient.ContainerState(cfg.ContainerID())
-			if err != nil {
-				logger.Error("failed-to-fetch-state", err)
-			} else {
-				if state.Status == ContainerStateStopped {
-					return nil
-				}
+			if j.isStopped(logger, cfg) {
+				return true
 			}
 		case <-timeout.C():
-			err := j.runcClient.SignalContainer(cfg.ContainerID(), client.Quit)
-			if err != nil {
-				logger.Error("failed-to-sigquit", err)
-			}
-
-			j.clock.Sleep(ContainerSigQuitGracePeriod)
-			return timeoutError
+			return false
 		}
 	}
 }
 
+func (j *RuncLifecycle) isStopped(logger lager.Logger, cfg *config.BPMConfig) bool {
+	state, err := j.runcClient.ContainerState(cfg.ContainerID())
+	if err != nil {
+		logger.Error("failed-to-fetch-state", err)
+		return false
+	}
+
+	// There is no state once the container has been deleted, e.g. by another
+	// bpm command.
+	return state == nil || state.Status == ContainerStateStopped
+}
+
 func (j *RuncLifecycle) RemoveProcess(logger lager.Logger, cfg *config.BPMConfig) error {
+	// If the process was not stopped by bpm then it must have exited by
+	// itself and its container can still be checked for an OOM kill.
+	if !j.stoppedByBPM(logger, cfg) {
+		oomKilled := j.checkOOMKilled(logger, cfg, nil)
+		j.recordExit(logger, cfg, readExitStatus(logger, cfg), oomKilled)
+	}
+
 	logger.Info("forcefully-deleting-container")
 	if err := j.runcClient.DeleteContainer(cfg.ContainerID()); err != nil {
 		return err
@@ -284,10 +659,79 @@ func (j *RuncLifecycle) RemoveProcess(logger lager.Logger, cfg *config.BPMConfig
 		return err
 	}
 
+	logger.Info("deleting-exit-status-file")
+	if err := j.deleteFile(cfg.ExitStatusFile().External()); err != nil {
+		return err
+	}
+
 	logger.Info("deleting-pidfile")
 	return j.deleteFile(cfg.PidFile().External())
 }
 
+func createExitStatusFile(cfg *config.BPMConfig) (*os.File, error) {
+	path := cfg.ExitStatusFile().External()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+}
+
+// readExitStatus returns the exit status written by a process which was
+// started in the background. It is nil if the process is still running or was
+// run in the foreground.
+func readExitStatus(logger lager.Logger, cfg *config.BPMConfig) *int {
+	data, err := os.ReadFile(cfg.ExitStatusFile().External())
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		logger.Error("failed-to-read-exit-status", err)
+		return nil
+	}
+
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return nil
+	}
+
+	status, err := strconv.Atoi(text)
+	if err != nil {
+		logger.Error("failed-to-parse-exit-status", err)
+		return nil
+	}
+
+	return &status
+}
+
+// The history is informational and so failing to record it does not fail the
+// operation.
+func (j *RuncLifecycle) recordStart(logger lager.Logger, cfg *config.BPMConfig) {
+	if err := history.RecordStart(cfg.HistoryFile().External(), j.clock.Now()); err != nil {
+		logger.Error("failed-to-record-history", err)
+	}
+}
+
+func (j *RuncLifecycle) recordStop(logger lager.Logger, cfg *config.BPMConfig, signal string) {
+	if err := history.RecordStop(cfg.HistoryFile().External(), j.clock.Now(), signal); err != nil {
+		logger.Error("failed-to-record-history", err)
+	}
+}
+
+func (j *RuncLifecycle) stoppedByBPM(logger lager.Logger, cfg *config.BPMConfig) bool {
+	stopped, err := history.StoppedByBPM(cfg.HistoryFile().External())
+	if err != nil {
+		logger.Error("failed-to-read-history", err)
+	}
+
+	return stopped
+}
+
+func (j *RuncLifecycle) recordExit(logger lager.Logger, cfg *config.BPMConfig, exitStatus *int, oomKilled bool) {
+	if err := history.RecordExit(cfg.HistoryFile().External(), j.clock.Now(), exitStatus, oomKilled); err != nil {
+		logger.Error("failed-to-record-history", err)
+	}
+}
+
 func newProcessFromContainerState(id string, status specs.ContainerState, pid int) *models.Process {
 	return &models.Process{
 		Name:   id,
@@ -18,9 +18,14 @@ package lifecycle_test
 import (
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"code.cloudfoundry.org/clock/fakeclock"
@@ -35,6 +40,7 @@ import (
 
 	"bpm/bosh"
 	"bpm/config"
+	"bpm/history"
 	"bpm/jobid"
 	"bpm/models"
 	"bpm/runc/client"
@@ -107,7 +113,7 @@ var _ = Describe("RuncJobLifecycle", func() {
 			},
 			Version: "example-version",
 		}
-		expectedSystemRoot = "system-root"
+		expectedSystemRoot = GinkgoT().TempDir()
 		boshEnv = bosh.NewEnv(expectedSystemRoot)
 
 		runcLifecycle = lifecycle.NewRuncLifecycle(
@@ -160,7 +166,7 @@ var _ = Describe("RuncJobLifecycle", func() {
 
 		fakeRuncClient.
 			EXPECT().
-			RunContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			RunContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			AnyTimes()
 
 		fakeRuncClient.
@@ -173,22 +179,58 @@ var _ = Describe("RuncJobLifecycle", func() {
 			DestroyBundle(gomock.Any()).
 			AnyTimes()
 
+		fakeRuncClient.
+			EXPECT().
+			CgroupPaths(gomock.Any()).
+			Return(nil, os.ErrNotExist).
+			AnyTimes()
+
+		fakeRuncAdapter.
+			EXPECT().
+			ProcessEnvironment(gomock.Any(), gomock.Any()).
+			Return([]string{"foo=bar"}).
+			AnyTimes()
+
+		fakeRuncAdapter.
+			EXPECT().
+			OpenLogFiles(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(*config.BPMConfig, specs.User) (*os.File, *os.File, error) {
+				stdout, err := os.OpenFile(expectedStdout.Name(), os.O_WRONLY|os.O_APPEND, 0600)
+				Expect(err).NotTo(HaveOccurred())
+				stderr, err := os.OpenFile(expectedStderr.Name(), os.O_WRONLY|os.O_APPEND, 0600)
+				Expect(err).NotTo(HaveOccurred())
+				return stdout, stderr, nil
+			}).
+			AnyTimes()
+
 		fakeCommandRunner.
 			EXPECT().
 			Run(gomock.Any()).
 			AnyTimes()
 	}
 
+	oomKilledCgroup := func() map[string]string {
+		cgroupPath := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(cgroupPath, "memory.events"), []byte("oom 1\noom_kill 1\n"), 0600)).To(Succeed())
+		return map[string]string{"": cgroupPath}
+	}
+
+	hookCommand := func(path string) gomock.Matcher {
+		return gomock.Cond(func(cmd *exec.Cmd) bool {
+			return cmd.Path == path
+		})
+	}
+
 	var ItSetsUpAndRunsAProcess = func(run func(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error) {
 		Context("when a PreStart Hook is provided", func() {
 			BeforeEach(func() {
 				procCfg.Hooks = &config.Hooks{
-					PreStart: "/please/execute/me",
+					PreStart: config.Hook{Path: "/please/execute/me"},
 				}
 			})
 
 			It("executes the pre start hook", func() {
-				expectedCommand := exec.Command(procCfg.Hooks.PreStart)
+				expectedCommand := exec.Command(procCfg.Hooks.PreStart.Path)
 				expectedCommand.Stdout = expectedStdout
 				expectedCommand.Stderr = expectedStderr
 				expectedCommand.Env = []string{"foo=bar"}
@@ -221,7 +263,7 @@ var _ = Describe("RuncJobLifecycle", func() {
 		Context("when PreStart Hook is empty", func() {
 			BeforeEach(func() {
 				procCfg.Hooks = &config.Hooks{
-					PreStart: "",
+					PreStart: config.Hook{Path: ""},
 				}
 			})
 
@@ -237,7 +279,7 @@ var _ = Describe("RuncJobLifecycle", func() {
 
 				fakeRuncClient.
 					EXPECT().
-					RunContainer(gomock.Any(), gomock.Any(), jobid.Encode(expectedJobName), gomock.Any(), gomock.Any(), gomock.Any()).
+					RunContainer(gomock.Any(), gomock.Any(), jobid.Encode(expectedJobName), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 					Times(1)
 			})
 
@@ -335,6 +377,7 @@ var _ = Describe("RuncJobLifecycle", func() {
 					true,
 					expectedStdout,
 					expectedStderr,
+					gomock.Any(),
 				).
 				Times(1)
 
@@ -344,11 +387,44 @@ var _ = Describe("RuncJobLifecycle", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("gives the process a new file to write its exit status to", func() {
+			Expect(os.MkdirAll(bpmCfg.PidDir().External(), 0700)).To(Succeed())
+			Expect(os.WriteFile(bpmCfg.ExitStatusFile().External(), []byte("1\n"), 0600)).To(Succeed())
+
+			fakeRuncClient.
+				EXPECT().
+				RunContainer(gomock.Any(), gomock.Any(), gomock.Any(), true, gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_, _, _ string, _ bool, _, _ io.Writer, extraFiles ...*os.File) (int, error) {
+					Expect(extraFiles).To(HaveLen(1))
+					Expect(extraFiles[0].Name()).To(Equal(bpmCfg.ExitStatusFile().External()))
+					Expect(os.ReadFile(bpmCfg.ExitStatusFile().External())).To(BeEmpty())
+					return 0, nil
+				})
+
+			setupMockDefaults()
+
+			err := runcLifecycle.StartProcess(logger, bpmCfg, procCfg)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("records that the process was started", func() {
+			setupMockDefaults()
+
+			err := runcLifecycle.StartProcess(logger, bpmCfg, procCfg)
+			Expect(err).NotTo(HaveOccurred())
+
+			entries, err := history.Read(bpmCfg.HistoryFile().External())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].StartTime).To(Equal(fakeClock.Now().UTC()))
+			Expect(entries[0].StopTime).To(BeNil())
+		})
+
 		Context("when running the container fails", func() {
 			BeforeEach(func() {
 				fakeRuncClient.
 					EXPECT().
-					RunContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					RunContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(1, errors.New("fake test error"))
 			})
 
@@ -360,6 +436,55 @@ var _ = Describe("RuncJobLifecycle", func() {
 			})
 		})
 
+		Context("when a PostStart Hook is provided", func() {
+			BeforeEach(func() {
+				procCfg.Hooks = &config.Hooks{
+					PostStart: config.Hook{Path: "/please/execute/me/after"},
+				}
+			})
+
+			It("executes the post start hook after the container is running", func() {
+				gomock.InOrder(
+					fakeRuncClient.
+						EXPECT().
+						RunContainer(gomock.Any(), gomock.Any(), gomock.Any(), true, gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(1),
+					fakeCommandRunner.
+						EXPECT().
+						Run(hookCommand("/please/execute/me/after")).
+						Do(func(cmd *exec.Cmd) {
+							Expect(cmd.Env).To(Equal([]string{"foo=bar"}))
+						}).
+						Times(1),
+				)
+
+				setupMockDefaults()
+				err := runcLifecycle.StartProcess(logger, bpmCfg, procCfg)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("when the PostStart Hook fails", func() {
+				BeforeEach(func() {
+					fakeCommandRunner.
+						EXPECT().
+						Run(hookCommand("/please/execute/me/after")).
+						Return(errors.New("fake test error")).
+						Times(1)
+				})
+
+				It("removes the container and returns an error", func() {
+					fakeRuncClient.
+						EXPECT().
+						DeleteContainer(expectedContainerID).
+						Times(1)
+
+					setupMockDefaults()
+					err := runcLifecycle.StartProcess(logger, bpmCfg, procCfg)
+					Expect(err).To(MatchError("post-start hook failed: fake test error"))
+				})
+			})
+		})
+
 		ItSetsUpAndRunsAProcess(func(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
 			setupMockDefaults()
 
@@ -429,6 +554,116 @@ var _ = Describe("RuncJobLifecycle", func() {
 				Expect(err).To(HaveOccurred())
 				Expect(status).To(Equal(1))
 			})
+
+			It("is not a setup failure", func() {
+				setupMockDefaults()
+
+				_, err := runcLifecycle.RunProcess(logger, bpmCfg, procCfg)
+				Expect(lifecycle.IsSetupFailure(err)).To(BeFalse())
+				Expect(lifecycle.IsHookFailure(err)).To(BeFalse())
+			})
+
+			It("deletes the container", func() {
+				fakeRuncClient.
+					EXPECT().
+					DeleteContainer(expectedContainerID).
+					Times(1)
+
+				setupMockDefaults()
+
+				_, err := runcLifecycle.RunProcess(logger, bpmCfg, procCfg)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("records the exit status of the process", func() {
+				setupMockDefaults()
+
+				_, err := runcLifecycle.RunProcess(logger, bpmCfg, procCfg)
+				Expect(err).To(HaveOccurred())
+
+				entries, err := history.Read(bpmCfg.HistoryFile().External())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(HaveLen(1))
+				Expect(entries[0].StopTime).NotTo(BeNil())
+				Expect(*entries[0].ExitStatus).To(Equal(1))
+			})
+		})
+
+		Context("when the bundle cannot be created", func() {
+			BeforeEach(func() {
+				fakeRuncClient.
+					EXPECT().
+					CreateBundle(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(errors.New("fake test error"))
+			})
+
+			It("returns a setup failure", func() {
+				setupMockDefaults()
+
+				_, err := runcLifecycle.RunProcess(logger, bpmCfg, procCfg)
+				Expect(err).To(MatchError("bundle build failure: fake test error"))
+				Expect(lifecycle.IsSetupFailure(err)).To(BeTrue())
+				Expect(lifecycle.IsHookFailure(err)).To(BeFalse())
+			})
+		})
+
+		Context("when the pre-start hook fails", func() {
+			BeforeEach(func() {
+				procCfg.Hooks = &config.Hooks{
+					PreStart: config.Hook{Path: "/please/execute/me/before"},
+				}
+
+				fakeCommandRunner.
+					EXPECT().
+					Run(hookCommand("/please/execute/me/before")).
+					Return(errors.New("fake test error"))
+			})
+
+			It("returns a hook failure", func() {
+				setupMockDefaults()
+
+				_, err := runcLifecycle.RunProcess(logger, bpmCfg, procCfg)
+				Expect(err).To(MatchError("prestart hook failed: fake test error"))
+				Expect(lifecycle.IsSetupFailure(err)).To(BeTrue())
+				Expect(lifecycle.IsHookFailure(err)).To(BeTrue())
+			})
+		})
+
+		Context("when the process is killed by the OOM killer", func() {
+			BeforeEach(func() {
+				fakeRuncClient.
+					EXPECT().
+					RunContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(137, errors.New("exit status 137"))
+
+				fakeRuncClient.
+					EXPECT().
+					CgroupPaths(expectedContainerID).
+					Return(oomKilledCgroup(), nil)
+			})
+
+			It("returns an OOM killed error", func() {
+				setupMockDefaults()
+
+				status, err := runcLifecycle.RunProcess(logger, bpmCfg, procCfg)
+				Expect(status).To(Equal(137))
+				Expect(lifecycle.IsOOMKilled(err)).To(BeTrue())
+			})
+
+			It("logs the OOM kill and records it in the history and the stderr log", func() {
+				setupMockDefaults()
+
+				_, err := runcLifecycle.RunProcess(logger, bpmCfg, procCfg)
+				Expect(err).To(HaveOccurred())
+
+				Expect(logger).To(gbytes.Say("oom-killed"))
+				Expect(os.ReadFile(expectedStderr.Name())).To(ContainSubstring("bpm: process was killed by the OOM killer"))
+
+				entries, err := history.Read(bpmCfg.HistoryFile().External())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries[0].OOMKilled).To(BeTrue())
+				Expect(*entries[0].ExitStatus).To(Equal(137))
+			})
 		})
 
 		ItSetsUpAndRunsAProcess(func(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
@@ -464,6 +699,50 @@ var _ = Describe("RuncJobLifecycle", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("records the signal which stopped the process", func() {
+			Expect(history.RecordStart(bpmCfg.HistoryFile().External(), fakeClock.Now())).To(Succeed())
+
+			fakeRuncClient.
+				EXPECT().
+				ContainerState(expectedContainerID).
+				Return(&specs.State{
+					Status: "stopped",
+				}, nil)
+
+			fakeRuncClient.
+				EXPECT().
+				SignalContainer(expectedContainerID, client.Term).
+				Times(1)
+
+			setupMockDefaults()
+			err := runcLifecycle.StopProcess(logger, bpmCfg, procCfg, exitTimeout)
+			Expect(err).ToNot(HaveOccurred())
+
+			entries, err := history.Read(bpmCfg.HistoryFile().External())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].StopTime).NotTo(BeNil())
+			Expect(entries[0].Signal).To(Equal("TERM"))
+		})
+
+		Context("when the container is removed once it has stopped", func() {
+			It("stops the container", func() {
+				fakeRuncClient.
+					EXPECT().
+					ContainerState(expectedContainerID).
+					Return(nil, nil)
+
+				fakeRuncClient.
+					EXPECT().
+					SignalContainer(expectedContainerID, client.Term).
+					Times(1)
+
+				setupMockDefaults()
+				err := runcLifecycle.StopProcess(logger, bpmCfg, procCfg, exitTimeout)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
 		Context("when the shutdown signal is SIGINT", func() {
 			BeforeEach(func() {
 				procCfg.ShutdownSignal = "INT"
@@ -551,10 +830,15 @@ var _ = Describe("RuncJobLifecycle", func() {
 						fakeRuncClient.
 							EXPECT().
 							SignalContainer(expectedContainerID, client.Quit).
-							Do(func(id string, signal client.Signal) {
+							Times(1),
+						fakeRuncClient.
+							EXPECT().
+							ContainerState(expectedContainerID).
+							DoAndReturn(func(id string) (*specs.State, error) {
 								go fakeClock.WaitForNWatchersAndIncrement(lifecycle.ContainerSigQuitGracePeriod, 2)
+								return &specs.State{Status: "running"}, nil
 							}).
-							Times(1),
+							AnyTimes(),
 					)
 
 					setupMockDefaults()
@@ -564,45 +848,172 @@ var _ = Describe("RuncJobLifecycle", func() {
 			})
 		})
 
-		Context("when fetching the container state fails", func() {
-			It("keeps attempting to fetch the state", func() {
+		Context("when a PreStop Hook is provided", func() {
+			BeforeEach(func() {
+				procCfg.Hooks = &config.Hooks{
+					PreStop: config.Hook{Path: "/please/execute/me/first"},
+				}
+
+				fakeRuncClient.
+					EXPECT().
+					ContainerState(expectedContainerID).
+					Return(&specs.State{Status: "stopped"}, nil)
+			})
+
+			It("executes the hook before sending the shutdown signal", func() {
 				gomock.InOrder(
+					fakeCommandRunner.
+						EXPECT().
+						Run(hookCommand("/please/execute/me/first")).
+						Times(1),
 					fakeRuncClient.
 						EXPECT().
 						SignalContainer(expectedContainerID, client.Term).
 						Times(1),
+				)
+
+				setupMockDefaults()
+				err := runcLifecycle.StopProcess(logger, bpmCfg, procCfg, exitTimeout)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("when the PreStop Hook fails", func() {
+				It("still stops the container", func() {
+					gomock.InOrder(
+						fakeCommandRunner.
+							EXPECT().
+							Run(hookCommand("/please/execute/me/first")).
+							Return(errors.New("fake test error")).
+							Times(1),
+						fakeRuncClient.
+							EXPECT().
+							SignalContainer(expectedContainerID, client.Term).
+							Times(1),
+					)
+
+					setupMockDefaults()
+					err := runcLifecycle.StopProcess(logger, bpmCfg, procCfg, exitTimeout)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(logger).To(gbytes.Say("pre-stop-hook-failed"))
+				})
+			})
+		})
+
+		Context("when a shutdown sequence is configured", func() {
+			BeforeEach(func() {
+				procCfg.Shutdown = []config.ShutdownStep{
+					{Signal: "USR1", Wait: "30s"},
+					{Signal: "TERM"},
+					{Signal: "KILL", Wait: "1s"},
+				}
+			})
+
+			It("sends each signal in turn until the container stops", func() {
+				gomock.InOrder(
+					fakeRuncClient.
+						EXPECT().
+						SignalContainer(expectedContainerID, client.Usr1).
+						Times(1),
 					fakeRuncClient.
 						EXPECT().
 						ContainerState(expectedContainerID).
 						DoAndReturn(func(id string) (*specs.State, error) {
-							go fakeClock.WaitForNWatchersAndIncrement(lifecycle.ContainerStatePollInterval, 2)
-							return nil, errors.New("fake test error")
+							go fakeClock.WaitForNWatchersAndIncrement(30*time.Second, 2)
+							return &specs.State{Status: "running"}, nil
 						}).
+						AnyTimes(),
+					fakeRuncClient.
+						EXPECT().
+						SignalContainer(expectedContainerID, client.Term).
 						Times(1),
 					fakeRuncClient.
 						EXPECT().
 						ContainerState(expectedContainerID).
-						DoAndReturn(func(id string) (*specs.State, error) {
-							go fakeClock.WaitForNWatchersAndIncrement(exitTimeout, 2)
-							return nil, errors.New("fake test error")
-						}).
-						AnyTimes(),
+						Return(&specs.State{Status: "running"}, nil).
+						Times(1),
 					fakeRuncClient.
 						EXPECT().
-						SignalContainer(expectedContainerID, client.Quit).
-						Do(func(id string, signal client.Signal) {
-							go fakeClock.WaitForNWatchersAndIncrement(lifecycle.ContainerSigQuitGracePeriod, 2)
-						}).
+						SignalContainer(expectedContainerID, client.Kill).
+						Times(1),
+					fakeRuncClient.
+						EXPECT().
+						ContainerState(expectedContainerID).
+						Return(&specs.State{Status: "stopped"}, nil).
 						Times(1),
 				)
 
 				setupMockDefaults()
 				err := runcLifecycle.StopProcess(logger, bpmCfg, procCfg, exitTimeout)
-				Expect(err).To(MatchError("failed to stop job within timeout"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logger).To(gbytes.Say("shutdown-step.sending-signal.*USR1"))
+				Expect(logger).To(gbytes.Say("shutdown-step.sending-signal.*TERM"))
+				Expect(logger).To(gbytes.Say("shutdown-step.sending-signal.*KILL"))
+				Expect(logger).To(gbytes.Say("shutdown-step.process-stopped"))
 			})
-		})
 
-		Context("when stopping a container fails", func() {
+			It("does not send later signals once the container has stopped", func() {
+				gomock.InOrder(
+					fakeRuncClient.
+						EXPECT().
+						SignalContainer(expectedContainerID, client.Usr1).
+						Times(1),
+					fakeRuncClient.
+						EXPECT().
+						ContainerState(expectedContainerID).
+						Return(&specs.State{Status: "stopped"}, nil).
+						Times(1),
+				)
+
+				setupMockDefaults()
+				err := runcLifecycle.StopProcess(logger, bpmCfg, procCfg, exitTimeout)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when fetching the container state fails", func() {
+			It("keeps attempting to fetch the state", func() {
+				gomock.InOrder(
+					fakeRuncClient.
+						EXPECT().
+						SignalContainer(expectedContainerID, client.Term).
+						Times(1),
+					fakeRuncClient.
+						EXPECT().
+						ContainerState(expectedContainerID).
+						DoAndReturn(func(id string) (*specs.State, error) {
+							go fakeClock.WaitForNWatchersAndIncrement(lifecycle.ContainerStatePollInterval, 2)
+							return nil, errors.New("fake test error")
+						}).
+						Times(1),
+					fakeRuncClient.
+						EXPECT().
+						ContainerState(expectedContainerID).
+						DoAndReturn(func(id string) (*specs.State, error) {
+							go fakeClock.WaitForNWatchersAndIncrement(exitTimeout, 2)
+							return nil, errors.New("fake test error")
+						}).
+						AnyTimes(),
+					fakeRuncClient.
+						EXPECT().
+						SignalContainer(expectedContainerID, client.Quit).
+						Times(1),
+					fakeRuncClient.
+						EXPECT().
+						ContainerState(expectedContainerID).
+						DoAndReturn(func(id string) (*specs.State, error) {
+							go fakeClock.WaitForNWatchersAndIncrement(lifecycle.ContainerSigQuitGracePeriod, 2)
+							return nil, errors.New("fake test error")
+						}).
+						AnyTimes(),
+				)
+
+				setupMockDefaults()
+				err := runcLifecycle.StopProcess(logger, bpmCfg, procCfg, exitTimeout)
+				Expect(err).To(MatchError("failed to stop job within timeout"))
+			})
+		})
+
+		Context("when stopping a container fails", func() {
 			var expectedErr error
 
 			BeforeEach(func() {
@@ -613,92 +1024,922 @@ var _ = Describe("RuncJobLifecycle", func() {
 					Return(expectedErr)
 			})
 
-			It("returns an error", func() {
+			It("returns an error", func() {
+				setupMockDefaults()
+				err := runcLifecycle.StopProcess(logger, bpmCfg, procCfg, exitTimeout)
+				Expect(err).To(Equal(expectedErr))
+			})
+		})
+	})
+
+	Describe("RunPostStopHook", func() {
+		Context("when a PostStop Hook is provided", func() {
+			BeforeEach(func() {
+				procCfg.Hooks = &config.Hooks{
+					PostStop: config.Hook{Path: "/please/clean/up"},
+				}
+			})
+
+			It("executes the hook with the process environment", func() {
+				fakeCommandRunner.
+					EXPECT().
+					Run(hookCommand("/please/clean/up")).
+					Do(func(cmd *exec.Cmd) {
+						Expect(cmd.Env).To(Equal([]string{"foo=bar"}))
+					}).
+					Times(1)
+
+				setupMockDefaults()
+				Expect(runcLifecycle.RunPostStopHook(logger, bpmCfg, procCfg)).To(Succeed())
+			})
+
+			It("returns an error if the hook fails", func() {
+				fakeCommandRunner.
+					EXPECT().
+					Run(gomock.Any()).
+					Return(errors.New("fake test error"))
+
+				setupMockDefaults()
+				err := runcLifecycle.RunPostStopHook(logger, bpmCfg, procCfg)
+				Expect(err).To(MatchError("post-stop hook failed: fake test error"))
+			})
+
+			Context("when the hook runs for longer than its timeout", func() {
+				var hookPath string
+
+				BeforeEach(func() {
+					hook, err := os.CreateTemp("", "post-stop")
+					Expect(err).NotTo(HaveOccurred())
+					_, err = hook.WriteString("#!/bin/sh\nsleep 10\n")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(hook.Close()).To(Succeed())
+					Expect(os.Chmod(hook.Name(), 0700)).To(Succeed())
+					hookPath = hook.Name()
+
+					procCfg.Hooks.PostStop = config.Hook{Path: hookPath, Timeout: "100ms"}
+
+					runcLifecycle = lifecycle.NewRuncLifecycle(
+						fakeRuncClient,
+						fakeRuncAdapter,
+						fakeUserFinder,
+						lifecycle.NewCommandRunner(),
+						fakeClock,
+						fakeFileRemover.Remove,
+					)
+				})
+
+				AfterEach(func() {
+					Expect(os.Remove(hookPath)).To(Succeed())
+				})
+
+				It("kills the hook and returns an error", func() {
+					setupMockDefaults()
+					err := runcLifecycle.RunPostStopHook(logger, bpmCfg, procCfg)
+					Expect(err).To(MatchError("post-stop hook did not complete within 100ms"))
+				})
+			})
+		})
+
+		Context("when the PostStop Hook runs in a container", func() {
+			var (
+				hookCfg *config.BPMConfig
+				hookID  string
+			)
+
+			BeforeEach(func() {
+				procCfg.Args = []string{"--server"}
+				procCfg.Hooks = &config.Hooks{
+					PostStop: config.Hook{Path: "/var/vcap/jobs/example/bin/cleanup", Timeout: "5s", RunInContainer: true},
+				}
+
+				hookCfg = bpmCfg.ForHook("post-stop")
+				hookID = jobid.Encode(fmt.Sprintf("%s.%s+post-stop", expectedJobName, expectedProcName))
+			})
+
+			It("runs the hook in a container built from the process configuration", func() {
+				fakeRuncAdapter.
+					EXPECT().
+					BuildSpec(gomock.Any(), hookCfg, gomock.Any(), expectedUser).
+					DoAndReturn(func(_ lager.Logger, _ *config.BPMConfig, hookProcCfg *config.ProcessConfig, _ specs.User) (specs.Spec, error) {
+						Expect(hookProcCfg.Executable).To(Equal("/var/vcap/jobs/example/bin/cleanup"))
+						Expect(hookProcCfg.Args).To(BeEmpty())
+						Expect(hookProcCfg.Limits).To(Equal(procCfg.Limits))
+						return jobSpec, nil
+					}).
+					Times(1)
+
+				gomock.InOrder(
+					fakeRuncClient.
+						EXPECT().
+						CreateBundle(hookCfg.BundlePath(), jobSpec, expectedUser).
+						Times(1),
+					fakeRuncClient.
+						EXPECT().
+						RunContainer(gomock.Any(), hookCfg.BundlePath(), hookID, false, gomock.Any(), gomock.Any()).
+						Times(1),
+					fakeRuncClient.
+						EXPECT().
+						DeleteContainer(hookID).
+						Times(1),
+					fakeRuncClient.
+						EXPECT().
+						DestroyBundle(hookCfg.BundlePath()).
+						Times(1),
+				)
+
+				fakeCommandRunner.
+					EXPECT().
+					Run(gomock.Any()).
+					Times(0)
+
+				setupMockDefaults()
+				Expect(runcLifecycle.RunPostStopHook(logger, bpmCfg, procCfg)).To(Succeed())
+				Expect(procCfg.Executable).To(Equal("/bin/sleep"))
+			})
+
+			It("kills the container if the hook does not complete within its timeout", func() {
+				killed := make(chan struct{})
+
+				fakeRuncClient.
+					EXPECT().
+					RunContainer(gomock.Any(), gomock.Any(), hookID, false, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(string, string, string, bool, io.Writer, io.Writer, ...*os.File) (int, error) {
+						fakeClock.WaitForWatcherAndIncrement(5 * time.Second)
+						<-killed
+						return 137, errors.New("exit status 137")
+					})
+
+				fakeRuncClient.
+					EXPECT().
+					SignalContainer(hookID, client.Kill).
+					Do(func(string, client.Signal) { close(killed) })
+
+				setupMockDefaults()
+				err := runcLifecycle.RunPostStopHook(logger, bpmCfg, procCfg)
+				Expect(err).To(MatchError("post-stop hook did not complete within 5s"))
+			})
+		})
+
+		Context("when no hooks are provided", func() {
+			It("does nothing", func() {
+				fakeCommandRunner.
+					EXPECT().
+					Run(gomock.Any()).
+					Times(0)
+
+				Expect(runcLifecycle.RunPostStopHook(logger, bpmCfg, procCfg)).To(Succeed())
+			})
+		})
+	})
+
+	Describe("WaitForReady", func() {
+		var runningState *specs.State
+
+		BeforeEach(func() {
+			runningState = &specs.State{ID: expectedContainerID, Pid: 1234, Status: "running"}
+		})
+
+		listen := func() net.Listener {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(listener.Close)
+			return listener
+		}
+
+		Context("when the process does not have a readiness probe", func() {
+			It("returns immediately", func() {
+				Expect(runcLifecycle.WaitForReady(logger, bpmCfg, procCfg)).To(Succeed())
+			})
+		})
+
+		Context("when the process has a tcp probe", func() {
+			It("succeeds once the port accepts connections", func() {
+				listener := listen()
+				procCfg.Readiness = &config.Probe{
+					TCP: &config.TCPProbe{Port: listener.Addr().(*net.TCPAddr).Port},
+				}
+
+				fakeRuncClient.EXPECT().ContainerState(expectedContainerID).Return(runningState, nil)
+
+				Expect(runcLifecycle.WaitForReady(logger, bpmCfg, procCfg)).To(Succeed())
+				Expect(logger).To(gbytes.Say("ready"))
+			})
+
+			It("returns an error once the failure threshold is reached", func() {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).NotTo(HaveOccurred())
+				port := listener.Addr().(*net.TCPAddr).Port
+				Expect(listener.Close()).To(Succeed())
+
+				procCfg.Readiness = &config.Probe{
+					TCP:              &config.TCPProbe{Port: port},
+					Interval:         "5s",
+					FailureThreshold: 2,
+				}
+
+				fakeRuncClient.EXPECT().ContainerState(expectedContainerID).Return(runningState, nil).Times(2)
+
+				errCh := make(chan error)
+				go func() { errCh <- runcLifecycle.WaitForReady(logger, bpmCfg, procCfg) }()
+
+				fakeClock.WaitForWatcherAndIncrement(5 * time.Second)
+
+				Eventually(errCh).Should(Receive(MatchError(ContainSubstring("did not become ready after 2 attempts"))))
+			})
+		})
+
+		Context("when the process has an http probe", func() {
+			It("retries until the endpoint returns a successful status", func() {
+				var requests atomic.Int32
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.URL.Path).To(Equal("/healthz"))
+					if requests.Add(1) == 1 {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+					w.WriteHeader(http.StatusNoContent)
+				}))
+				DeferCleanup(server.Close)
+
+				procCfg.Readiness = &config.Probe{
+					HTTP: &config.HTTPProbe{
+						Port: server.Listener.Addr().(*net.TCPAddr).Port,
+						Path: "/healthz",
+					},
+				}
+
+				fakeRuncClient.EXPECT().ContainerState(expectedContainerID).Return(runningState, nil).Times(2)
+
+				errCh := make(chan error)
+				go func() { errCh <- runcLifecycle.WaitForReady(logger, bpmCfg, procCfg) }()
+
+				fakeClock.WaitForWatcherAndIncrement(config.DefaultProbeInterval)
+
+				Eventually(errCh).Should(Receive(BeNil()))
+				Expect(requests.Load()).To(BeEquivalentTo(2))
+			})
+		})
+
+		Context("when the process has a socket probe", func() {
+			It("connects to the socket in the socket directory of the job", func() {
+				root, err := os.MkdirTemp("", "lifecycle-sockets")
+				Expect(err).NotTo(HaveOccurred())
+				DeferCleanup(os.RemoveAll, root)
+
+				bpmCfg = config.NewBPMConfig(bosh.NewEnv(root), expectedJobName, expectedProcName)
+				Expect(os.MkdirAll(bpmCfg.SocketDir().External(), 0700)).To(Succeed())
+
+				listener, err := net.Listen("unix", filepath.Join(bpmCfg.SocketDir().External(), "server.sock"))
+				Expect(err).NotTo(HaveOccurred())
+				DeferCleanup(listener.Close)
+
+				procCfg.Readiness = &config.Probe{Socket: "server.sock"}
+
+				fakeRuncClient.EXPECT().ContainerState(expectedContainerID).Return(runningState, nil)
+
+				Expect(runcLifecycle.WaitForReady(logger, bpmCfg, procCfg)).To(Succeed())
+			})
+		})
+
+		Context("when the process has an exec probe", func() {
+			BeforeEach(func() {
+				procCfg.Readiness = &config.Probe{
+					Exec:             []string{"/var/vcap/jobs/example/bin/ready", "--quick"},
+					FailureThreshold: 1,
+				}
+				fakeRuncClient.EXPECT().ContainerState(expectedContainerID).Return(runningState, nil)
+			})
+
+			It("runs the command in the container", func() {
+				fakeRuncClient.
+					EXPECT().
+					ExecCommand(gomock.Any(), expectedContainerID, []string{"/var/vcap/jobs/example/bin/ready", "--quick"}, gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				Expect(runcLifecycle.WaitForReady(logger, bpmCfg, procCfg)).To(Succeed())
+			})
+
+			It("includes the output of a failing command in the error", func() {
+				fakeRuncClient.
+					EXPECT().
+					ExecCommand(gomock.Any(), expectedContainerID, gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ interface{}, _ string, _ []string, stdout, _ io.Writer) error {
+						fmt.Fprintln(stdout, "still loading") //nolint:errcheck
+						return errors.New("exit status 1")
+					})
+
+				err := runcLifecycle.WaitForReady(logger, bpmCfg, procCfg)
+				Expect(err).To(MatchError(ContainSubstring("exit status 1: still loading")))
+			})
+		})
+
+		Context("when the process stops before becoming ready", func() {
+			It("returns an error", func() {
+				procCfg.Readiness = &config.Probe{TCP: &config.TCPProbe{Port: 1}}
+
+				fakeRuncClient.
+					EXPECT().
+					ContainerState(expectedContainerID).
+					Return(&specs.State{ID: expectedContainerID, Status: "stopped"}, nil)
+
+				err := runcLifecycle.WaitForReady(logger, bpmCfg, procCfg)
+				Expect(err).To(MatchError("process stopped before becoming ready"))
+			})
+		})
+	})
+
+	Describe("CheckHealth", func() {
+		var runningState *specs.State
+
+		BeforeEach(func() {
+			runningState = &specs.State{ID: expectedContainerID, Pid: 1234, Status: "running"}
+		})
+
+		Context("when the process does not have a liveness probe", func() {
+			It("is healthy if the process is running", func() {
+				fakeRuncClient.EXPECT().ContainerState(expectedContainerID).Return(runningState, nil)
+
+				Expect(runcLifecycle.CheckHealth(logger, bpmCfg, procCfg)).To(Succeed())
+			})
+		})
+
+		Context("when the process is not running", func() {
+			It("returns an error", func() {
+				procCfg.Liveness = &config.Probe{TCP: &config.TCPProbe{Port: 1}}
+				fakeRuncClient.EXPECT().ContainerState(expectedContainerID).Return(nil, nil)
+
+				err := runcLifecycle.CheckHealth(logger, bpmCfg, procCfg)
+				Expect(err).To(MatchError("process is not running"))
+			})
+		})
+
+		Context("when the process has a liveness probe", func() {
+			It("is healthy if the probe succeeds", func() {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).NotTo(HaveOccurred())
+				DeferCleanup(listener.Close)
+
+				procCfg.Liveness = &config.Probe{
+					TCP: &config.TCPProbe{Port: listener.Addr().(*net.TCPAddr).Port},
+				}
+				fakeRuncClient.EXPECT().ContainerState(expectedContainerID).Return(runningState, nil)
+
+				Expect(runcLifecycle.CheckHealth(logger, bpmCfg, procCfg)).To(Succeed())
+				Expect(logger.LogMessages()).To(BeEmpty())
+			})
+
+			It("returns the reason once the failure threshold is reached", func() {
+				procCfg.Liveness = &config.Probe{
+					Exec:             []string{"/var/vcap/jobs/example/bin/healthy"},
+					Interval:         "3s",
+					FailureThreshold: 2,
+				}
+
+				fakeRuncClient.EXPECT().ContainerState(expectedContainerID).Return(runningState, nil).Times(2)
+				fakeRuncClient.
+					EXPECT().
+					ExecCommand(gomock.Any(), expectedContainerID, []string{"/var/vcap/jobs/example/bin/healthy"}, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ interface{}, _ string, _ []string, _, stderr io.Writer) error {
+						fmt.Fprintln(stderr, "database unreachable") //nolint:errcheck
+						return errors.New("exit status 1")
+					}).
+					Times(2)
+
+				errCh := make(chan error)
+				go func() { errCh <- runcLifecycle.CheckHealth(logger, bpmCfg, procCfg) }()
+
+				fakeClock.WaitForWatcherAndIncrement(3 * time.Second)
+
+				Eventually(errCh).Should(Receive(MatchError("liveness probe failed: exit status 1: database unreachable")))
+				Expect(logger.LogMessages()).To(Equal([]string{
+					"lifecycle.check-health.probe-failed",
+					"lifecycle.check-health.probe-failed",
+					"lifecycle.check-health.unhealthy",
+				}))
+			})
+		})
+	})
+
+	Describe("RemoveProcess", func() {
+		It("deletes the container", func() {
+			fakeRuncClient.
+				EXPECT().
+				DeleteContainer(expectedContainerID).
+				Times(1)
+
+			setupMockDefaults()
+			err := runcLifecycle.RemoveProcess(logger, bpmCfg)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("destroys the bundle", func() {
+			bundlePath := filepath.Join(expectedSystemRoot, "data", "bpm", "bundles", expectedJobName, expectedProcName)
+			fakeRuncClient.
+				EXPECT().
+				DestroyBundle(bundlePath).
+				Times(1)
+
+			setupMockDefaults()
+			err := runcLifecycle.RemoveProcess(logger, bpmCfg)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("deletes the pidfile and the exit status file", func() {
+			setupMockDefaults()
+			err := runcLifecycle.RemoveProcess(logger, bpmCfg)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFileRemover.deletedFiles).To(ConsistOf(
+				bpmCfg.PidFile().External(),
+				bpmCfg.ExitStatusFile().External(),
+			))
+		})
+
+		It("records that a process which was not stopped by bpm exited", func() {
+			Expect(history.RecordStart(bpmCfg.HistoryFile().External(), fakeClock.Now())).To(Succeed())
+			fakeClock.Increment(time.Minute)
+
+			setupMockDefaults()
+			err := runcLifecycle.RemoveProcess(logger, bpmCfg)
+			Expect(err).NotTo(HaveOccurred())
+
+			entries, err := history.Read(bpmCfg.HistoryFile().External())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(*entries[0].StopTime).To(Equal(fakeClock.Now().UTC()))
+			Expect(entries[0].ExitStatus).To(BeNil())
+			Expect(entries[0].Signal).To(BeEmpty())
+			Expect(entries[0].OOMKilled).To(BeFalse())
+		})
+
+		Context("when the process wrote its exit status", func() {
+			BeforeEach(func() {
+				Expect(os.MkdirAll(bpmCfg.PidDir().External(), 0700)).To(Succeed())
+				Expect(os.WriteFile(bpmCfg.ExitStatusFile().External(), []byte("143\n"), 0600)).To(Succeed())
+			})
+
+			It("records the exit status in the history", func() {
+				Expect(history.RecordStart(bpmCfg.HistoryFile().External(), fakeClock.Now())).To(Succeed())
+
+				setupMockDefaults()
+				err := runcLifecycle.RemoveProcess(logger, bpmCfg)
+				Expect(err).NotTo(HaveOccurred())
+
+				entries, err := history.Read(bpmCfg.HistoryFile().External())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(HaveLen(1))
+				Expect(entries[0].ExitStatus).NotTo(BeNil())
+				Expect(*entries[0].ExitStatus).To(Equal(143))
+			})
+		})
+
+		Context("when the process was stopped by bpm", func() {
+			It("does not report an OOM kill from earlier in the life of the container", func() {
+				Expect(history.RecordStart(bpmCfg.HistoryFile().External(), fakeClock.Now())).To(Succeed())
+				Expect(history.RecordStop(bpmCfg.HistoryFile().External(), fakeClock.Now(), "TERM")).To(Succeed())
+
+				fakeRuncClient.
+					EXPECT().
+					CgroupPaths(expectedContainerID).
+					Return(oomKilledCgroup(), nil).
+					AnyTimes()
+
+				setupMockDefaults()
+				err := runcLifecycle.RemoveProcess(logger, bpmCfg)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger).NotTo(gbytes.Say("oom-killed"))
+				Expect(os.ReadFile(expectedStderr.Name())).To(BeEmpty())
+
+				entries, err := history.Read(bpmCfg.HistoryFile().External())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries[0].Signal).To(Equal("TERM"))
+				Expect(entries[0].OOMKilled).To(BeFalse())
+			})
+		})
+
+		Context("when the process was killed by the OOM killer", func() {
+			BeforeEach(func() {
+				fakeRuncClient.
+					EXPECT().
+					CgroupPaths(expectedContainerID).
+					Return(oomKilledCgroup(), nil)
+			})
+
+			It("logs the OOM kill and records it in the history and the stderr log", func() {
+				Expect(history.RecordStart(bpmCfg.HistoryFile().External(), fakeClock.Now())).To(Succeed())
+
+				setupMockDefaults()
+				err := runcLifecycle.RemoveProcess(logger, bpmCfg)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger).To(gbytes.Say("oom-killed"))
+				Expect(os.ReadFile(expectedStderr.Name())).To(ContainSubstring("bpm: process was killed by the OOM killer"))
+
+				entries, err := history.Read(bpmCfg.HistoryFile().External())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries[0].OOMKilled).To(BeTrue())
+			})
+		})
+
+		Context("when the process name is the same as the job name", func() {
+			BeforeEach(func() {
+				bpmCfg = config.NewBPMConfig(boshEnv, expectedJobName, expectedJobName)
+			})
+
+			It("simplifies the container id", func() {
+				fakeRuncClient.
+					EXPECT().
+					DeleteContainer(jobid.Encode(expectedJobName)).
+					Times(1)
+
+				setupMockDefaults()
+				err := runcLifecycle.RemoveProcess(logger, bpmCfg)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when deleting a container fails", func() {
+			var expectedErr error
+
+			BeforeEach(func() {
+				expectedErr = errors.New("an error")
+				fakeRuncClient.
+					EXPECT().
+					DeleteContainer(gomock.Any()).
+					Return(expectedErr)
+			})
+
+			It("returns an error", func() {
+				setupMockDefaults()
+				err := runcLifecycle.RemoveProcess(logger, bpmCfg)
+				Expect(err).To(Equal(expectedErr))
+			})
+		})
+
+		Context("when destroying a bundle fails", func() {
+			It("returns an error", func() {
+				expectedErr := errors.New("an error2")
+				fakeRuncClient.
+					EXPECT().
+					DestroyBundle(gomock.Any()).
+					Return(expectedErr)
+
+				setupMockDefaults()
+				err := runcLifecycle.RemoveProcess(logger, bpmCfg)
+				Expect(err).To(Equal(expectedErr))
+			})
+		})
+	})
+
+	Describe("SignalProcess", func() {
+		It("sends the signal to the init process of the container", func() {
+			fakeRuncClient.
+				EXPECT().
+				SignalContainer(expectedContainerID, client.Hup).
+				Return(nil)
+
+			setupMockDefaults()
+			err := runcLifecycle.SignalProcess(logger, bpmCfg, client.Hup, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.LogMessages()).To(ConsistOf("lifecycle.sending-signal"))
+		})
+
+		It("sends the signal to every process in the container when asked to", func() {
+			fakeRuncClient.
+				EXPECT().
+				SignalAllProcesses(expectedContainerID, client.Usr1).
+				Return(nil)
+
+			setupMockDefaults()
+			err := runcLifecycle.SignalProcess(logger, bpmCfg, client.Usr1, true)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when signalling the container fails", func() {
+			It("returns an error", func() {
+				fakeRuncClient.
+					EXPECT().
+					SignalContainer(gomock.Any(), gomock.Any()).
+					Return(errors.New("fake test error"))
+
+				setupMockDefaults()
+				err := runcLifecycle.SignalProcess(logger, bpmCfg, client.Hup, false)
+				Expect(err).To(MatchError("fake test error"))
+			})
+		})
+	})
+
+	Describe("ReloadProcess", func() {
+		var (
+			runningSpec specs.Spec
+			bundleErr   error
+		)
+
+		BeforeEach(func() {
+			bundleErr = nil
+			procCfg.ReloadSignal = "HUP"
+			runningSpec = specs.Spec{
+				Process: &specs.Process{
+					Env: []string{"foo=bar"},
+				},
+				Version: "example-version",
+			}
+		})
+
+		JustBeforeEach(func() {
+			fakeRuncClient.
+				EXPECT().
+				BundleSpec(bpmCfg.BundlePath()).
+				Return(runningSpec, bundleErr).
+				AnyTimes()
+		})
+
+		It("sends the reload signal when the container is unchanged", func() {
+			fakeRuncClient.
+				EXPECT().
+				SignalContainer(expectedContainerID, client.Hup).
+				Return(nil)
+
+			setupMockDefaults()
+			err := runcLifecycle.ReloadProcess(logger, bpmCfg, procCfg)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when the process does not have a reload signal", func() {
+			BeforeEach(func() {
+				procCfg.ReloadSignal = ""
+			})
+
+			It("returns an error", func() {
+				setupMockDefaults()
+				err := runcLifecycle.ReloadProcess(logger, bpmCfg, procCfg)
+				Expect(err).To(MatchError("process does not have a reload_signal"))
+				Expect(lifecycle.IsRestartRequired(err)).To(BeFalse())
+			})
+		})
+
+		Context("when the mounts or limits of the container have changed", func() {
+			BeforeEach(func() {
+				limit := int64(10)
+				runningSpec.Mounts = []specs.Mount{{Destination: "/var/vcap/data/example"}}
+				runningSpec.Linux = &specs.Linux{
+					Resources: &specs.LinuxResources{Pids: &specs.LinuxPids{Limit: &limit}},
+				}
+			})
+
+			It("does not send the signal and says what changed", func() {
+				setupMockDefaults()
+				err := runcLifecycle.ReloadProcess(logger, bpmCfg, procCfg)
+				Expect(lifecycle.IsRestartRequired(err)).To(BeTrue())
+				Expect(err).To(MatchError("process must be restarted to apply changes to: mounts, limits"))
+			})
+		})
+
+		Context("when the environment is the same but in a different order", func() {
+			BeforeEach(func() {
+				runningSpec.Process.Env = []string{"foo=bar", "baz=qux"}
+				jobSpec.Process = &specs.Process{Env: []string{"baz=qux", "foo=bar"}}
+			})
+
+			It("sends the reload signal", func() {
+				fakeRuncClient.
+					EXPECT().
+					SignalContainer(expectedContainerID, client.Hup).
+					Return(nil)
+
+				setupMockDefaults()
+				err := runcLifecycle.ReloadProcess(logger, bpmCfg, procCfg)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when only the environment of the process has changed", func() {
+			BeforeEach(func() {
+				runningSpec.Process.Env = []string{"foo=baz"}
+			})
+
+			It("does not send the signal and says the process changed", func() {
+				setupMockDefaults()
+				err := runcLifecycle.ReloadProcess(logger, bpmCfg, procCfg)
+				Expect(lifecycle.IsRestartRequired(err)).To(BeTrue())
+				Expect(err).To(MatchError("process must be restarted to apply changes to: process"))
+			})
+		})
+
+		Context("when the capabilities or process of the container have changed", func() {
+			BeforeEach(func() {
+				runningSpec.Process.Env = []string{"foo=baz"}
+				runningSpec.Process.Capabilities = &specs.LinuxCapabilities{Bounding: []string{"CAP_NET_BIND_SERVICE"}}
+			})
+
+			It("does not send the signal and says what changed", func() {
+				setupMockDefaults()
+				err := runcLifecycle.ReloadProcess(logger, bpmCfg, procCfg)
+				Expect(lifecycle.IsRestartRequired(err)).To(BeTrue())
+				Expect(err).To(MatchError("process must be restarted to apply changes to: capabilities, process"))
+			})
+		})
+
+		Context("when the bundle cannot be read", func() {
+			BeforeEach(func() {
+				bundleErr = errors.New("fake test error")
+			})
+
+			It("returns an error", func() {
+				setupMockDefaults()
+				err := runcLifecycle.ReloadProcess(logger, bpmCfg, procCfg)
+				Expect(err).To(MatchError(ContainSubstring("fake test error")))
+				Expect(lifecycle.IsRestartRequired(err)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("UpdateProcess", func() {
+		var (
+			runningSpec specs.Spec
+			runningPids int64
+			desiredPids int64
+		)
+
+		pidsResources := func(limit *int64) *specs.LinuxResources {
+			return &specs.LinuxResources{Pids: &specs.LinuxPids{Limit: limit}}
+		}
+
+		BeforeEach(func() {
+			runningPids = 10
+			desiredPids = 20
+
+			runningSpec = specs.Spec{
+				Process: &specs.Process{Env: []string{"foo=bar"}},
+				Linux:   &specs.Linux{Resources: pidsResources(&runningPids)},
+				Version: "example-version",
+			}
+			jobSpec.Linux = &specs.Linux{Resources: pidsResources(&desiredPids)}
+		})
+
+		JustBeforeEach(func() {
+			fakeRuncClient.
+				EXPECT().
+				BundleSpec(bpmCfg.BundlePath()).
+				Return(runningSpec, nil).
+				AnyTimes()
+		})
+
+		It("applies the new limits to the container and records them in the bundle", func() {
+			fakeRuncClient.
+				EXPECT().
+				UpdateContainer(expectedContainerID, pidsResources(&desiredPids)).
+				Return(nil)
+			fakeRuncClient.
+				EXPECT().
+				CreateBundle(bpmCfg.BundlePath(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ string, spec specs.Spec, _ specs.User) error {
+					Expect(spec.Linux.Resources).To(Equal(pidsResources(&desiredPids)))
+					Expect(spec.Process.Env).To(Equal([]string{"foo=bar"}))
+					return nil
+				})
+
+			setupMockDefaults()
+			updated, pending, err := runcLifecycle.UpdateProcess(logger, bpmCfg, procCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeTrue())
+			Expect(pending).To(BeEmpty())
+		})
+
+		Context("when the limits have not changed", func() {
+			BeforeEach(func() {
+				desiredPids = runningPids
+			})
+
+			It("does not update the container", func() {
 				setupMockDefaults()
-				err := runcLifecycle.StopProcess(logger, bpmCfg, procCfg, exitTimeout)
-				Expect(err).To(Equal(expectedErr))
+				updated, _, err := runcLifecycle.UpdateProcess(logger, bpmCfg, procCfg)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(updated).To(BeFalse())
 			})
 		})
-	})
 
-	Describe("RemoveProcess", func() {
-		It("deletes the container", func() {
-			fakeRuncClient.
-				EXPECT().
-				DeleteContainer(expectedContainerID).
-				Times(1)
+		Context("when a limit has been removed", func() {
+			BeforeEach(func() {
+				jobSpec.Linux = &specs.Linux{}
+			})
 
-			setupMockDefaults()
-			err := runcLifecycle.RemoveProcess(logger, bpmCfg)
-			Expect(err).NotTo(HaveOccurred())
+			It("requires a restart", func() {
+				setupMockDefaults()
+				_, _, err := runcLifecycle.UpdateProcess(logger, bpmCfg, procCfg)
+				Expect(lifecycle.IsRestartRequired(err)).To(BeTrue())
+				Expect(err).To(MatchError("process must be restarted to apply changes to: removed pids.limit"))
+			})
 		})
 
-		It("destroys the bundle", func() {
-			bundlePath := filepath.Join(expectedSystemRoot, "data", "bpm", "bundles", expectedJobName, expectedProcName)
-			fakeRuncClient.
-				EXPECT().
-				DestroyBundle(bundlePath).
-				Times(1)
+		Context("when a limit nested within another has been removed", func() {
+			BeforeEach(func() {
+				limit := int64(200)
+				reservation := int64(100)
+				rate := uint64(1000)
+				device := specs.LinuxThrottleDevice{Rate: rate}
+				device.Major = 8
+
+				runningSpec.Linux.Resources = &specs.LinuxResources{
+					Memory:  &specs.LinuxMemory{Limit: &limit, Reservation: &reservation},
+					Unified: map[string]string{"memory.high": "150"},
+					BlockIO: &specs.LinuxBlockIO{
+						ThrottleReadBpsDevice: []specs.LinuxThrottleDevice{device},
+					},
+				}
+				jobSpec.Linux = &specs.Linux{Resources: &specs.LinuxResources{
+					Memory:  &specs.LinuxMemory{Limit: &limit},
+					BlockIO: &specs.LinuxBlockIO{},
+				}}
+			})
 
-			setupMockDefaults()
-			err := runcLifecycle.RemoveProcess(logger, bpmCfg)
-			Expect(err).NotTo(HaveOccurred())
+			It("requires a restart", func() {
+				setupMockDefaults()
+				_, _, err := runcLifecycle.UpdateProcess(logger, bpmCfg, procCfg)
+				Expect(lifecycle.IsRestartRequired(err)).To(BeTrue())
+				Expect(err).To(MatchError("process must be restarted to apply changes to: removed blockIO.throttleReadBpsDevice[8:0], removed memory.reservation, removed unified.memory.high"))
+			})
 		})
 
-		It("deletes the pidfile", func() {
-			setupMockDefaults()
-			err := runcLifecycle.RemoveProcess(logger, bpmCfg)
-			Expect(err).NotTo(HaveOccurred())
+		Context("when a limit on one device has been removed and another kept", func() {
+			BeforeEach(func() {
+				sda := specs.LinuxThrottleDevice{Rate: 1000}
+				sda.Major = 8
+				sdb := specs.LinuxThrottleDevice{Rate: 1000}
+				sdb.Major = 8
+				sdb.Minor = 16
+
+				runningSpec.Linux.Resources = &specs.LinuxResources{BlockIO: &specs.LinuxBlockIO{
+					ThrottleWriteIOPSDevice: []specs.LinuxThrottleDevice{sda, sdb},
+				}}
+				sdb.Rate = 2000
+				jobSpec.Linux = &specs.Linux{Resources: &specs.LinuxResources{BlockIO: &specs.LinuxBlockIO{
+					ThrottleWriteIOPSDevice: []specs.LinuxThrottleDevice{sdb},
+				}}}
+			})
 
-			Expect(fakeFileRemover.deletedFiles).To(ConsistOf(bpmCfg.PidFile().External()))
+			It("requires a restart", func() {
+				setupMockDefaults()
+				_, _, err := runcLifecycle.UpdateProcess(logger, bpmCfg, procCfg)
+				Expect(lifecycle.IsRestartRequired(err)).To(BeTrue())
+				Expect(err).To(MatchError("process must be restarted to apply changes to: removed blockIO.throttleWriteIOPSDevice[8:0]"))
+			})
 		})
 
-		Context("when the process name is the same as the job name", func() {
+		Context("when the process has changed as well as its limits", func() {
 			BeforeEach(func() {
-				bpmCfg = config.NewBPMConfig(boshEnv, expectedJobName, expectedJobName)
+				jobSpec.Process = &specs.Process{Env: []string{"foo=baz"}}
 			})
 
-			It("simplifies the container id", func() {
+			It("updates the limits and reports that the rest needs a restart", func() {
 				fakeRuncClient.
 					EXPECT().
-					DeleteContainer(jobid.Encode(expectedJobName)).
-					Times(1)
+					UpdateContainer(expectedContainerID, pidsResources(&desiredPids)).
+					Return(nil)
+				fakeRuncClient.
+					EXPECT().
+					CreateBundle(bpmCfg.BundlePath(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ string, spec specs.Spec, _ specs.User) error {
+						Expect(spec.Process.Env).To(Equal([]string{"foo=bar"}))
+						return nil
+					})
 
 				setupMockDefaults()
-				err := runcLifecycle.RemoveProcess(logger, bpmCfg)
+				updated, pending, err := runcLifecycle.UpdateProcess(logger, bpmCfg, procCfg)
 				Expect(err).NotTo(HaveOccurred())
+				Expect(updated).To(BeTrue())
+				Expect(pending).To(Equal([]string{"process"}))
 			})
 		})
 
-		Context("when deleting a container fails", func() {
-			var expectedErr error
-
+		Context("when limits which cannot be changed live have changed", func() {
 			BeforeEach(func() {
-				expectedErr = errors.New("an error")
-				fakeRuncClient.
-					EXPECT().
-					DeleteContainer(gomock.Any()).
-					Return(expectedErr)
+				jobSpec.Process = &specs.Process{
+					Env:     []string{"foo=bar"},
+					Rlimits: []specs.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 100, Soft: 100}},
+				}
+				jobSpec.Mounts = []specs.Mount{{Destination: "/var/vcap/data/example"}}
 			})
 
-			It("returns an error", func() {
+			It("requires a restart without updating anything", func() {
 				setupMockDefaults()
-				err := runcLifecycle.RemoveProcess(logger, bpmCfg)
-				Expect(err).To(Equal(expectedErr))
+				_, _, err := runcLifecycle.UpdateProcess(logger, bpmCfg, procCfg)
+				Expect(lifecycle.IsRestartRequired(err)).To(BeTrue())
+				Expect(err).To(MatchError("process must be restarted to apply changes to: mounts, open_files or core_file_size limits"))
 			})
 		})
 
-		Context("when destroying a bundle fails", func() {
+		Context("when the container cannot be updated", func() {
 			It("returns an error", func() {
-				expectedErr := errors.New("an error2")
 				fakeRuncClient.
 					EXPECT().
-					DestroyBundle(gomock.Any()).
-					Return(expectedErr)
+					UpdateContainer(gomock.Any(), gomock.Any()).
+					Return(errors.New("fake test error"))
 
 				setupMockDefaults()
-				err := runcLifecycle.RemoveProcess(logger, bpmCfg)
-				Expect(err).To(Equal(expectedErr))
+				_, _, err := runcLifecycle.UpdateProcess(logger, bpmCfg, procCfg)
+				Expect(err).To(MatchError(ContainSubstring("fake test error")))
 			})
 		})
 	})
@@ -715,6 +1956,8 @@ var _ = Describe("RuncJobLifecycle", func() {
 					ID:             "job-process-1",
 					InitProcessPid: 34567,
 					Status:         "running",
+					Bundle:         "/path/to/bundle",
+					Created:        time.Unix(1500000000, 0),
 				},
 				{
 					ID:             "job-process-3",
@@ -733,11 +1976,43 @@ var _ = Describe("RuncJobLifecycle", func() {
 
 			Expect(bpmJobs).To(ConsistOf([]*models.Process{
 				{Name: "job-process-2", Pid: 23456, Status: "created"},
-				{Name: "job-process-1", Pid: 34567, Status: "running"},
+				{Name: "job-process-1", Pid: 34567, Status: "running", StartTime: time.Unix(1500000000, 0), BundlePath: "/path/to/bundle"},
 				{Name: "job-process-3", Pid: 0, Status: "failed"},
 			}))
 		})
 
+		It("leaves out the containers of hooks", func() {
+			fakeRuncClient.
+				EXPECT().
+				ListContainers().
+				Return([]client.ContainerState{
+					{ID: jobid.Encode("job.process"), InitProcessPid: 34567, Status: "running"},
+					{ID: jobid.Encode("job.process+post-start"), InitProcessPid: 45678, Status: "running"},
+				}, nil)
+
+			setupMockDefaults()
+			bpmJobs, err := runcLifecycle.ListProcesses()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bpmJobs).To(ConsistOf(&models.Process{Name: jobid.Encode("job.process"), Pid: 34567, Status: "running"}))
+		})
+
+		It("reports whether a stopped process was killed by the OOM killer", func() {
+			fakeRuncClient.
+				EXPECT().
+				ListContainers().
+				Return([]client.ContainerState{{ID: "job-process-3", Status: "stopped"}}, nil)
+
+			fakeRuncClient.
+				EXPECT().
+				CgroupPaths("job-process-3").
+				Return(oomKilledCgroup(), nil)
+
+			setupMockDefaults()
+			bpmJobs, err := runcLifecycle.ListProcesses()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bpmJobs).To(ConsistOf(&models.Process{Name: "job-process-3", Status: "failed", OOMKilled: true}))
+		})
+
 		Context("when listing jobs fails", func() {
 			It("returns an error", func() {
 				expectedErr := errors.New("list jobs error")
@@ -848,6 +2123,229 @@ var _ = Describe("RuncJobLifecycle", func() {
 		})
 	})
 
+	Describe("CgroupPaths", func() {
+		It("returns the cgroup paths which runc recorded for the container", func() {
+			fakeRuncClient.
+				EXPECT().
+				CgroupPaths(expectedContainerID).
+				Return(map[string]string{"": "/sys/fs/cgroup/example"}, nil)
+
+			setupMockDefaults()
+			paths, err := runcLifecycle.CgroupPaths(bpmCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal(map[string]string{"": "/sys/fs/cgroup/example"}))
+		})
+
+		Context("when runc has no state for the container", func() {
+			It("returns an 'IsNotExist' error", func() {
+				setupMockDefaults()
+				_, err := runcLifecycle.CgroupPaths(bpmCfg)
+				Expect(lifecycle.IsNotExist(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("RenderSpec", func() {
+		It("builds the spec after checking the job prerequisites", func() {
+			fakeRuncAdapter.
+				EXPECT().
+				CheckJobPrerequisites(bpmCfg, procCfg).
+				Return(nil)
+
+			setupMockDefaults()
+			spec, err := runcLifecycle.RenderSpec(logger, bpmCfg, procCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec).To(Equal(jobSpec))
+		})
+
+		Context("when the job prerequisites cannot be met", func() {
+			It("returns an error", func() {
+				fakeRuncAdapter.
+					EXPECT().
+					CheckJobPrerequisites(bpmCfg, procCfg).
+					Return(errors.New("fake test error"))
+
+				setupMockDefaults()
+				_, err := runcLifecycle.RenderSpec(logger, bpmCfg, procCfg)
+				Expect(err).To(MatchError("failed to check system files: fake test error"))
+			})
+		})
+	})
+
+	Describe("InspectProcess", func() {
+		var (
+			bundleSpec specs.Spec
+			bundleErr  error
+			memory     int64
+		)
+
+		BeforeEach(func() {
+			memory = 1024
+			bundleErr = nil
+
+			bundleSpec = specs.Spec{
+				Process: &specs.Process{
+					Args:         []string{"/var/vcap/packages/bpm/bin/tini", "-w", "--", "/var/vcap/jobs/example/bin/server", "--port", "2424"},
+					Env:          []string{"foo=bar"},
+					User:         specs.User{UID: 300, GID: 400},
+					Cwd:          "/var/vcap/jobs/example",
+					Capabilities: &specs.LinuxCapabilities{Bounding: []string{"CAP_NET_BIND_SERVICE"}},
+					Rlimits:      []specs.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 100, Soft: 100}},
+				},
+				Mounts: []specs.Mount{
+					{Destination: "/var/vcap/jobs/example", Source: "/var/vcap/jobs/example", Type: "bind", Options: []string{"rbind", "ro", "noexec"}},
+					{Destination: "/var/vcap/data/example", Source: "/var/vcap/data/example", Type: "bind", Options: []string{"rbind", "rw"}},
+				},
+				Linux: &specs.Linux{
+					Namespaces: []specs.LinuxNamespace{{Type: specs.IPCNamespace}, {Type: specs.MountNamespace}},
+					Seccomp:    &specs.LinuxSeccomp{},
+					Resources:  &specs.LinuxResources{Memory: &specs.LinuxMemory{Limit: &memory}},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			fakeRuncClient.
+				EXPECT().
+				BundleSpec(bpmCfg.BundlePath()).
+				Return(bundleSpec, bundleErr).
+				AnyTimes()
+		})
+
+		It("describes the container from its bundle and state", func() {
+			fakeRuncClient.
+				EXPECT().
+				ContainerState(expectedContainerID).
+				Return(&specs.State{ID: expectedContainerID, Pid: 1234, Status: "running"}, nil)
+
+			setupMockDefaults()
+			inspection, err := runcLifecycle.InspectProcess(bpmCfg)
+			Expect(err).NotTo(HaveOccurred())
+
+			openFiles := uint64(100)
+			Expect(inspection).To(Equal(&models.ProcessInspection{
+				Name:         "example.server",
+				Job:          expectedJobName,
+				Process:      expectedProcName,
+				Status:       "running",
+				Pid:          1234,
+				Bundle:       bpmCfg.BundlePath(),
+				Executable:   "/var/vcap/packages/bpm/bin/tini",
+				Args:         []string{"-w", "--", "/var/vcap/jobs/example/bin/server", "--port", "2424"},
+				Env:          []string{"foo=bar"},
+				User:         "300:400",
+				WorkDir:      "/var/vcap/jobs/example",
+				Capabilities: []string{"CAP_NET_BIND_SERVICE"},
+				Namespaces:   []string{"ipc", "mount"},
+				Seccomp:      true,
+				Mounts: []models.MountInspection{
+					{Destination: "/var/vcap/jobs/example", Source: "/var/vcap/jobs/example", Type: "bind"},
+					{Destination: "/var/vcap/data/example", Source: "/var/vcap/data/example", Type: "bind", Writable: true, AllowExecutions: true},
+				},
+				Limits: models.LimitsInspection{
+					MemoryBytes: &memory,
+					OpenFiles:   &openFiles,
+				},
+			}))
+		})
+
+		Context("when the container has memory, swap, and I/O limits", func() {
+			var (
+				reservation int64
+				swap        int64
+				oomScoreAdj int
+				weight      uint16
+			)
+
+			BeforeEach(func() {
+				reservation = 512
+				swap = 3072
+				oomScoreAdj = 500
+				weight = 100
+
+				readBPS := specs.LinuxThrottleDevice{Rate: 1048576}
+				readBPS.Major = 8
+				writeIOPS := specs.LinuxThrottleDevice{Rate: 100}
+				writeIOPS.Major = 8
+				otherDevice := specs.LinuxThrottleDevice{Rate: 200}
+				otherDevice.Major = 8
+				otherDevice.Minor = 16
+
+				bundleSpec.Process.OOMScoreAdj = &oomScoreAdj
+				bundleSpec.Linux.Resources = &specs.LinuxResources{
+					Memory:  &specs.LinuxMemory{Limit: &memory, Reservation: &reservation, Swap: &swap},
+					Unified: map[string]string{"memory.high": "768"},
+					BlockIO: &specs.LinuxBlockIO{
+						Weight:                  &weight,
+						ThrottleReadBpsDevice:   []specs.LinuxThrottleDevice{readBPS},
+						ThrottleWriteIOPSDevice: []specs.LinuxThrottleDevice{writeIOPS, otherDevice},
+					},
+				}
+			})
+
+			It("includes them in the limits", func() {
+				fakeRuncClient.
+					EXPECT().
+					ContainerState(expectedContainerID).
+					Return(&specs.State{ID: expectedContainerID, Pid: 1234, Status: "running"}, nil)
+
+				setupMockDefaults()
+				inspection, err := runcLifecycle.InspectProcess(bpmCfg)
+				Expect(err).NotTo(HaveOccurred())
+
+				high := int64(768)
+				swapOnly := int64(2048)
+				readRate := uint64(1048576)
+				writeRate := uint64(100)
+				otherRate := uint64(200)
+				openFiles := uint64(100)
+				Expect(inspection.Limits).To(Equal(models.LimitsInspection{
+					MemoryBytes:            &memory,
+					MemoryReservationBytes: &reservation,
+					MemoryHighBytes:        &high,
+					SwapBytes:              &swapOnly,
+					OOMScoreAdj:            &oomScoreAdj,
+					IOWeight:               &weight,
+					IODevices: []models.IODeviceInspection{
+						{Device: "8:0", ReadBPS: &readRate, WriteIOPS: &writeRate},
+						{Device: "8:16", WriteIOPS: &otherRate},
+					},
+					OpenFiles: &openFiles,
+				}))
+			})
+		})
+
+		Context("when the container does not exist", func() {
+			It("returns an 'IsNotExist' error", func() {
+				fakeRuncClient.
+					EXPECT().
+					ContainerState(expectedContainerID).
+					Return(nil, nil)
+
+				setupMockDefaults()
+				_, err := runcLifecycle.InspectProcess(bpmCfg)
+				Expect(lifecycle.IsNotExist(err)).To(BeTrue())
+			})
+		})
+
+		Context("when the bundle cannot be read", func() {
+			BeforeEach(func() {
+				bundleErr = errors.New("fake test error")
+			})
+
+			It("returns an error", func() {
+				fakeRuncClient.
+					EXPECT().
+					ContainerState(expectedContainerID).
+					Return(&specs.State{ID: expectedContainerID, Status: "running"}, nil)
+
+				setupMockDefaults()
+				_, err := runcLifecycle.InspectProcess(bpmCfg)
+				Expect(err).To(MatchError("failed to read bundle: fake test error"))
+			})
+		})
+	})
+
 	Describe("OpenShell", func() {
 		var expectedStdin *gbytes.Buffer
 
@@ -855,15 +2353,83 @@ var _ = Describe("RuncJobLifecycle", func() {
 			expectedStdin = gbytes.BufferWithBytes([]byte("stdin"))
 		})
 
+		expectBash := func(containerID string, present bool) {
+			var err error
+			if !present {
+				err = errors.New("exec failed: no such file or directory")
+			}
+
+			fakeRuncClient.
+				EXPECT().
+				ExecCommand(gomock.Any(), containerID, []string{"/bin/bash", "-c", "true"}, gomock.Any(), gomock.Any()).
+				Return(err)
+		}
+
 		It("execs /bin/bash inside the container", func() {
+			expectBash(expectedContainerID, true)
 			fakeRuncClient.
 				EXPECT().
-				Exec(expectedContainerID, "/bin/bash", expectedStdin, expectedStdout, expectedStderr).
+				Exec(expectedContainerID, []string{"/bin/bash"}, client.ExecOptions{TTY: true}, expectedStdin, expectedStdout, expectedStderr).
 				Times(1)
 
 			setupMockDefaults()
-			err := runcLifecycle.OpenShell(bpmCfg, expectedStdin, expectedStdout, expectedStderr)
+			err := runcLifecycle.OpenShell(logger, bpmCfg, lifecycle.ShellOptions{}, expectedStdin, expectedStdout, expectedStderr)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("records the shell session in the log", func() {
+			expectBash(expectedContainerID, true)
+			fakeRuncClient.
+				EXPECT().
+				Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(string, []string, client.ExecOptions, io.Reader, io.Writer, io.Writer) (int, error) {
+					fakeClock.Increment(90 * time.Second)
+					return 0, nil
+				})
+
+			setupMockDefaults()
+			err := runcLifecycle.OpenShell(logger, bpmCfg, lifecycle.ShellOptions{}, expectedStdin, expectedStdout, expectedStderr)
 			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.LogMessages()).To(Equal([]string{"lifecycle.shell-started", "lifecycle.shell-exited"}))
+			exited := logger.Logs()[1]
+			Expect(exited.Data).To(HaveKeyWithValue("shell", "/bin/bash"))
+			Expect(exited.Data).To(HaveKeyWithValue("duration", "1m30s"))
+			Expect(exited.Data).To(HaveKeyWithValue("exit_status", BeNumerically("==", 0)))
+		})
+
+		Context("when bash is not present in the container", func() {
+			It("falls back to /bin/sh", func() {
+				expectBash(expectedContainerID, false)
+				fakeRuncClient.
+					EXPECT().
+					Exec(expectedContainerID, []string{"/bin/sh"}, client.ExecOptions{TTY: true}, expectedStdin, expectedStdout, expectedStderr).
+					Times(1)
+
+				setupMockDefaults()
+				err := runcLifecycle.OpenShell(logger, bpmCfg, lifecycle.ShellOptions{}, expectedStdin, expectedStdout, expectedStderr)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when a shell and user are given", func() {
+			It("runs that shell as that user", func() {
+				fakeUserFinder.
+					EXPECT().
+					Lookup("root").
+					Return(specs.User{Username: "root", UID: 0, GID: 0}, nil)
+				fakeRuncClient.
+					EXPECT().
+					Exec(expectedContainerID, []string{"/bin/zsh"}, client.ExecOptions{TTY: true, User: "0:0"}, expectedStdin, expectedStdout, expectedStderr).
+					Times(1)
+
+				setupMockDefaults()
+				opts := lifecycle.ShellOptions{Shell: "/bin/zsh", User: "root"}
+				err := runcLifecycle.OpenShell(logger, bpmCfg, opts, expectedStdin, expectedStdout, expectedStderr)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.Logs()[0].Data).To(HaveKeyWithValue("user", "root"))
+			})
 		})
 
 		Context("when the process name is the same as the job name", func() {
@@ -872,31 +2438,94 @@ var _ = Describe("RuncJobLifecycle", func() {
 			})
 
 			It("simplifies the container id", func() {
+				expectBash(jobid.Encode(expectedJobName), true)
 				fakeRuncClient.
 					EXPECT().
-					Exec(jobid.Encode(expectedJobName), "/bin/bash", expectedStdin, expectedStdout, expectedStderr).
+					Exec(jobid.Encode(expectedJobName), []string{"/bin/bash"}, client.ExecOptions{TTY: true}, expectedStdin, expectedStdout, expectedStderr).
 					Times(1)
 				setupMockDefaults()
-				err := runcLifecycle.OpenShell(bpmCfg, expectedStdin, expectedStdout, expectedStderr)
+				err := runcLifecycle.OpenShell(logger, bpmCfg, lifecycle.ShellOptions{}, expectedStdin, expectedStdout, expectedStderr)
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
 
 		Context("when the exec command fails", func() {
 			BeforeEach(func() {
+				expectBash(expectedContainerID, true)
 				fakeRuncClient.
 					EXPECT().
-					Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-					Return(errors.New("fake test error"))
+					Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(1, errors.New("fake test error"))
 			})
 
 			It("returns an error", func() {
 				setupMockDefaults()
-				err := runcLifecycle.OpenShell(bpmCfg, expectedStdin, expectedStdout, expectedStderr)
+				err := runcLifecycle.OpenShell(logger, bpmCfg, lifecycle.ShellOptions{}, expectedStdin, expectedStdout, expectedStderr)
 				Expect(err).To(HaveOccurred())
 			})
 		})
 	})
+
+	Describe("ExecProcess", func() {
+		var expectedStdin *gbytes.Buffer
+
+		BeforeEach(func() {
+			expectedStdin = gbytes.BufferWithBytes([]byte("stdin"))
+		})
+
+		It("runs the command inside the container", func() {
+			fakeRuncClient.
+				EXPECT().
+				Exec(expectedContainerID, []string{"/bin/ls", "-l"}, client.ExecOptions{Env: []string{"FOO=bar"}, Cwd: "/tmp"}, expectedStdin, expectedStdout, expectedStderr).
+				Return(0, nil)
+
+			setupMockDefaults()
+			opts := lifecycle.ExecOptions{Env: []string{"FOO=bar"}, Cwd: "/tmp"}
+			status, err := runcLifecycle.ExecProcess(bpmCfg, []string{"/bin/ls", "-l"}, opts, expectedStdin, expectedStdout, expectedStderr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal(0))
+		})
+
+		It("runs the command as the given user", func() {
+			fakeUserFinder.
+				EXPECT().
+				Lookup("root").
+				Return(specs.User{Username: "root", UID: 0, GID: 0}, nil)
+			fakeRuncClient.
+				EXPECT().
+				Exec(expectedContainerID, []string{"/bin/ls"}, client.ExecOptions{User: "0:0"}, expectedStdin, expectedStdout, expectedStderr).
+				Return(0, nil)
+
+			setupMockDefaults()
+			_, err := runcLifecycle.ExecProcess(bpmCfg, []string{"/bin/ls"}, lifecycle.ExecOptions{User: "root"}, expectedStdin, expectedStdout, expectedStderr)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns the exit status of the command", func() {
+			fakeRuncClient.
+				EXPECT().
+				Exec(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(3, errors.New("exit status 3"))
+
+			setupMockDefaults()
+			status, err := runcLifecycle.ExecProcess(bpmCfg, []string{"/bin/false"}, lifecycle.ExecOptions{}, expectedStdin, expectedStdout, expectedStderr)
+			Expect(err).To(HaveOccurred())
+			Expect(status).To(Equal(3))
+		})
+
+		Context("when the user cannot be found", func() {
+			It("returns an error without running the command", func() {
+				fakeUserFinder.
+					EXPECT().
+					Lookup("nobody-here").
+					Return(specs.User{}, errors.New("unknown user"))
+
+				setupMockDefaults()
+				_, err := runcLifecycle.ExecProcess(bpmCfg, []string{"/bin/ls"}, lifecycle.ExecOptions{User: "nobody-here"}, expectedStdin, expectedStdout, expectedStderr)
+				Expect(err).To(MatchError(ContainSubstring("unknown user")))
+			})
+		})
+	})
 })
 
 type fileRemover struct {
@@ -0,0 +1,98 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+
+	"bpm/config"
+	"bpm/procstats"
+	"bpm/usertools"
+)
+
+var oomKilledError = errors.New("process was killed by the OOM killer") //nolint:staticcheck
+
+// IsOOMKilled returns whether the error returned by RunProcess means that the
+// process was killed by the OOM killer.
+func IsOOMKilled(err error) bool {
+	return err == oomKilledError
+}
+
+// isOOMKilled returns whether any process in the container has been killed by
+// the OOM killer. A container which has already been deleted cannot be
+// checked and so is assumed not to have been.
+func (j *RuncLifecycle) isOOMKilled(containerID string) (bool, error) {
+	paths, err := j.runcClient.CgroupPaths(containerID)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	kills, err := procstats.OOMKills(paths)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return kills > 0, nil
+}
+
+// checkOOMKilled is isOOMKilled for a container which is about to be deleted.
+// An OOM kill is logged and noted in the standard error log of the job, which
+// is opened if stderr is nil, so that it is not mistaken for a crash.
+func (j *RuncLifecycle) checkOOMKilled(logger lager.Logger, cfg *config.BPMConfig, stderr io.Writer) bool {
+	killed, err := j.isOOMKilled(cfg.ContainerID())
+	if err != nil {
+		logger.Error("failed-to-check-for-oom-kill", err)
+		return false
+	}
+
+	if !killed {
+		return false
+	}
+
+	logger.Error("oom-killed", oomKilledError)
+
+	if stderr == nil {
+		user, err := j.userFinder.Lookup(usertools.VcapUser)
+		if err != nil {
+			logger.Error("failed-to-open-log-files", err)
+			return true
+		}
+
+		stdout, stderrLog, err := j.runcAdapter.OpenLogFiles(cfg, user)
+		if err != nil {
+			logger.Error("failed-to-open-log-files", err)
+			return true
+		}
+		defer stdout.Close()    //nolint:errcheck
+		defer stderrLog.Close() //nolint:errcheck
+
+		stderr = stderrLog
+	}
+
+	fmt.Fprintf(stderr, "[%s] bpm: %s\n", j.clock.Now().UTC().Format(time.RFC3339), oomKilledError) //nolint:errcheck
+
+	return true
+}
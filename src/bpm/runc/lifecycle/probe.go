@@ -0,0 +1,193 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/lager/v3"
+
+	"bpm/config"
+)
+
+// probeClient does not follow redirects so that they are treated as a
+// successful response rather than probing wherever they point.
+var probeClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+var errProcessNotRunning = errors.New("process is not running")
+
+// WaitForReady runs the readiness probe of the process until it succeeds. An
+// error is returned if the probe fails FailureThreshold times in a row or if
+// the process stops running before it becomes ready. It returns immediately if
+// the process does not have a readiness probe.
+func (j *RuncLifecycle) WaitForReady(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+	probe := procCfg.Readiness
+	if probe == nil {
+		return nil
+	}
+
+	logger = logger.Session("wait-for-ready")
+	logger.Info("starting")
+	defer logger.Info("complete")
+
+	attempts, err := j.runProbe(logger, bpmCfg, probe, probe.Threshold(config.DefaultReadinessFailureThreshold))
+	if err == errProcessNotRunning {
+		err := errors.New("process stopped before becoming ready")
+		logger.Error("process-not-running", err)
+		return err
+	} else if err != nil {
+		return fmt.Errorf("process did not become ready after %d attempts: %s", attempts, err)
+	}
+
+	logger.Info("ready")
+	return nil
+}
+
+// CheckHealth runs the liveness probe of the process. An error describing why
+// the process is unhealthy is returned if it is not running or if the probe
+// fails FailureThreshold times in a row. A running process without a liveness
+// probe is considered healthy. Only failed attempts and the outcome of an
+// unhealthy check are logged as this is run by monit every cycle.
+func (j *RuncLifecycle) CheckHealth(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+	logger = logger.Session("check-health")
+
+	probe := procCfg.Liveness
+	threshold := config.DefaultLivenessFailureThreshold
+	if probe != nil {
+		threshold = probe.Threshold(threshold)
+	}
+
+	_, err := j.runProbe(logger, bpmCfg, probe, threshold)
+	if err != nil && err != errProcessNotRunning {
+		err = fmt.Errorf("liveness probe failed: %s", err)
+	}
+
+	if err != nil {
+		logger.Error("unhealthy", err)
+	}
+
+	return err
+}
+
+// runProbe attempts the probe until it succeeds or has failed threshold times
+// in a row, checking that the process is still running before each attempt. A
+// nil probe only checks that the process is running. It returns the number of
+// attempts which were made and the error from the last one.
+func (j *RuncLifecycle) runProbe(logger lager.Logger, bpmCfg *config.BPMConfig, probe *config.Probe, threshold int) (int, error) {
+	for attempts := 1; ; attempts++ {
+		state, err := j.runcClient.ContainerState(bpmCfg.ContainerID())
+		if err != nil {
+			return attempts, err
+		}
+
+		if state == nil || state.Status != ContainerStateRunning {
+			return attempts, errProcessNotRunning
+		}
+
+		if probe == nil {
+			return attempts, nil
+		}
+
+		err = j.checkProbe(bpmCfg, probe)
+		if err == nil {
+			return attempts, nil
+		}
+
+		logger.Info("probe-failed", lager.Data{
+			"failures": attempts,
+			"error":    err.Error(),
+		})
+
+		if attempts >= threshold {
+			return attempts, err
+		}
+
+		j.clock.Sleep(probe.ParseInterval())
+	}
+}
+
+// checkProbe makes a single attempt of the probe. The probe fails if the
+// attempt takes longer than the timeout of the probe.
+func (j *RuncLifecycle) checkProbe(bpmCfg *config.BPMConfig, probe *config.Probe) error {
+	ctx, cancel := context.WithTimeout(context.Background(), probe.ParseTimeout())
+	defer cancel()
+
+	switch {
+	case probe.TCP != nil:
+		return dialProbe(ctx, "tcp", localhost(probe.TCP.Port))
+	case probe.HTTP != nil:
+		return httpProbe(ctx, probe.HTTP)
+	case probe.Socket != "":
+		return dialProbe(ctx, "unix", filepath.Join(bpmCfg.SocketDir().External(), probe.Socket))
+	default:
+		var output bytes.Buffer
+		err := j.runcClient.ExecCommand(ctx, bpmCfg.ContainerID(), probe.Exec, &output, &output)
+		if err != nil {
+			return fmt.Errorf("%s: %s", err, strings.TrimSpace(output.String()))
+		}
+		return nil
+	}
+}
+
+func dialProbe(ctx context.Context, network, address string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+func httpProbe(ctx context.Context, probe *config.HTTPProbe) error {
+	path := probe.Path
+	if path == "" {
+		path = "/"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+localhost(probe.Port)+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func localhost(port int) string {
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+}
@@ -0,0 +1,171 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"code.cloudfoundry.org/lager/v3"
+
+	"bpm/config"
+	"bpm/usertools"
+)
+
+var noReloadSignalError = errors.New("process does not have a reload_signal") //nolint:staticcheck
+
+// restartRequiredError is returned by ReloadProcess when the configuration of
+// the process has changed in a way which cannot be applied by reloading it.
+type restartRequiredError struct {
+	changes []string
+}
+
+func (e restartRequiredError) Error() string {
+	return fmt.Sprintf("process must be restarted to apply changes to: %s", strings.Join(e.changes, ", "))
+}
+
+// IsRestartRequired returns whether the error returned by ReloadProcess means
+// that the process must be restarted instead.
+func IsRestartRequired(err error) bool {
+	var rerr restartRequiredError
+	return errors.As(err, &rerr)
+}
+
+// ReloadProcess sends the reload signal of the process to it if the container
+// which the current configuration would give the process is the same as the
+// one it is running in. Otherwise the signal is not sent and an error for
+// which IsRestartRequired is true is returned.
+func (j *RuncLifecycle) ReloadProcess(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) error {
+	signal, ok := procCfg.ParseReloadSignal()
+	if !ok {
+		return noReloadSignalError
+	}
+
+	changes, err := j.containerChanges(logger, bpmCfg, procCfg)
+	if err != nil {
+		return fmt.Errorf("failed to compare configuration with running container: %s", err)
+	}
+
+	if len(changes) > 0 {
+		logger.Info("restart-required", lager.Data{"changes": changes})
+		return restartRequiredError{changes: changes}
+	}
+
+	return j.SignalProcess(logger, bpmCfg, signal, false)
+}
+
+// containerChanges returns which parts of the container ("mounts", "limits",
+// "capabilities", or "process" for anything else, such as the arguments or
+// environment) the current configuration would change compared to the bundle
+// the process is running from. Configuration which is not part of the
+// container, such as hooks and probes, is never a change.
+func (j *RuncLifecycle) containerChanges(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) ([]string, error) {
+	running, desired, err := j.containerSpecs(logger, bpmCfg, procCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return specChanges(running, desired)
+}
+
+// containerSpecs returns the spec of the bundle the process is running from
+// and the spec which the current configuration would give it.
+func (j *RuncLifecycle) containerSpecs(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) (specs.Spec, specs.Spec, error) {
+	user, err := j.userFinder.Lookup(usertools.VcapUser)
+	if err != nil {
+		return specs.Spec{}, specs.Spec{}, err
+	}
+
+	desired, err := j.runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+	if err != nil {
+		return specs.Spec{}, specs.Spec{}, err
+	}
+
+	running, err := j.runcClient.BundleSpec(bpmCfg.BundlePath())
+	if err != nil {
+		return specs.Spec{}, specs.Spec{}, err
+	}
+
+	return running, desired, nil
+}
+
+func specChanges(running, desired specs.Spec) ([]string, error) {
+	// Both specs are passed through JSON so that a spec which was read back
+	// from a bundle can be compared with one which was built in memory.
+	a, err := normalizeSpec(running)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := normalizeSpec(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	// The environment is built from a map so its order is not meaningful.
+	sort.Strings(a.Process.Env)
+	sort.Strings(b.Process.Env)
+
+	var changes []string
+	if !reflect.DeepEqual(a.Mounts, b.Mounts) {
+		changes = append(changes, "mounts")
+	}
+	if !reflect.DeepEqual(a.Linux.Resources, b.Linux.Resources) || !reflect.DeepEqual(a.Process.Rlimits, b.Process.Rlimits) {
+		changes = append(changes, "limits")
+	}
+	if !reflect.DeepEqual(a.Process.Capabilities, b.Process.Capabilities) {
+		changes = append(changes, "capabilities")
+	}
+
+	for _, s := range []*specs.Spec{a, b} {
+		s.Mounts = nil
+		s.Linux.Resources = nil
+		s.Process.Rlimits = nil
+		s.Process.Capabilities = nil
+	}
+	if !reflect.DeepEqual(a, b) {
+		changes = append(changes, "process")
+	}
+
+	return changes, nil
+}
+
+func normalizeSpec(spec specs.Spec) (*specs.Spec, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized specs.Spec
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+
+	if normalized.Process == nil {
+		normalized.Process = &specs.Process{}
+	}
+	if normalized.Linux == nil {
+		normalized.Linux = &specs.Linux{}
+	}
+
+	return &normalized, nil
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+
+	"bpm/config"
+)
+
+const (
+	// DefaultShell is the shell opened by OpenShell if it is present in the
+	// container.
+	DefaultShell = "/bin/bash"
+
+	// FallbackShell is the shell opened by OpenShell if DefaultShell is not
+	// present in the container.
+	FallbackShell = "/bin/sh"
+
+	// shellProbeTimeout is how long checking whether DefaultShell is present
+	// in the container may take.
+	shellProbeTimeout = 10 * time.Second
+)
+
+// ShellOptions configures the shell opened with OpenShell.
+type ShellOptions struct {
+	// Shell is the path of the shell to run. DefaultShell, or FallbackShell
+	// if it is not present in the container, is used when it is empty.
+	Shell string
+
+	// User is the name of the user to run the shell as. The user of the
+	// process is used when it is empty.
+	User string
+}
+
+// OpenShell runs an interactive shell in the container of a running process
+// and waits for it to exit. The start and end of the session are logged so
+// that they can be audited.
+func (j *RuncLifecycle) OpenShell(logger lager.Logger, cfg *config.BPMConfig, opts ShellOptions, stdin io.Reader, stdout, stderr io.Writer) error {
+	shell := opts.Shell
+	if shell == "" {
+		shell = j.defaultShell(cfg)
+	}
+
+	data := lager.Data{"shell": shell}
+	if opts.User != "" {
+		data["user"] = opts.User
+	}
+
+	logger.Info("shell-started", data)
+	start := j.clock.Now()
+
+	status, err := j.ExecProcess(cfg, []string{shell}, ExecOptions{TTY: true, User: opts.User}, stdin, stdout, stderr)
+
+	data["duration"] = j.clock.Since(start).String()
+	data["exit_status"] = status
+	logger.Info("shell-exited", data)
+
+	return err
+}
+
+// defaultShell returns DefaultShell if it can be run in the container of the
+// process and FallbackShell otherwise.
+func (j *RuncLifecycle) defaultShell(cfg *config.BPMConfig) string {
+	ctx, cancel := context.WithTimeout(context.Background(), shellProbeTimeout)
+	defer cancel()
+
+	err := j.runcClient.ExecCommand(ctx, cfg.ContainerID(), []string{DefaultShell, "-c", "true"}, io.Discard, io.Discard)
+	if err != nil {
+		return FallbackShell
+	}
+
+	return DefaultShell
+}
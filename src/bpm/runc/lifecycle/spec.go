@@ -0,0 +1,41 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/lager/v3"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"bpm/config"
+	"bpm/usertools"
+)
+
+// RenderSpec builds the spec which the process would be started with without
+// creating any of its directories, log files, or bundle.
+func (j *RuncLifecycle) RenderSpec(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) (specs.Spec, error) {
+	user, err := j.userFinder.Lookup(usertools.VcapUser)
+	if err != nil {
+		return specs.Spec{}, err
+	}
+
+	if err := j.runcAdapter.CheckJobPrerequisites(bpmCfg, procCfg); err != nil {
+		return specs.Spec{}, fmt.Errorf("failed to check system files: %s", err)
+	}
+
+	return j.runcAdapter.BuildSpec(logger, bpmCfg, procCfg, user)
+}
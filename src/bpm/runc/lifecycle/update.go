@@ -0,0 +1,181 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"code.cloudfoundry.org/lager/v3"
+
+	"bpm/config"
+)
+
+// UpdateProcess applies changes to the resource limits in the configuration
+// of the process to its running container without restarting it and returns
+// whether there were any. Other changes which only a restart can apply, such
+// as to the arguments or environment of the process, do not stop the limits
+// from being updated and are returned so that they can be reported. An error
+// for which IsRestartRequired is true is returned, and nothing is updated, if
+// the open_files or core_file_size limits (which are fixed when the process
+// starts) or the mounts changed, or if a limit was removed.
+func (j *RuncLifecycle) UpdateProcess(logger lager.Logger, bpmCfg *config.BPMConfig, procCfg *config.ProcessConfig) (bool, []string, error) {
+	running, desired, err := j.containerSpecs(logger, bpmCfg, procCfg)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to compare configuration with running container: %s", err)
+	}
+
+	changes, err := specChanges(running, desired)
+	if err != nil {
+		return false, nil, err
+	}
+
+	a, err := normalizeSpec(running)
+	if err != nil {
+		return false, nil, err
+	}
+
+	b, err := normalizeSpec(desired)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var unsupported, pending []string
+	for _, change := range changes {
+		switch change {
+		case "limits":
+		case "mounts":
+			unsupported = append(unsupported, change)
+		default:
+			pending = append(pending, change)
+		}
+	}
+	if !reflect.DeepEqual(a.Process.Rlimits, b.Process.Rlimits) {
+		unsupported = append(unsupported, "open_files or core_file_size limits")
+	}
+
+	removed, err := removedResources(a.Linux.Resources, b.Linux.Resources)
+	if err != nil {
+		return false, nil, err
+	}
+	unsupported = append(unsupported, removed...)
+
+	if len(unsupported) > 0 {
+		logger.Info("restart-required", lager.Data{"changes": unsupported})
+		return false, nil, restartRequiredError{changes: unsupported}
+	}
+
+	if len(pending) > 0 {
+		logger.Info("other-changes-need-restart", lager.Data{"changes": pending})
+	}
+
+	if reflect.DeepEqual(a.Linux.Resources, b.Linux.Resources) {
+		logger.Info("limits-unchanged")
+		return false, pending, nil
+	}
+
+	logger.Info("updating-limits")
+	if err := j.runcClient.UpdateContainer(bpmCfg.ContainerID(), b.Linux.Resources); err != nil {
+		return false, nil, fmt.Errorf("failed to update container: %s", err)
+	}
+
+	// The bundle is updated so that later comparisons, e.g. by
+	// ReloadProcess, are made against the limits which are in effect. The
+	// rest of it still describes the container as it was started.
+	a.Linux.Resources = b.Linux.Resources
+	if err := j.runcClient.CreateBundle(bpmCfg.BundlePath(), *a, a.Process.User); err != nil {
+		return true, pending, fmt.Errorf("limits were updated but could not be recorded in the bundle: %s", err)
+	}
+
+	return true, pending, nil
+}
+
+// removedResources returns a change for each resource limit (e.g.
+// memory.reservation) which is set in the running container but not in the
+// desired one. runc cannot remove a limit from a running container.
+func removedResources(running, desired *specs.LinuxResources) ([]string, error) {
+	r, err := resourceLimits(running)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := resourceLimits(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for limit := range r {
+		if !d[limit] {
+			removed = append(removed, fmt.Sprintf("removed %s", limit))
+		}
+	}
+	sort.Strings(removed)
+
+	return removed, nil
+}
+
+// resourceLimits returns the name of each limit which is set in the resources,
+// such as memory.limit or unified.memory.high. A device which is throttled is
+// named by its major and minor numbers, e.g.
+// blockIO.throttleReadBpsDevice[8:0].
+func resourceLimits(resources *specs.LinuxResources) (map[string]bool, error) {
+	limits := map[string]bool{}
+	if resources == nil {
+		return limits, nil
+	}
+
+	data, err := json.Marshal(resources)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+
+	collectLimits(limits, "", tree)
+
+	return limits, nil
+}
+
+func collectLimits(limits map[string]bool, name string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if name != "" {
+				key = name + "." + key
+			}
+			collectLimits(limits, key, child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			device, ok := child.(map[string]interface{})
+			if !ok || device["major"] == nil || device["minor"] == nil {
+				limits[name] = true
+				continue
+			}
+			limits[fmt.Sprintf("%s[%v:%v]", name, device["major"], device["minor"])] = true
+		}
+	default:
+		limits[name] = true
+	}
+}
@@ -16,6 +16,8 @@
 package specbuilder
 
 import (
+	"strconv"
+
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 
 	"bpm/sysfeat"
@@ -157,16 +159,50 @@ func WithMounts(mounts []specs.Mount) SpecOption {
 
 func WithMemoryLimit(limit int64, features sysfeat.Features) SpecOption {
 	return func(spec *specs.Spec) {
-		spec.Linux.Resources.Memory = &specs.LinuxMemory{
-			Limit: &limit,
-		}
+		memory := memoryResources(spec)
+		memory.Limit = &limit
 
 		if features.SwapLimitSupported {
-			spec.Linux.Resources.Memory.Swap = &limit
+			memory.Swap = &limit
 		}
 	}
 }
 
+// WithSwapLimit sets the combined memory and swap limit of the container. It
+// must be applied after WithMemoryLimit and should be at least as large as
+// the memory limit.
+func WithSwapLimit(limit int64) SpecOption {
+	return func(spec *specs.Spec) {
+		memoryResources(spec).Swap = &limit
+	}
+}
+
+// WithMemoryReservation sets a soft memory limit which the kernel tries to
+// reclaim the container back down to when the host is under memory pressure.
+// runc maps this onto memory.low on cgroup v2 hosts.
+func WithMemoryReservation(reservation int64) SpecOption {
+	return func(spec *specs.Spec) {
+		memoryResources(spec).Reservation = &reservation
+	}
+}
+
+// WithMemoryHigh sets the cgroup v2 memory.high throttling threshold. There is
+// no equivalent on cgroup v1 hosts.
+func WithMemoryHigh(high int64) SpecOption {
+	return func(spec *specs.Spec) {
+		if spec.Linux.Resources.Unified == nil {
+			spec.Linux.Resources.Unified = map[string]string{}
+		}
+		spec.Linux.Resources.Unified["memory.high"] = strconv.FormatInt(high, 10)
+	}
+}
+
+func WithOOMScoreAdj(adj int) SpecOption {
+	return func(spec *specs.Spec) {
+		spec.Process.OOMScoreAdj = &adj
+	}
+}
+
 func WithPidLimit(limit int64) SpecOption {
 	return func(spec *specs.Spec) {
 		spec.Linux.Resources.Pids = &specs.LinuxPids{
@@ -175,6 +211,89 @@ func WithPidLimit(limit int64) SpecOption {
 	}
 }
 
+func WithCPUQuota(quota int64, period uint64) SpecOption {
+	return func(spec *specs.Spec) {
+		cpu := cpuResources(spec)
+		cpu.Quota = &quota
+		cpu.Period = &period
+	}
+}
+
+// WithCPUShares sets the cgroup v1 CPU shares of the container. On cgroup v2
+// hosts runc converts the shares into an equivalent cpu.weight.
+func WithCPUShares(shares uint64) SpecOption {
+	return func(spec *specs.Spec) {
+		cpuResources(spec).Shares = &shares
+	}
+}
+
+// WithCPUWeight sets the cgroup v2 CPU weight of the container. On cgroup v1
+// hosts the weight is converted into the equivalent number of CPU shares.
+func WithCPUWeight(weight uint64, features sysfeat.Features) SpecOption {
+	return func(spec *specs.Spec) {
+		if !features.CgroupV2 {
+			shares := cpuWeightToShares(weight)
+			cpuResources(spec).Shares = &shares
+			return
+		}
+
+		if spec.Linux.Resources.Unified == nil {
+			spec.Linux.Resources.Unified = map[string]string{}
+		}
+		spec.Linux.Resources.Unified["cpu.weight"] = strconv.FormatUint(weight, 10)
+	}
+}
+
+func WithCPUSet(cpus string) SpecOption {
+	return func(spec *specs.Spec) {
+		cpuResources(spec).Cpus = cpus
+	}
+}
+
+func WithBlockIOWeight(weight uint16) SpecOption {
+	return func(spec *specs.Spec) {
+		blockIOResources(spec).Weight = &weight
+	}
+}
+
+// BlockIODeviceLimit describes the throttling to apply to a single block
+// device. Zero values are left unlimited.
+type BlockIODeviceLimit struct {
+	ReadBPS   uint64
+	WriteBPS  uint64
+	ReadIOPS  uint64
+	WriteIOPS uint64
+}
+
+func WithBlockIODeviceLimit(major, minor int64, limit BlockIODeviceLimit) SpecOption {
+	return func(spec *specs.Spec) {
+		blkio := blockIOResources(spec)
+
+		throttle := func(rate uint64) specs.LinuxThrottleDevice {
+			device := specs.LinuxThrottleDevice{Rate: rate}
+			device.Major = major
+			device.Minor = minor
+			return device
+		}
+
+		if limit.ReadBPS > 0 {
+			blkio.ThrottleReadBpsDevice = append(blkio.ThrottleReadBpsDevice, throttle(limit.ReadBPS))
+		}
+
+		if limit.WriteBPS > 0 {
+			blkio.ThrottleWriteBpsDevice = append(blkio.ThrottleWriteBpsDevice, throttle(limit.WriteBPS))
+		}
+
+		if limit.ReadIOPS > 0 {
+			blkio.ThrottleReadIOPSDevice = append(blkio.ThrottleReadIOPSDevice, throttle(limit.ReadIOPS))
+		}
+
+		if limit.WriteIOPS > 0 {
+			blkio.ThrottleWriteIOPSDevice = append(blkio.ThrottleWriteIOPSDevice, throttle(limit.WriteIOPS))
+		}
+	}
+}
+
 func WithOpenFileLimit(limit uint64) SpecOption {
 	return func(spec *specs.Spec) {
 		spec.Process.Rlimits = append(spec.Process.Rlimits, specs.POSIXRlimit{
@@ -234,6 +353,37 @@ func WithCgroupsPath(path string) SpecOption {
 	}
 }
 
+func memoryResources(spec *specs.Spec) *specs.LinuxMemory {
+	if spec.Linux.Resources.Memory == nil {
+		spec.Linux.Resources.Memory = &specs.LinuxMemory{}
+	}
+
+	return spec.Linux.Resources.Memory
+}
+
+func cpuResources(spec *specs.Spec) *specs.LinuxCPU {
+	if spec.Linux.Resources.CPU == nil {
+		spec.Linux.Resources.CPU = &specs.LinuxCPU{}
+	}
+
+	return spec.Linux.Resources.CPU
+}
+
+func blockIOResources(spec *specs.Spec) *specs.LinuxBlockIO {
+	if spec.Linux.Resources.BlockIO == nil {
+		spec.Linux.Resources.BlockIO = &specs.LinuxBlockIO{}
+	}
+
+	return spec.Linux.Resources.BlockIO
+}
+
+// cpuWeightToShares maps the cgroup v2 weight range [1, 10000] linearly onto
+// the cgroup v1 shares range [2, 262144]. This is the inverse of the
+// conversion runc performs in the other direction.
+func cpuWeightToShares(weight uint64) uint64 {
+	return 2 + ((weight-1)*262142)/9999
+}
+
 func removeNosuidMountOption(opts []string) []string {
 	for i := 0; i < len(opts); i++ {
 		if opts[i] == "nosuid" {
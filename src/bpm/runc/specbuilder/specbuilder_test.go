@@ -23,6 +23,7 @@ import (
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 
 	"bpm/runc/specbuilder"
+	"bpm/sysfeat"
 )
 
 func TestSpecbuilder(t *testing.T) {
@@ -145,6 +146,21 @@ var _ = Describe("SpecBuilder", func() {
 		})
 	})
 
+	Describe("WithCPUWeight", func() {
+		It("maps the ends of the weight range onto the ends of the shares range", func() {
+			spec := specbuilder.Build(specbuilder.WithCPUWeight(1, sysfeat.Features{}))
+			Expect(*spec.Linux.Resources.CPU.Shares).To(Equal(uint64(2)))
+
+			spec = specbuilder.Build(specbuilder.WithCPUWeight(10000, sysfeat.Features{}))
+			Expect(*spec.Linux.Resources.CPU.Shares).To(Equal(uint64(262144)))
+		})
+
+		It("uses the unified cpu.weight key on cgroup v2", func() {
+			spec := specbuilder.Build(specbuilder.WithCPUWeight(50, sysfeat.Features{CgroupV2: true}))
+			Expect(spec.Linux.Resources.Unified).To(HaveKeyWithValue("cpu.weight", "50"))
+		})
+	})
+
 	Describe("DefaultSpec", func() {
 		It("includes seccomp by default", func() {
 			spec := specbuilder.DefaultSpec()
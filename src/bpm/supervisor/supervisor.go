@@ -0,0 +1,197 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+// Package supervisor contains the restart policy decisions and persisted state
+// used by `bpm supervise` to keep a process running.
+package supervisor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"bpm/config"
+)
+
+const (
+	StatusRunning  = "running"
+	StatusBackoff  = "backoff"
+	StatusStopping = "stopping"
+	StatusStopped  = "stopped"
+	StatusFailed   = "failed"
+)
+
+// State is the state of a supervised process. It is written to disk so that
+// other bpm commands can see it.
+type State struct {
+	Status         string    `json:"status"`
+	Restarts       int       `json:"restarts"`
+	LastExitStatus *int      `json:"last_exit_status,omitempty"`
+	LastExitTime   time.Time `json:"last_exit_time,omitempty"`
+}
+
+// ReadState reads the state from the path. The error satisfies os.IsNotExist
+// if the process has never been supervised.
+func ReadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// WriteState atomically replaces the state at the path.
+func WriteState(path string, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// RequestStop tells the supervisor of the process, if there is one, not to
+// restart the process when it next exits. The state left behind by a
+// supervisor which has already finished is removed.
+func RequestStop(path string) error {
+	state, err := ReadState(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	switch state.Status {
+	case StatusRunning, StatusBackoff:
+		state.Status = StatusStopping
+		return WriteState(path, state)
+	case StatusStopped, StatusFailed:
+		return removeState(path)
+	default:
+		return nil
+	}
+}
+
+// Reset removes the state left behind by a supervisor which has finished so
+// that a process started or stopped by hand is not reported with the outcome
+// of an earlier supervisor. The state of a supervisor which is still running
+// is left alone.
+func Reset(path string) error {
+	state, err := ReadState(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if state.Status != StatusStopped && state.Status != StatusFailed {
+		return nil
+	}
+
+	return removeState(path)
+}
+
+func removeState(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Policy decides whether and when a process is restarted after it exits.
+type Policy struct {
+	policy     string
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// NewPolicy creates a Policy from the restart_policy of a process. A process
+// without one is restarted whenever it fails.
+func NewPolicy(cfg *config.RestartPolicy) Policy {
+	if cfg == nil {
+		cfg = &config.RestartPolicy{Policy: config.RestartOnFailure}
+	}
+
+	return Policy{
+		policy:     cfg.Policy,
+		maxRetries: cfg.MaxRetries,
+		backoff:    cfg.ParseBackoff(),
+		maxBackoff: cfg.ParseMaxBackoff(),
+	}
+}
+
+// ShouldRestart returns whether a process which exited with the exit status
+// should be restarted given how many times in a row it has already been
+// restarted.
+func (p Policy) ShouldRestart(exitStatus, restarts int) bool {
+	if p.maxRetries > 0 && restarts >= p.maxRetries {
+		return false
+	}
+
+	switch p.policy {
+	case config.RestartAlways:
+		return true
+	case config.RestartOnFailure:
+		return exitStatus != 0
+	default:
+		return false
+	}
+}
+
+// Backoff returns how long to wait before restarting a process which has
+// already been restarted the given number of times in a row. The wait doubles
+// with each restart up to the max_backoff.
+func (p Policy) Backoff(restarts int) time.Duration {
+	backoff := p.backoff
+	for i := 0; i < restarts && backoff < p.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, p.maxBackoff)
+}
+
+// ResetAfter returns how long a process must run for before its consecutive
+// restarts are forgotten.
+func (p Policy) ResetAfter() time.Duration {
+	return p.maxBackoff
+}
+
+// FinalStatus returns the status of a process which exited with the exit
+// status and will not be restarted.
+func FinalStatus(exitStatus int) string {
+	if exitStatus == 0 {
+		return StatusStopped
+	}
+
+	return StatusFailed
+}
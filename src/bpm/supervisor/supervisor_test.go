@@ -0,0 +1,171 @@
+// Copyright (C) 2017-Present CloudFoundry.org Foundation, Inc. All rights reserved.
+//
+// This program and the accompanying materials are made available under
+// the terms of the under the Apache License, Version 2.0 (the "License”);
+// you may not use this file except in compliance with the License.
+//
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.  See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package supervisor_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"bpm/config"
+	"bpm/supervisor"
+)
+
+var _ = Describe("Supervisor", func() {
+	Describe("Policy", func() {
+		Context("when there is no restart policy", func() {
+			It("restarts the process whenever it fails", func() {
+				policy := supervisor.NewPolicy(nil)
+
+				Expect(policy.ShouldRestart(1, 100)).To(BeTrue())
+				Expect(policy.ShouldRestart(0, 0)).To(BeFalse())
+			})
+		})
+
+		It("never restarts a process with the never policy", func() {
+			policy := supervisor.NewPolicy(&config.RestartPolicy{Policy: config.RestartNever})
+
+			Expect(policy.ShouldRestart(1, 0)).To(BeFalse())
+			Expect(policy.ShouldRestart(0, 0)).To(BeFalse())
+		})
+
+		It("restarts a process which exits successfully with the always policy", func() {
+			policy := supervisor.NewPolicy(&config.RestartPolicy{Policy: config.RestartAlways})
+
+			Expect(policy.ShouldRestart(0, 0)).To(BeTrue())
+			Expect(policy.ShouldRestart(137, 0)).To(BeTrue())
+		})
+
+		It("gives up once the maximum retries have been made", func() {
+			policy := supervisor.NewPolicy(&config.RestartPolicy{Policy: config.RestartAlways, MaxRetries: 3})
+
+			Expect(policy.ShouldRestart(1, 2)).To(BeTrue())
+			Expect(policy.ShouldRestart(1, 3)).To(BeFalse())
+		})
+
+		It("backs off exponentially up to the maximum", func() {
+			policy := supervisor.NewPolicy(&config.RestartPolicy{
+				Policy:     config.RestartOnFailure,
+				Backoff:    "1s",
+				MaxBackoff: "10s",
+			})
+
+			Expect(policy.Backoff(0)).To(Equal(1 * time.Second))
+			Expect(policy.Backoff(1)).To(Equal(2 * time.Second))
+			Expect(policy.Backoff(3)).To(Equal(8 * time.Second))
+			Expect(policy.Backoff(4)).To(Equal(10 * time.Second))
+			Expect(policy.Backoff(1000)).To(Equal(10 * time.Second))
+			Expect(policy.ResetAfter()).To(Equal(10 * time.Second))
+		})
+	})
+
+	Describe("FinalStatus", func() {
+		It("is stopped for a successful exit and failed otherwise", func() {
+			Expect(supervisor.FinalStatus(0)).To(Equal(supervisor.StatusStopped))
+			Expect(supervisor.FinalStatus(2)).To(Equal(supervisor.StatusFailed))
+		})
+	})
+
+	Describe("State", func() {
+		var path string
+
+		BeforeEach(func() {
+			dir, err := os.MkdirTemp("", "supervisor")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(os.RemoveAll, dir)
+
+			path = filepath.Join(dir, "run", "server.supervisor.json")
+		})
+
+		It("round trips the state", func() {
+			exitStatus := 3
+			state := &supervisor.State{
+				Status:         supervisor.StatusBackoff,
+				Restarts:       2,
+				LastExitStatus: &exitStatus,
+				LastExitTime:   time.Unix(1000, 0).UTC(),
+			}
+			Expect(supervisor.WriteState(path, state)).To(Succeed())
+
+			Expect(supervisor.ReadState(path)).To(Equal(state))
+		})
+
+		It("returns a not exist error if there is no state", func() {
+			_, err := supervisor.ReadState(path)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		Describe("RequestStop", func() {
+			It("marks a running process as stopping", func() {
+				Expect(supervisor.WriteState(path, &supervisor.State{Status: supervisor.StatusRunning})).To(Succeed())
+				Expect(supervisor.RequestStop(path)).To(Succeed())
+
+				state, err := supervisor.ReadState(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Status).To(Equal(supervisor.StatusStopping))
+			})
+
+			It("leaves a process which is already stopping alone", func() {
+				Expect(supervisor.WriteState(path, &supervisor.State{Status: supervisor.StatusStopping})).To(Succeed())
+				Expect(supervisor.RequestStop(path)).To(Succeed())
+
+				state, err := supervisor.ReadState(path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Status).To(Equal(supervisor.StatusStopping))
+			})
+
+			It("removes the state of a supervisor which has given up", func() {
+				Expect(supervisor.WriteState(path, &supervisor.State{Status: supervisor.StatusFailed})).To(Succeed())
+				Expect(supervisor.RequestStop(path)).To(Succeed())
+				Expect(path).NotTo(BeAnExistingFile())
+			})
+
+			It("does nothing if the process is not supervised", func() {
+				Expect(supervisor.RequestStop(path)).To(Succeed())
+				Expect(path).NotTo(BeAnExistingFile())
+			})
+		})
+
+		Describe("Reset", func() {
+			It("removes the state of a supervisor which has finished", func() {
+				for _, status := range []string{supervisor.StatusStopped, supervisor.StatusFailed} {
+					Expect(supervisor.WriteState(path, &supervisor.State{Status: status})).To(Succeed())
+					Expect(supervisor.Reset(path)).To(Succeed())
+					Expect(path).NotTo(BeAnExistingFile())
+				}
+			})
+
+			It("leaves the state of a running supervisor alone", func() {
+				for _, status := range []string{supervisor.StatusRunning, supervisor.StatusBackoff, supervisor.StatusStopping} {
+					Expect(supervisor.WriteState(path, &supervisor.State{Status: status})).To(Succeed())
+					Expect(supervisor.Reset(path)).To(Succeed())
+
+					state, err := supervisor.ReadState(path)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(state.Status).To(Equal(status))
+				}
+			})
+
+			It("does nothing if the process is not supervised", func() {
+				Expect(supervisor.Reset(path)).To(Succeed())
+				Expect(path).NotTo(BeAnExistingFile())
+			})
+		})
+	})
+})
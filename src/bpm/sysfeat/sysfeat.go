@@ -20,6 +20,7 @@ package sysfeat
 import (
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/opencontainers/cgroups"
 )
@@ -43,6 +44,12 @@ type Features struct {
 	// filters are architecture-specific and will not work correctly under
 	// Rosetta's x86_64-on-ARM64 emulation.
 	SeccompSupported bool
+	// Whether the system uses the unified cgroup v2 hierarchy. Some resource
+	// limits are expressed differently between cgroup v1 and v2.
+	CgroupV2 bool
+	// Whether the block I/O cgroup controller (io on cgroup v2 or blkio on
+	// cgroup v1) is available for limiting disk bandwidth.
+	IOControllerSupported bool
 }
 
 func Fetch() (*Features, error) {
@@ -52,8 +59,10 @@ func Fetch() (*Features, error) {
 	}
 
 	return &Features{
-		SwapLimitSupported: supported,
-		SeccompSupported:   seccompSupported(),
+		SwapLimitSupported:    supported,
+		SeccompSupported:      seccompSupported(),
+		CgroupV2:              cgroups.IsCgroup2UnifiedMode(),
+		IOControllerSupported: ioControllerSupported(),
 	}, nil
 }
 
@@ -88,6 +97,24 @@ func swapLimitSupportedCgroup1() (bool, error) {
 	return err == nil, nil
 }
 
+// ioControllerSupported reports whether the host has the block I/O controller
+// enabled. Any error determining this is treated as the controller being
+// unavailable: it only matters to jobs which ask for I/O limits and they will
+// get a clear error rather than bpm failing for everyone.
+func ioControllerSupported() bool {
+	subsystems, err := cgroups.GetAllSubsystems()
+	if err != nil {
+		return false
+	}
+
+	controller := "blkio"
+	if cgroups.IsCgroup2UnifiedMode() {
+		controller = "io"
+	}
+
+	return slices.Contains(subsystems, controller)
+}
+
 // seccompSupported returns false when Rosetta binfmt_misc translation is
 // registered on the host. This is the only scenario where BPM needs to
 // disable seccomp: Colima (or similar) VMs on Apple Silicon register